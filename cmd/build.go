@@ -0,0 +1,142 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/let-sh/cli/handler/deploy"
+	"github.com/let-sh/cli/info"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/spf13/cobra"
+)
+
+// buildCmd represents the build command
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build your current project locally",
+	Long: `Run the local build step of lets deploy in current dir, without uploading or deploying.
+Commands are the ones of project type, or build.command of let.json, run by the shell of system
+with env of config and build.env. Static files are expected in build.outputDir, static or the dist dir of project type.
+The output is cached under ~/.let/cache, and reused until lock files, build config or sources change.
+
+e.g. lets build
+e.g. lets build --prod --env-file .env.ci
+e.g. lets build --no-cache
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// build template is queried from let.sh
+		if info.Credentials.LoadToken() == "" {
+			return errs.New(errs.Auth, "please login via `lets login` first")
+		}
+		if inputBuildProd && inputBuildDev {
+			return errs.New(errs.Validation, "--prod and --dev are exclusive")
+		}
+		envFiles, err := envFilePaths(inputBuildEnvFiles)
+		if err != nil {
+			return err
+		}
+
+		opts := deploy.Options{
+			ProjectName: inputBuildProjectName,
+			ProjectType: inputBuildProjectType,
+			EnvFiles:    envFiles,
+			BuildOnly:   true,
+			NoCache:     inputBuildNoCache,
+		}
+		if inputBuildProd {
+			opts.Channel = "prod"
+		}
+		if inputBuildDev {
+			opts.Channel = "dev"
+		}
+		if log.JSON() {
+			// keep stdout for json documents only
+			opts.Stdout = os.Stderr
+		}
+
+		// Ctrl+C kills the build commands
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		pipeline := deploy.NewPipeline(opts, buildListener{})
+		log.BStart("building")
+		err = pipeline.Run(ctx)
+		log.S.StopFail()
+		if err != nil {
+			return err
+		}
+
+		c := pipeline.Context
+		result := map[string]interface{}{
+			"name":       c.Name,
+			"type":       c.Type,
+			"commands":   c.BuildStep.Commands,
+			"output_dir": c.Static,
+			"duration":   c.BuildDuration.Seconds(),
+			"cached":     c.BuildCache != nil,
+		}
+		log.Result(result, func() {
+			if len(c.BuildStep.Commands) == 0 {
+				log.Warning(fmt.Sprintf("nothing to build for %s project, set build.command in let.json to build locally",
+					c.Type))
+				return
+			}
+			fmt.Println("")
+			log.Success(builtMessage(c) + ", output dir: " + c.Static)
+		})
+		return nil
+	},
+}
+
+// buildListener renders the progress of local build, questions are answered as deploying
+type buildListener struct {
+	deployListener
+}
+
+func (buildListener) OnStage(stage deploy.Stage, c *deploy.DeployContext) {
+	if stage == deploy.StageBuild {
+		log.S.StopFail()
+		printBuildStep(c)
+	}
+}
+
+var (
+	inputBuildProjectName string
+	inputBuildProjectType string
+	inputBuildProd        bool
+	inputBuildDev         bool
+	inputBuildEnvFiles    []string
+	inputBuildNoCache     bool
+)
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().StringVarP(&inputBuildProjectName, "project", "p", "", "current project name")
+	buildCmd.Flags().StringVarP(&inputBuildProjectType, "type", "t", "", "current project type, e.g. react")
+	buildCmd.Flags().BoolVarP(&inputBuildProd, "prod", "", false, "build with config of production channel")
+	buildCmd.Flags().BoolVarP(&inputBuildDev, "dev", "", false, "build with config of development channel")
+	buildCmd.Flags().StringArrayVarP(&inputBuildEnvFiles, "env-file", "", nil,
+		"env file loaded after .env files of channel, could be repeated")
+	buildCmd.Flags().BoolVarP(&inputBuildNoCache, "no-cache", "", false,
+		"build without reusing the cached output of unchanged project")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local build cache",
+	Long: `Manage the outputs of local build step cached under ~/.let/cache/<project>.
+An output is reused by lets build and lets deploy until lock files, build config or sources change,
+the 3 most recently used outputs are kept for each project.
+
+e.g. lets cache ls
+e.g. lets cache clear
+e.g. lets cache clear my-site --older-than 168h
+`,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/handler/build"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/spf13/cobra"
+)
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [project]...",
+	Short: "Remove the cached build outputs",
+	Long: `Remove the cached build outputs of projects, all projects if none is given.
+With --older-than, only the outputs not used for the duration are removed.
+
+e.g. lets cache clear
+e.g. lets cache clear my-site
+e.g. lets cache clear --older-than 168h
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if inputCacheOlderThan < 0 {
+			return errs.New(errs.Validation, "--older-than should be positive")
+		}
+		var before time.Time
+		if inputCacheOlderThan > 0 {
+			before = time.Now().Add(-inputCacheOlderThan)
+		}
+
+		removed, err := build.DefaultCache().Remove(before, args...)
+		if err != nil {
+			return err
+		}
+		var size int64
+		for _, e := range removed {
+			size += e.Size
+		}
+		log.Result(map[string]interface{}{"removed": removed, "size": size}, func() {
+			if len(removed) == 0 {
+				log.Warning("no cached build output to remove")
+				return
+			}
+			log.Success(fmt.Sprintf("removed %d cached outputs, %s freed", len(removed), datasize.ByteSize(size).HR()))
+		})
+		return nil
+	},
+}
+
+var inputCacheOlderThan time.Duration
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cacheClearCmd.Flags().DurationVarP(&inputCacheOlderThan, "older-than", "", 0,
+		"only remove the outputs not used for the duration, e.g. 168h")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/handler/build"
+	"github.com/let-sh/cli/log"
+	"github.com/spf13/cobra"
+)
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:     "ls [project]...",
+	Aliases: []string{"list"},
+	Short:   "List the cached build outputs",
+	Long: `List the cached build outputs of projects, all projects if none is given
+
+e.g. lets cache ls
+e.g. lets cache ls my-site
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := build.DefaultCache().List(args...)
+		if err != nil {
+			return err
+		}
+
+		log.Result(entries, func() {
+			if len(entries) == 0 {
+				log.Warning("no cached build output")
+				return
+			}
+
+			var size int64
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "PROJECT\tKEY\tOUTPUT\tFILES\tSIZE\tBUILT\tUSED")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", e.Project, e.Key, e.OutputDir, e.Files,
+					datasize.ByteSize(e.Size).HR(), e.CreatedAt.Format("2006-01-02 15:04"),
+					e.UsedAt.Format("2006-01-02 15:04"))
+				size += e.Size
+			}
+			w.Flush()
+			fmt.Printf("\n%d outputs, %s in total\n", len(entries), datasize.ByteSize(size).HR())
+		})
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheListCmd)
+}
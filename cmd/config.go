@@ -0,0 +1,36 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect config of project",
+	Long: `Inspect let.json, let.yaml or let.toml of project
+
+e.g. lets config show --prod
+e.g. lets config validate
+e.g. lets config schema
+`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/let-sh/cli/handler/config"
+	"github.com/spf13/cobra"
+)
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print JSON Schema of let.json",
+	Long: `Print JSON Schema of let.json, for completion and validation in editors.
+It's the same as schema/let.schema.json of let.sh cli repository.
+
+e.g. lets config schema > let.schema.json
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		content, err := config.MarshalSchema()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/let-sh/cli/handler/config"
+	"github.com/let-sh/cli/handler/deploy"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/types"
+	"github.com/spf13/cobra"
+)
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show [dir]",
+	Short: "Show the effective config of project",
+	Long: `Show the config lets deploy would deploy from project under dir, default to current dir.
+Config is merged in the order of cli flag > config file > auto saved config > detected,
+and the environment of channel is merged into the config file.
+Channel is the preference of user unless --prod or --dev is specified, values of env are masked.
+
+e.g. lets config show
+e.g. lets config show --prod
+e.g. lets config show --layers -t static ./web
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		if !deploy.FileExists(dir) {
+			return errs.New(errs.Validation, "no such directory: "+dir)
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if inputShowProd && inputShowDev {
+			return errs.New(errs.Validation, "--prod and --dev are exclusive")
+		}
+
+		envFiles, err := envFilePaths(inputShowEnvFiles)
+		if err != nil {
+			return err
+		}
+
+		opts := deploy.Options{
+			Dir:         dir,
+			ProjectName: inputShowProjectName,
+			ProjectType: inputShowProjectType,
+			EnvFiles:    envFiles,
+		}
+		if inputShowProd {
+			opts.Channel = "prod"
+		}
+		if inputShowDev {
+			opts.Channel = "dev"
+		}
+		if cmd.Flags().Changed("cn") {
+			opts.CN = &inputShowCN
+		}
+		pipeline := deploy.NewPipeline(opts, nil)
+		if err := pipeline.ResolveConfig(context.Background()); err != nil {
+			return err
+		}
+		file, err := config.Find(dir)
+		if err != nil {
+			return err
+		}
+
+		result := map[string]interface{}{
+			"channel": pipeline.Context.Channel,
+			"file":    file,
+			"config":  env.MaskedConfig(pipeline.Context.LetConfig),
+		}
+		layers := []struct {
+			name   string
+			config types.LetConfig
+		}{
+			{"detected", pipeline.Layers.Detected},
+			{"auto saved config", pipeline.Layers.Saved},
+			{"config file", pipeline.Layers.File},
+			{"cli flag", pipeline.Layers.Flags},
+		}
+		if inputShowLayers {
+			result["layers"] = map[string]types.LetConfig{
+				"detected": env.MaskedConfig(pipeline.Layers.Detected),
+				"saved":    env.MaskedConfig(pipeline.Layers.Saved),
+				"file":     env.MaskedConfig(pipeline.Layers.File),
+				"flags":    env.MaskedConfig(pipeline.Layers.Flags),
+			}
+		}
+		log.Result(result, func() {
+			if file != "" {
+				fmt.Println("file:    " + file)
+			}
+			if pipeline.Context.Channel != "" {
+				fmt.Println("channel: " + pipeline.Context.Channel)
+			}
+			if inputShowLayers {
+				for _, layer := range layers {
+					fmt.Println(log.CyanBold("\n" + layer.name))
+					printConfig(layer.config)
+				}
+				fmt.Println(log.CyanBold("\neffective"))
+			}
+			printConfig(pipeline.Context.LetConfig)
+		})
+		return nil
+	},
+}
+
+// printConfig prints c in json with values of env masked
+func printConfig(c types.LetConfig) {
+	content, err := json.MarshalIndent(env.MaskedConfig(c), "", "  ")
+	if err != nil {
+		log.Warning(err.Error())
+		return
+	}
+	fmt.Println(string(content))
+}
+
+var (
+	inputShowProjectName string
+	inputShowProjectType string
+	inputShowProd        bool
+	inputShowDev         bool
+	inputShowCN          bool
+	inputShowLayers      bool
+	inputShowEnvFiles    []string
+)
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+
+	configShowCmd.Flags().StringVarP(&inputShowProjectName, "project", "p", "", "current project name")
+	configShowCmd.Flags().StringVarP(&inputShowProjectType, "type", "t", "", "current project type, e.g. react")
+	configShowCmd.Flags().BoolVarP(&inputShowProd, "prod", "", false, "show config of production channel")
+	configShowCmd.Flags().BoolVarP(&inputShowDev, "dev", "", false, "show config of development channel")
+	configShowCmd.Flags().BoolVarP(&inputShowCN, "cn", "", false, "show config of deploying to mainland China")
+	configShowCmd.Flags().StringArrayVarP(&inputShowEnvFiles, "env-file", "", nil,
+		"env file loaded after .env files of channel, could be repeated")
+	configShowCmd.Flags().BoolVarP(&inputShowLayers, "layers", "", false,
+		"show each layer of config in the order of merging")
+}
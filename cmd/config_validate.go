@@ -0,0 +1,85 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/let-sh/cli/handler/config"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/spf13/cobra"
+)
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [dir]",
+	Short: "Validate config file",
+	Long: `Validate let.json, let.yaml or let.toml of project under dir, default to current dir.
+Unknown keys, type errors and problems of redirects, rewrites and headers are reported with positions,
+environments are checked merged into the config.
+
+e.g. lets config validate
+e.g. lets config validate ./web
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		file, err := config.Find(dir)
+		if err != nil {
+			return err
+		}
+		if file == "" {
+			return errs.Newf(errs.Validation, "config file not found under %s, expect one of %s", dir,
+				strings.Join(config.FileNames, ", "))
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		problems := config.Validate(file, data)
+		if problems == nil {
+			problems = []config.Problem{}
+		}
+		log.Result(problems, func() {
+			if len(problems) == 0 {
+				log.Success(file + " is valid")
+				return
+			}
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+		})
+		switch len(problems) {
+		case 0:
+			return nil
+		case 1:
+			return errs.Newf(errs.Validation, "1 problem found in %s", file)
+		default:
+			return errs.Newf(errs.Validation, "%d problems found in %s", len(problems), file)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -17,15 +17,11 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/muesli/termenv"
-	"io/ioutil"
 	"os"
-	"os/exec"
 	"os/signal"
-	"os/user"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -36,499 +32,503 @@ import (
 	"github.com/let-sh/cli/handler/deploy"
 	"github.com/let-sh/cli/info"
 	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/requests"
-	"github.com/let-sh/cli/requests/graphql"
 	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/ui"
 	"github.com/let-sh/cli/utils"
 	"github.com/let-sh/cli/utils/cache"
 	"github.com/let-sh/cli/utils/s3"
+	"github.com/logrusorgru/aurora"
 	"github.com/manifoldco/promptui"
-	"github.com/mholt/archiver/v3"
-	c "github.com/otiai10/copy"
-	ignore "github.com/sabhiram/go-gitignore"
-	gql "github.com/shurcooL/graphql"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-var DeploymentID string
-
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploy your current project to let.sh",
 	Long:  `Deploy your current project to let.sh with a single command line`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Setup our Ctrl+C handler
-		SetupCloseHandler()
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// check whether user is logged in
 		if info.Credentials.LoadToken() == "" {
-			log.Warning("please login via `lets login` first")
-			return
+			return errs.New(errs.Auth, "please login via `lets login` first")
 		}
 
-		log.BStart("deploying")
-
-		// check deployment directory is valid
-		{
-			// check not home dir
-			dir, _ := os.Getwd()
-			usr, _ := user.Current()
-			if dir == usr.HomeDir {
-				log.Error(errors.New("currently under home dir, please switch to your project dir"))
-				return
-			}
+		if inputListFiles && !inputDryRun && !inputPlan {
+			return errs.New(errs.Validation, "--list-files requires --dry-run or --plan")
+		}
+		if inputDryRun && inputPlan {
+			return errs.New(errs.Validation, "--dry-run and --plan are exclusive")
+		}
 
-			// limit files to 10000
-			files, _ := ioutil.ReadDir("./")
-			if len(files) > 10000 {
-				log.Error(errors.New("too many files in current dir, please check whether in the " +
-					"correct directory"))
-				return
+		compression, err := deploy.ParseCompression(inputCompression)
+		if err != nil {
+			return errs.Wrap(errs.Validation, err)
+		}
+		if inputConcurrency < 1 {
+			return errs.New(errs.Validation, "--concurrency must be at least 1")
+		}
+		envFiles, err := envFilePaths(inputEnvFiles)
+		if err != nil {
+			return err
+		}
+		var maxBandwidth datasize.ByteSize
+		if inputMaxBandwidth != "" {
+			if err := maxBandwidth.UnmarshalText([]byte(inputMaxBandwidth)); err != nil {
+				return errs.Newf(errs.Validation, "invalid --max-bandwidth %q, expect size per second like 2MB",
+					inputMaxBandwidth)
 			}
 		}
 
-		// merge config
-		// cli flag > config file > auto saved config > detected config & types
-		{
-			// detect current project config first
-			// init current project name
-			dir, _ := os.Getwd()
-			deploymentCtx.Name = filepath.Base(dir)
-
-			// detect current project info
-			deploymentCtx.DetectProjectType()
+		// Ctrl+C stops the pipeline, and cancels the triggered deployment
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		opts := deploy.Options{
+			ProjectName:  inputProjectName,
+			ProjectType:  inputProjectType,
+			Detach:       inputDetach,
+			CheckRunID:   inputCheckRunID,
+			DryRun:       inputDryRun,
+			Plan:         inputPlan,
+			Compression:  compression,
+			Concurrency:  inputConcurrency,
+			MaxBandwidth: maxBandwidth,
+			EnvFiles:     envFiles,
+			NoCache:      inputNoCache,
+		}
+		if inputProd { // if manually set to deploy to production, rewrite channel
+			opts.Channel = "prod"
+		}
+		if inputDev { // if manually set to deploy to development, rewrite channel
+			opts.Channel = "dev"
+		}
+		if cmd.Flags().Changed("cn") { // if user customized cn flag
+			opts.CN = &inputCN
+		}
+		if cmd.Flags().Changed("web3") {
+			opts.Web3 = &inputWeb3
+		}
+		if log.JSON() {
+			// keep stdout for json documents only
+			opts.Stdout = os.Stderr
+		}
 
-			// Step2: get cache config
-			deploymentCtx.LoadProjectInfoCache()
+		ws, packages, err := resolveWorkspacePackages(inputFilters, inputChangedSince)
+		if err != nil {
+			return err
+		}
 
-			// Step3: load user config and environment variables
-			deploymentCtx.LoadLetJson()
-			err := deploymentCtx.LoadEnvFiles()
+		// not under workspace, deploy current dir
+		if ws == nil {
+			result, print, err := runDeployPipeline(ctx, opts)
 			if err != nil {
-				log.Error(err)
-				return
-			}
-
-			// Step4: merge cli flag config
-			deploymentCtx.LoadCliFlag(inputProjectName, inputProjectType)
-			deploymentCtx.Web3 = &inputWeb3
-
-			// load cn
-			// if user customized cn flag
-			deploymentCtx.LoadRegion(cmd, inputCN)
-		}
-
-		if deploymentCtx.Type == "unknown" {
-			log.S.StopFail()
-			log.Warning("unknown project type, please check your project directory. " +
-				"or you could specify project type with `-t` flag")
-			return
-		}
-
-		// check project exists
-		// if not exists, tell to create
-		// and confirm project configuration
-		if !deploymentCtx.ConfirmProject() {
-			log.Warning("deploy canceled")
-			return
-		}
-
-		// check if user dir is changed
-		if _, ok := cache.ProjectsInfo[deploymentCtx.Name]; ok {
-			pwd, _ := os.Getwd()
-
-			logrus.Debug("cached project dir: ", cache.ProjectsInfo[deploymentCtx.Name].Dir)
-			logrus.Debug("current project dir: ", pwd)
-
-			if pwd != cache.ProjectsInfo[deploymentCtx.Name].Dir {
-				log.S.StopFail()
-
-				if !inputAssumeYes {
-					// if current dir is not previous dir
-					prompt := promptui.Prompt{
-						Label:   "Detected your project dir changed, continue deployment?[Y/n]",
-						Default: "Y",
-						Validate: func(input string) error {
-							if utils.ItemExists([]string{"", "n", "N", "No", "Y", "y", "yes", "Yes"}, input) {
-								return nil
-							}
-							return errors.New("no matching input")
-						},
-					}
-
-					result, err := prompt.Run()
-					if err != nil {
-						log.Error(err)
-						return
-					}
-
-					if utils.ItemExists([]string{"n", "N", "No"}, result) {
-						log.S.StopFail()
-						log.Warning("Deployment canceled")
-						return
-					}
-				}
-
-				log.BStart("deploying")
+				return err
 			}
+			log.Result(result, print)
+			return nil
 		}
 
-		// make pre deploy request
-		{
-			query := struct {
-				graphql.QueryCheckDeployCapability
-				graphql.QueryBuildTemplate
-				graphql.QueryStsToken
-				graphql.QueryPreference
-			}{}
-			err := graphql.NewClient().Query(context.Background(), &query, map[string]interface{}{
-				"projectName": gql.String(deploymentCtx.Name),
-				"tokenType":   gql.String("buildBundle"),
-				"type":        gql.String(deploymentCtx.Type),
-				"cn":          gql.Boolean(*deploymentCtx.CN),
-				"name":        gql.String("channel"),
-			})
-
-			var requestError *gql.RequestError
-			var graphqlError *gql.GraphQLError
-			if errors.As(err, &graphqlError) {
-				log.Error(errors.New(graphqlError.GraphqlErrors[0].Message))
-				return
-			}
-
-			if errors.As(err, &requestError) {
-				log.Error(requestError)
-				return
-			}
-
-			deploymentCtx.PreDeployRequest = query
-
-			// check whether is dynamic project
-			if deploymentCtx.LetConfig.Web3 != nil {
-				if deploymentCtx.PreDeployRequest.BuildTemplate.ContainsDynamic && *deploymentCtx.LetConfig.Web3 {
-					log.Warning("you cannot deploy dynamic project to web3 infra yet")
-					return
-				}
-			}
-
+		if len(packages) > 1 && inputProjectName != "" {
+			return errs.New(errs.Validation, "cannot specify project name for multiple packages")
 		}
 
-		// get project type config from api
-		log.S.StopFail()
-
-		fmt.Println("")
-		fmt.Println(log.CyanBold("Detected Project Info"))
-		fmt.Println("name:", termenv.String(deploymentCtx.Name).Bold().String())
-		fmt.Println("type:", termenv.String(deploymentCtx.Type).Bold().String())
-		fmt.Println("")
+		results := []map[string]interface{}{}
+		for _, pkg := range packages {
+			o := opts
+			o.Dir = pkg.Dir
+			o.Root = ws.Root
+			o.Include = ws.UploadPaths(pkg)
 
-		{
-			if deploymentCtx.Static == "" {
-				deploymentCtx.Static = deploymentCtx.PreDeployRequest.BuildTemplate.DistDir
+			if len(packages) > 1 && !log.JSON() {
+				fmt.Println(log.CyanBold("\nDeploying package " + pkg.Name))
 			}
-		}
-
-		// if contains static, upload static files to s3
-		dirPath := deploymentCtx.Static
-		if len(dirPath) == 0 {
-			dirPath = "./"
-		}
-		if deploymentCtx.PreDeployRequest.BuildTemplate.ContainsStatic {
-			if utils.ItemExists([]string{"static"}, deploymentCtx.Type) {
-				// todo: merge static dir value source
-				if err := s3.UploadDirToStaticSource(dirPath, deploymentCtx.Name, deploymentCtx.Name+"-"+deploymentCtx.PreDeployRequest.CheckDeployCapability.HashID, *deploymentCtx.CN); err != nil {
-					log.Error(err)
-					return
-				}
-			} else {
-				if deploymentCtx.PreDeployRequest.BuildTemplate.LocalCompiling {
-					for _, command := range deploymentCtx.PreDeployRequest.BuildTemplate.CompileCommands {
-						command := strings.Split(command, " ")
-						c := exec.Command(command[0], command[1:]...)
-						c.Stdout = os.Stdout
-						c.Stderr = os.Stderr
-
-						// FIXME: handler exit code not 0
-						err := c.Run()
-						if err != nil {
-							log.Error(err)
-							return
-						}
-
-						if c.ProcessState.ExitCode() != 0 {
-							log.Error(errors.New("build error"))
-							return
-						}
-					}
-				}
 
-				if err := s3.UploadDirToStaticSource(deploymentCtx.Static, deploymentCtx.Name, deploymentCtx.Name+"-"+deploymentCtx.PreDeployRequest.CheckDeployCapability.HashID, *deploymentCtx.CN); err != nil {
-					log.Error(err)
-					return
-				}
+			result, print, err := runDeployPipeline(ctx, o)
+			if err != nil {
+				return errs.Wrap(errs.KindOf(err), fmt.Errorf("%s: %w", pkg.Name, err))
 			}
-		}
-
-		// if contains dynamic, upload dynamic files to s3
-		// then trigger deployment
-		if deploymentCtx.PreDeployRequest.BuildTemplate.ContainsDynamic {
-			//
-			//// create temp dir
-			//dir := os.TempDir()
-			//
-			//defer os.RemoveAll(dir)
-			////fmt.Println(dir)
-			////os.MkdirAll(dir+"/source", os.ModePerm)
-			//
-			//// copy current dir to temp dir
-			//c.Copy("./", dir+"/"+deploymentCtx.Name+"-"+hashID)
-			dirPath, _ = os.Getwd()
-
-			// remove if not clean
-			//os.Remove(dir + "/" + deploymentCtx.Name + "-" + hashID + ".tar.gz")
-
-			// Read directory files
-			var names []string
-			err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-				if !info.IsDir() {
-					names = append(names, path)
-				}
+			if len(packages) == 1 {
+				log.Result(result, print)
 				return nil
-			})
-			if err != nil {
-				log.Error(err)
-				return
 			}
+			if !log.JSON() {
+				print()
+			}
+			result["package"] = pkg.Name
+			results = append(results, result)
+		}
 
-			// respect .gitignore and .letignore
-			if _, err := os.Stat(filepath.Join(dirPath, ".gitignore")); err == nil {
-				// match a file against a particular .gitignore
-				i, _ := ignore.CompileIgnoreFileAndLines(filepath.Join(dirPath, ".gitignore"),
-					"node_modules", // nodejs
-				)
-
-				tmp := []string{}
-				for _, v := range names {
-					if !i.MatchesPath(v) {
-						tmp = append(tmp, v)
-					}
-				}
-
-				names = tmp
+		log.Result(results, func() {
+			if len(results) == 0 {
+				log.Warning("no package changed since " + inputChangedSince)
 			}
+		})
+		return nil
+	},
+}
 
-			// .letignore
-			if _, err := os.Stat(filepath.Join(dirPath + ".letignore")); err == nil {
-				// match a file against a particular .gitignore
-				i, _ := ignore.CompileIgnoreFile(filepath.Join(dirPath + ".letignore"))
+// runDeployPipeline deploys the project of opts,
+// returns the result document and the function to print it
+func runDeployPipeline(ctx context.Context, opts deploy.Options) (result map[string]interface{}, print func(),
+	err error) {
+	pipeline := deploy.NewPipeline(opts, deployListener{dir: opts.Dir})
+
+	switch {
+	case opts.DryRun:
+		log.BStart("listing files")
+	case opts.Plan:
+		log.BStart("planning")
+	default:
+		log.BStart("deploying")
+	}
+	if err := pipeline.Run(ctx); err != nil {
+		log.S.StopFail()
+		return nil, nil, err
+	}
+	log.S.StopFail()
+
+	if opts.DryRun {
+		result, print = dryRunResult(pipeline)
+		return result, print, nil
+	}
+	if opts.Plan {
+		result, print = planResult(pipeline)
+		return result, print, nil
+	}
+
+	if opts.Detach {
+		result = map[string]interface{}{
+			"id":     pipeline.Deployment.ID,
+			"status": pipeline.Deployment.Status,
+		}
+		return result, func() {
+			log.Success("triggered deployment succeeded")
+		}, nil
+	}
+
+	name := pipeline.Context.Name
+	result = map[string]interface{}{
+		"id":      pipeline.Deployment.ID,
+		"url":     "https://" + pipeline.Status.TargetFQDN,
+		"details": "https://let.sh/console/projects/" + name + "/details",
+		"web3":    pipeline.Status.Web3,
+	}
+	return result, func() {
+		printDeploymentResult(name, pipeline.Status.TargetFQDN, pipeline.Status.Web3)
+	}, nil
+}
 
-				tmp := []string{}
-				for _, v := range names {
-					if !i.MatchesPath(v) {
-						tmp = append(tmp, v)
-					}
-				}
-				names = tmp
+// dryRunResult returns the files would be shipped, paths are listed only with --list-files
+func dryRunResult(pipeline *deploy.Pipeline) (result map[string]interface{}, print func()) {
+	c, files := pipeline.Context, pipeline.Files
+	result = map[string]interface{}{
+		"name":        c.Name,
+		"type":        c.Type,
+		"static_dir":  files.StaticDir,
+		"static_size": files.StaticSize,
+		"source_size": files.SourceSize,
+	}
+	if inputListFiles {
+		result["static"] = files.Static
+		result["source"] = files.Source
+	} else {
+		result["static_count"] = len(files.Static)
+		result["source_count"] = len(files.Source)
+	}
+
+	return result, func() {
+		fmt.Println(log.CyanBold("Detected Project Info"))
+		fmt.Println("name:", termenv.String(c.Name).Bold().String())
+		fmt.Println("type:", termenv.String(c.Type).Bold().String())
+
+		if files.StaticDir != "" {
+			fmt.Println("")
+			staticDir := filepath.FromSlash(files.StaticDir)
+			if !filepath.IsAbs(staticDir) {
+				staticDir = filepath.Join(pipeline.Options.Dir, staticDir)
 			}
-
-			// calculate files size
-			if size, err := utils.GetFilesSize(names); err != nil {
-				log.Error(err)
-				return
+			if _, err := os.Stat(staticDir); err != nil {
+				fmt.Printf("static files: %s not found, build the project first\n", c.Static)
 			} else {
-				// source code is too big
-				// < 20 MB directly upload
-				// 20 MB <= files < 40 MB confirm
-				// >= 40 MB abort
-				if uint64(size) > 20*datasize.MB.Bytes() {
-					log.Warning(`your directory is too big, larger than 20 MB.
-you could remove the irrelevant via .letignore or gitignore.`)
-					return
-				}
+				fmt.Printf("static files from %s: %d files, %s\n", c.Static, len(files.Static),
+					datasize.ByteSize(files.StaticSize).HR())
 			}
-
-			// copy to temp dir
-			tempDir, _ := ioutil.TempDir("", "upload")
-			defer os.RemoveAll(tempDir)
-			for _, f := range names {
-				toName := strings.Replace(f, dirPath, tempDir+"/", 1)
-				err := c.Copy(f, toName)
-				if err != nil {
-					log.Error(err)
+			if inputListFiles {
+				for _, f := range files.Static {
+					fmt.Println("  " + f)
 				}
 			}
-
-			// copy to temp dir
-			tempZipDir, _ := ioutil.TempDir("", "zip")
-			defer os.RemoveAll(tempZipDir)
-
-			// switch dir
-			os.Chdir(tempDir) // switch to temp directory
-			err = archiver.Archive([]string{"."}, tempZipDir+"/"+deploymentCtx.Name+"-"+deploymentCtx.PreDeployRequest.CheckDeployCapability.HashID+".tar.gz")
-			os.Chdir(dirPath) // switch back
-
-			if err != nil {
-				log.Error(err)
-				return
+		}
+		if len(files.Source) > 0 {
+			fmt.Println("")
+			fmt.Printf("source files: %d files, %s\n", len(files.Source), datasize.ByteSize(files.SourceSize).HR())
+			if inputListFiles {
+				for _, f := range files.Source {
+					fmt.Println("  " + f)
+				}
 			}
-			s3.UploadFileToCodeSource(tempZipDir+"/"+deploymentCtx.Name+"-"+deploymentCtx.PreDeployRequest.CheckDeployCapability.HashID+".tar.gz", deploymentCtx.Name+"-"+deploymentCtx.PreDeployRequest.CheckDeployCapability.HashID+".tar.gz", deploymentCtx.Name, *deploymentCtx.CN)
 		}
+	}
+}
 
-		logrus.WithFields(logrus.Fields{
-			"json": deploymentCtx,
-		}).Debugln("deploymentCtx")
-
-		configBytes, _ := json.Marshal(deploymentCtx)
-
-		// determine which channel to deploy
-		channel := deploymentCtx.PreDeployRequest.Preference
-
-		if inputProd == true { // if manually set to deploy to production, rewrite channel
-			channel = "prod"
+// planResult returns the plan with the changes since the last deployment recorded,
+// unchanged files are printed only with --list-files
+func planResult(pipeline *deploy.Pipeline) (result map[string]interface{}, print func()) {
+	plan, previous := pipeline.Plan, pipeline.Previous
+	diff := plan.Diff(previous)
+	changes := []deploy.Change{}
+	for _, c := range diff {
+		if c.Op != "" {
+			changes = append(changes, c)
 		}
-		if inputDev == true { // if manually set to deploy to production, rewrite channel
-			channel = "dev"
+	}
+	result = map[string]interface{}{
+		"plan":     plan,
+		"changes":  changes,
+		"previous": nil,
+	}
+	if previous != nil {
+		result["previous"] = map[string]interface{}{"id": previous.ID, "deployed_at": previous.DeployedAt}
+	}
+
+	return result, func() {
+		fmt.Println(log.CyanBold(fmt.Sprintf("Plan of %s to %s channel", plan.Name, plan.Channel)))
+		if previous != nil {
+			fmt.Printf("compared with deployment %s at %s\n", previous.ID,
+				previous.DeployedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Println("no deployment of this channel recorded on this machine, all is new")
 		}
+		fmt.Println("")
 
-		var deployment struct {
-			ID           string `json:"id"`
-			TargetFQDN   string `json:"targetFQDN"`
-			NetworkStage string `json:"networkStage"`
-			PackerStage  string `json:"packerStage"`
-			Status       string `json:"status"`
-			Project      struct {
-				ID string `json:"id"`
-			} `json:"project"`
+		for _, c := range diff {
+			file := strings.HasPrefix(c.Path, "files.static/") || strings.HasPrefix(c.Path, "files.source/")
+			if file && c.Op == "" && !inputListFiles {
+				continue
+			}
+			printChange(c, file)
 		}
-		var err error
-
-		if inputCheckRunID == 0 {
+		if len(changes) == 0 {
+			fmt.Println("")
+			log.Success("no changes since the last deployment")
+		}
+	}
+}
 
-			deployment, err = requests.Deploy(deploymentCtx.Type, deploymentCtx.Name, string(configBytes), channel,
-				*deploymentCtx.CN)
-		} else {
+// printChange prints a line of plan diff, in the color of its op
+func printChange(c deploy.Change, file bool) {
+	text := c.Path + ": " + c.New
+	switch {
+	case file:
+		text = c.Path
+	case c.Op == "-":
+		text = c.Path + ": " + c.Old
+	case c.Op == "~" && c.Old == c.New:
+		text += " (changed)"
+	case c.Op == "~":
+		text = c.Path + ": " + c.Old + " -> " + c.New
+	}
+
+	switch c.Op {
+	case "+":
+		fmt.Println(log.Green("+ " + text))
+	case "-":
+		fmt.Println(log.Red("- " + text))
+	case "~":
+		fmt.Println(log.Yellow("~ " + text))
+	default:
+		fmt.Println("  " + text)
+	}
+}
 
-			deployment, err = requests.DeployWithCheckRunID(deploymentCtx.Type, deploymentCtx.Name, string(configBytes),
-				channel,
-				*deploymentCtx.CN, inputCheckRunID)
-		}
+// deployListener renders the progress of deploy pipeline
+type deployListener struct {
+	// dir is the project dir, default to current dir
+	dir string
+}
 
-		if err != nil {
-			log.Error(err)
-			return
-		}
+func (deployListener) OnStage(stage deploy.Stage, c *deploy.DeployContext) {
+	switch stage {
+	case deploy.StageBuild:
+		// get project type config from api
+		log.S.StopFail()
 
-		if inputDetach {
-			log.S.StopFail()
-			log.Success("triggered deployment succeeded")
-			return
+		if log.JSON() {
+			log.Event("detected", map[string]interface{}{
+				"name": c.Name,
+				"type": c.Type,
+			})
+		} else {
+			fmt.Println("")
+			fmt.Println(log.CyanBold("Detected Project Info"))
+			fmt.Println("name:", termenv.String(c.Name).Bold().String())
+			fmt.Println("type:", termenv.String(c.Type).Bold().String())
+			fmt.Println("")
 		}
+		printBuildStep(c)
+	case deploy.StageUpload:
+		printBuilt(c)
+	case deploy.StageAwait:
+		log.BStart("deploying")
+	}
+}
 
-		DeploymentID = deployment.ID
-
-		pwd, _ := os.Getwd()
+// printBuildStep prints the heading of local build step, output of commands follows
+func printBuildStep(c *deploy.DeployContext) {
+	if len(c.BuildStep.Commands) == 0 {
+		return
+	}
+	if log.JSON() {
+		log.Event("build", map[string]interface{}{"commands": c.BuildStep.Commands})
+		return
+	}
+	fmt.Println(log.CyanBold("Build"))
+}
 
-		// save deployment info
-		cache.SaveProjectInfo(types.Project{
-			ID:           deployment.Project.ID,
-			Name:         deploymentCtx.Name,
-			Dir:          pwd,
-			Type:         deploymentCtx.Type,
-			ServeCommand: cache.ProjectsInfo[deploymentCtx.Name].ServeCommand,
+// printBuilt prints the time taken by local build step, if run
+func printBuilt(c *deploy.DeployContext) {
+	if c.BuildDuration == 0 {
+		return
+	}
+	if log.JSON() {
+		log.Event("built", map[string]interface{}{
+			"duration": c.BuildDuration.Seconds(),
+			"cached":   c.BuildCache != nil,
 		})
+		return
+	}
+	fmt.Println("")
+	log.Success(builtMessage(c))
+	fmt.Println("")
+}
 
-		log.BStart("deploying")
+// builtMessage tells how the output of build step is made
+func builtMessage(c *deploy.DeployContext) string {
+	if c.BuildCache != nil {
+		return fmt.Sprintf("nothing changed since the build of %s, reused the cached output",
+			c.BuildCache.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return "built in " + c.BuildDuration.Round(time.Millisecond).String()
+}
 
-		// awaiting deployment result
-		for {
-			currentStatus, err := requests.GetDeploymentStatus(deployment.ID)
+func (l deployListener) OnDeployment(d deploy.Deployment, c *deploy.DeployContext) {
+	dir := l.dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	// save deployment info
+	err := cache.SaveProjectInfo(types.Project{
+		ID:           d.ProjectID,
+		Name:         c.Name,
+		Dir:          dir,
+		Type:         c.Type,
+		ServeCommand: cache.ProjectsInfo[c.Name].ServeCommand,
+	})
+	if err != nil {
+		logrus.WithError(err).Debugln("save project info")
+	}
+}
 
-			// handle logging
-			if err != nil {
-				log.Error(err)
-			}
+func (deployListener) OnStatus(s deploy.DeploymentStatus) {
+	// stages:
+	// * Queuing
+	// * Building
+	switch s.Status {
+	case "Queuing":
+		log.BUpdate("queuing")
+	case "Running":
+		if s.PackerStage == "Build" {
+			log.BUpdate("building")
+		}
+	}
+}
 
-			// stages:
-			// * Queuing
-			// * Building
-			switch currentStatus.Status {
-			case "Queuing":
-				log.BUpdate("queuing")
-			case "Running":
-				if currentStatus.PackerStage == "Build" {
-					log.BUpdate("building")
-				}
-			}
-			//log.BUpdate(" NetworkStage: " + currentStatus.NetworkStage + ", PackerStage: " + currentStatus.PackerStage + ", Status: " + currentStatus.Status)
+func (deployListener) Choose(candidates []deploy.Candidate, c *deploy.DeployContext) (deploy.Candidate, error) {
+	if inputAssumeYes || log.JSON() {
+		return candidates[0], nil
+	}
+	log.S.StopFail()
+	defer log.BStart("deploying")
+
+	var items []string
+	for _, candidate := range candidates {
+		items = append(items, fmt.Sprintf("%s (%d%%, %s)", candidate.Type, candidate.Confidence,
+			strings.Join(candidate.Evidence, ", ")))
+	}
+	prompt := promptui.Select{
+		Label: "Several project types detected, please select one",
+		Items: items,
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		if errors.Is(err, promptui.ErrInterrupt) {
+			return deploy.Candidate{}, errs.New(errs.Canceled, "deployment canceled")
+		}
+		return deploy.Candidate{}, err
+	}
+	return candidates[i], nil
+}
 
-			if currentStatus.Done {
-				if currentStatus.Status == "Failed" {
-					log.Error(errors.New("build logs: " + currentStatus.ErrorLogs))
-					break
-				}
-				// write review url to clipboard
-
-				writeClipBoardError := clipboard.WriteAll("https://" + currentStatus.TargetFQDN)
-
-				log.S.StopFail()
-				//fmt.Println(
-				//	color.New(color.Bold).Sprint("Preview: ")+color.New(color.Underline).Sprint("https://"+currentStatus.TargetFQDN)+func() string {
-				//		if writeClipBoardError == nil {
-				//			return color.New().Sprint("  (📋 Copied!)")
-				//		}
-				//		return ""
-				//	}(), "\n"+
-				//		color.New(color.Bold).Sprint("Details: ")+color.New(color.Underline).Sprint("https://let.sh/console/project/"+deploymentCtx.Name+"/details"),
-				//)
-
-				// if web3
-				if currentStatus.Web3 != nil {
-					log.CyanBold("Web3 Info:")
-					fmt.Println("IPFS:   ", termenv.String("https://ipfs.io/ipfs/"+currentStatus.Web3.IpfsCID).
-						Underline().Bold().
-						String())
-					fmt.Println("Arweave:", termenv.String("https://arweave.net/"+currentStatus.Web3.ArTID).
-						Underline().
-						Bold().
-						String())
-					fmt.Println("\n")
-				}
+func (deployListener) Confirm(q deploy.Question, c *deploy.DeployContext) (bool, error) {
+	log.S.StopFail()
+	defer log.BStart("deploying")
+
+	switch q {
+	case deploy.QuestionNewProject:
+		return c.ConfirmNewProject()
+	case deploy.QuestionDirChanged:
+		// take the default answer, same as the prompt
+		if inputAssumeYes || log.JSON() {
+			return true, nil
+		}
 
-				fmt.Println(
-
-					termenv.String("URL:   ").String(), termenv.String("https://"+currentStatus.
-						TargetFQDN).Underline().Bold().String()+func() string {
-						if writeClipBoardError == nil {
-							p := termenv.ColorProfile()
-							return termenv.String("  (📋 Copied!)").Foreground(p.Color("#808080")).String()
-						}
-						return ""
-					}(),
-					"\n"+termenv.String("Details: ").String()+termenv.String("https://let."+
-						"sh/console/projects/"+deploymentCtx.Name+"/details").Bold().Underline().String(),
-					//color.New(color.Bold).Sprint("Preview: ")+color.New(color.Underline).Sprint("https://"+currentStatus.TargetFQDN)+func() string {
-					//	if writeClipBoardError == nil {
-					//		return color.New().Sprint("  (📋 Copied!)")
-					//	}
-					//	return ""
-					//}(),
-					//"\n"+
-					//	color.New(color.Bold).Sprint("Details: ")+color.New(color.Underline).Sprint("https://let.sh/console/project/"+deploymentCtx.Name+"/details"),
-				)
-
-				break
+		// if current dir is not previous dir
+		prompt := promptui.Prompt{
+			Label:   "Detected your project dir changed, continue deployment?[Y/n]",
+			Default: "Y",
+			Validate: func(input string) error {
+				if utils.ItemExists([]string{"", "n", "N", "No", "Y", "y", "yes", "Yes"}, input) {
+					return nil
+				}
+				return errors.New("no matching input")
+			},
+		}
 
+		result, err := prompt.Run()
+		if err != nil {
+			if errors.Is(err, promptui.ErrInterrupt) {
+				return false, errs.New(errs.Canceled, "deployment canceled")
 			}
-			// interval
-			time.Sleep(time.Second)
+			return false, err
 		}
-		return
-	},
+		return !utils.ItemExists([]string{"n", "N", "No"}, result), nil
+	case deploy.QuestionLargeSource:
+		if inputAssumeYes {
+			return true, nil
+		}
+		if log.JSON() {
+			return false, errs.Newf(errs.Validation, "source code is %s, larger than %s, pass --assume-yes to upload",
+				c.Source.Size.HR(), c.Source.Limit.Confirm.HR())
+		}
+		return ui.Radio(ui.RadioConfig{
+			Prefix: fmt.Sprintf(
+				"%s\n%s%s",
+				aurora.Index(51, fmt.Sprintf("Source code is %s, larger than %s, the largest are:",
+					c.Source.Size.HR(), c.Source.Limit.Confirm.HR())),
+				c.Source.Breakdown(),
+				aurora.Index(51, "continue to upload?"),
+			),
+			RadioText: aurora.Index(51, "[y/N]").String(),
+			Default:   false,
+		})
+	}
+	return true, nil
 }
 
-var deploymentCtx deploy.DeployContext
 var inputProjectName string
 var inputProjectType string
 var inputCN bool
@@ -539,6 +539,16 @@ var inputDetach bool      // return immediately after submitted deployment
 var inputAssumeYes bool   // assume the answer to all prompts is yes
 var inputCheckRunID int64 // github check run id
 var inputWeb3 bool        // deploy to web3
+var inputFilters []string // workspace packages to deploy
+var inputChangedSince string
+var inputDryRun bool    // list files to ship instead of deploying
+var inputListFiles bool // list every file in dry run
+var inputPlan bool      // show what would be deployed, diffed against the last deployment
+var inputCompression string
+var inputConcurrency int
+var inputMaxBandwidth string
+var inputNoCache bool      // build without reusing the cached output
+var inputEnvFiles []string // env files loaded after the ones of channel
 
 func init() {
 	rootCmd.AddCommand(deployCmd)
@@ -555,44 +565,72 @@ func init() {
 
 	deployCmd.Flags().BoolVarP(&inputProd, "prod", "", false, "deploy in production channel, will assign linked domain")
 	deployCmd.Flags().BoolVarP(&inputDev, "dev", "", false, "deploy in development channel")
+	deployCmd.Flags().StringArrayVarP(&inputEnvFiles, "env-file", "", nil,
+		"env file loaded after .env files of channel, could be repeated")
 
 	deployCmd.Flags().BoolVarP(&inputWeb3, "web3", "", false, "deploy in web3 infra, store files on arweave, "+
 		"visit via ipfs")
 
+	deployCmd.Flags().StringSliceVarP(&inputFilters, "filter", "F", nil,
+		"workspace packages to deploy by name or path, e.g. web, @org/*, apps/*")
+	deployCmd.Flags().StringVarP(&inputChangedSince, "changed", "", "",
+		"deploy workspace packages changed since git ref, default to HEAD~1")
+	deployCmd.Flags().Lookup("changed").NoOptDefVal = "HEAD~1"
+
+	deployCmd.Flags().BoolVarP(&inputDryRun, "dry-run", "", false,
+		"show what would be shipped without uploading or deploying")
+	deployCmd.Flags().BoolVarP(&inputListFiles, "list-files", "", false,
+		"list every file to ship in dry run or plan")
+	deployCmd.Flags().BoolVarP(&inputPlan, "plan", "", false,
+		"show what would be deployed as a diff against the last deployment, export it via -o json")
+
+	deployCmd.Flags().BoolVarP(&inputNoCache, "no-cache", "", false,
+		"build without reusing the cached output of unchanged project")
+
+	deployCmd.Flags().IntVarP(&inputConcurrency, "concurrency", "", s3.DefaultConcurrency,
+		"number of static files uploaded at the same time")
+	deployCmd.Flags().StringVarP(&inputMaxBandwidth, "max-bandwidth", "", "",
+		"limit the upload speed per second, e.g. 2MB")
+
 	deployCmd.Flags().BoolVarP(&inputCN, "cn", "", true, "deploy in mainland of china")
 	deployCmd.Flags().MarkHidden("cn")
 
 	deployCmd.Flags().Int64VarP(&inputCheckRunID, "check-run-id", "", 0, "github check run id")
 	deployCmd.Flags().MarkHidden("check-run-id")
 
+	deployCmd.Flags().StringVarP(&inputCompression, "compression", "", "gzip", "source tarball compression, gzip or zstd")
+	deployCmd.Flags().MarkHidden("compression")
+
 }
 
-func SetupCloseHandler() {
-	channel := make(chan os.Signal)
-	signal.Notify(channel, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-channel
-		if len(DeploymentID) > 0 {
-			succeeded, err := requests.CancelDeployment(DeploymentID)
-			if err != nil {
-				log.S.StopFail()
-				log.Error(err)
-				os.Exit(0)
-			}
-			if succeeded {
-				log.S.StopFail()
-				log.Warning("Deployment canceled")
-				os.Exit(0)
-			} else {
-				log.S.StopFail()
-				log.Warning("Deployment cancellation failed")
-				os.Exit(0)
+// printDeploymentResult prints the visiting url of deployment, and copies it to clipboard
+func printDeploymentResult(projectName, targetFQDN string, web3 *requests.Web3) {
+	// write review url to clipboard
+	writeClipBoardError := clipboard.WriteAll("https://" + targetFQDN)
+
+	// if web3
+	if web3 != nil {
+		fmt.Println(log.CyanBold("Web3 Info:"))
+		fmt.Println("IPFS:   ", termenv.String("https://ipfs.io/ipfs/"+web3.IpfsCID).
+			Underline().Bold().
+			String())
+		fmt.Println("Arweave:", termenv.String("https://arweave.net/"+web3.ArTID).
+			Underline().
+			Bold().
+			String())
+		fmt.Println("")
+	}
+
+	fmt.Println(
+		termenv.String("URL:   ").String(), termenv.String("https://"+
+			targetFQDN).Underline().Bold().String()+func() string {
+			if writeClipBoardError == nil {
+				p := termenv.ColorProfile()
+				return termenv.String("  (📋 Copied!)").Foreground(p.Color("#808080")).String()
 			}
-		} else {
-			log.S.StopFail()
-			log.Warning("Deployment canceled")
-			os.Exit(0)
-		}
-
-	}()
+			return ""
+		}(),
+		"\n"+termenv.String("Details: ").String()+termenv.String("https://let."+
+			"sh/console/projects/"+projectName+"/details").Bold().Underline().String(),
+	)
 }
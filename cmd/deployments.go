@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// deploymentsCmd represents the deployments command
+var deploymentsCmd = &cobra.Command{
+	Use:     "deployments",
+	Aliases: []string{"deployment"},
+	Short:   "Operate deployments of project",
+	Long: `Operate deployments of project
+
+e.g. lets deployments ls
+e.g. lets deployments ls -p hello-world -n 20
+`,
+}
+
+var deploymentsInputProjectName string
+
+func init() {
+	rootCmd.AddCommand(deploymentsCmd)
+
+	// Cobra supports Persistent Flags which will work for this command
+	// and all subcommands, e.g.:
+	deploymentsCmd.PersistentFlags().StringVarP(&deploymentsInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
+}
+
+// humanizeAge formats the time elapsed since createdAt, e.g. 3m, 2h, 5d
+func humanizeAge(createdAt string) string {
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return "-"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
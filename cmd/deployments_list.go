@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// deploymentsListCmd represents the deployments list command
+var deploymentsListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List recent deployments of project",
+	Long: `List recent deployments of project
+
+e.g. lets deployments ls
+e.g. lets deployments ls -n 20
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := resolveProject(deploymentsInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		q, err := graphql.GetDeployments(p.Name, inputDeploymentsCount)
+		if err != nil {
+			return err
+		}
+
+		deployments := []graphql.DeploymentNode{}
+		for _, edge := range q.Deployments.Edges {
+			deployments = append(deployments, edge.Node)
+		}
+
+		log.Result(deployments, func() {
+			if len(deployments) == 0 {
+				log.Warning("no deployment found under project " + p.Name)
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "ID\tCHANNEL\tSTATUS\tURL\tAGE")
+			for _, d := range deployments {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.ID, d.Channel, d.Status, "https://"+d.TargetFQDN,
+					humanizeAge(d.CreatedAt))
+			}
+			w.Flush()
+		})
+		return nil
+	},
+}
+
+var inputDeploymentsCount int
+
+func init() {
+	deploymentsCmd.AddCommand(deploymentsListCmd)
+
+	deploymentsListCmd.Flags().IntVarP(&inputDeploymentsCount, "count", "n", 10, "count of deployments to list")
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/let-sh/cli/handler/deploy"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/spf13/cobra"
+)
+
+// detectCmd represents the detect command
+var detectCmd = &cobra.Command{
+	Use:   "detect [dir]",
+	Short: "Detect the project type",
+	Long: `Detect the project type of current dir, or the given dir
+
+e.g. lets detect
+e.g. lets detect --explain ./web
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if !deploy.FileExists(dir) {
+			return errs.New(errs.Validation, "no such directory: "+dir)
+		}
+
+		candidates := deploy.Detect(dir)
+		if len(candidates) == 0 {
+			return errs.New(errs.Validation, "unknown project type, please check your project directory")
+		}
+
+		log.Result(map[string]interface{}{
+			"type":       candidates[0].Type,
+			"ambiguous":  deploy.Ambiguous(candidates),
+			"candidates": candidates,
+		}, func() {
+			if !inputDetectExplain {
+				fmt.Println(candidates[0].Type)
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tCONFIDENCE\tEVIDENCE")
+			for _, c := range candidates {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", c.Type, c.Confidence, strings.Join(c.Evidence, "; "))
+			}
+			w.Flush()
+
+			fmt.Println("")
+			if deploy.Ambiguous(candidates) {
+				log.Warning(fmt.Sprintf("%s and %s are equally plausible, "+
+					"lets deploy will ask you to choose one, or specify it via `-t` flag",
+					candidates[0].Type, candidates[1].Type))
+				return
+			}
+			fmt.Printf("chose %s, the most confident type\n", candidates[0].Type)
+		})
+		return nil
+	},
+}
+
+var inputDetectExplain bool
+
+func init() {
+	rootCmd.AddCommand(detectCmd)
+
+	detectCmd.Flags().BoolVarP(&inputDetectExplain, "explain", "", false,
+		"show all candidate types with confidence and evidence")
+}
@@ -38,7 +38,10 @@ import (
 	"github.com/let-sh/cli/handler/dev"
 	c "github.com/let-sh/cli/handler/dev/command"
 	"github.com/let-sh/cli/handler/dev/process"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/handler/routing"
 	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/utils"
 	"github.com/let-sh/cli/utils/cache"
 	"github.com/logrusorgru/aurora"
@@ -52,29 +55,64 @@ var inputLocalEndpoint string
 var inputCommand string
 var processPids []int
 var forceLocal bool
+var devInputFilters []string
+var devInputEnvFiles []string
 
 // devCmd represents the dev command
 var devCmd = &cobra.Command{
 	Use:   "dev",
 	Short: "Start development environment",
 	Long:  `Start development environment, let.sh cli will automatically export your service with development endpoint`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var command string
 		var localEndpoint string
 		var ports []int
 
+		// env files given are relative to the dir before switching to package
+		envFiles, err := envFilePaths(devInputEnvFiles)
+		if err != nil {
+			return err
+		}
+
+		// develop the selected workspace package in its own dir
+		ws, packages, err := resolveWorkspacePackages(devInputFilters, "")
+		if err != nil {
+			return err
+		}
+		if ws != nil {
+			if len(packages) != 1 {
+				var names []string
+				for _, p := range packages {
+					names = append(names, p.Name)
+				}
+				return errs.New(errs.Validation, "please select one package to develop, matched: "+
+					strings.Join(names, ", "))
+			}
+			if err := os.Chdir(packages[0].Dir); err != nil {
+				return err
+			}
+		}
+
 		var deploymentCtx deploy.DeployContext
-		detectedType := deploymentCtx.DetectProjectType()
+		deploymentCtx.DetectProjectType(".")
+		detectedType := deploymentCtx.Type
 		if detectedType == "unknown" {
 			log.S.StopFail()
-			log.Warning("unknown project type, please check your project directory. " +
+			return errs.New(errs.Validation, "unknown project type, please check your project directory. "+
 				"or you could specify project type with `-t` flag")
-			return
 		}
 		logrus.Debug("detected project type: ", detectedType)
 
-		deploymentCtx.LoadLetJson()
+		// develop with the config and env files of dev channel
+		fileConfig, err := deploy.LoadConfigFile(".", "dev", envFiles)
+		if err != nil {
+			return err
+		}
+		deploymentCtx.LetConfig, err = deploy.Layers{Detected: deploymentCtx.LetConfig, File: fileConfig}.Merge()
+		if err != nil {
+			return err
+		}
 
 		dir, _ := os.Getwd()
 		p, err := cache.GetProjectInfo(dir)
@@ -93,11 +131,15 @@ var devCmd = &cobra.Command{
 			// TODO: trigger to reverse port
 			freePort, err := GetFreePort()
 			if err != nil {
-				log.Error(err)
-				return
+				return err
 			}
 			inputLocalEndpoint = "localhost:" + cast.ToString(freePort)
-			go ServeStaticFiles(deploymentCtx.Static, freePort)
+			// route as deployed, so redirects, rewrites and headers could be checked locally
+			server, err := routing.NewServer(deploymentCtx.Static, deploymentCtx.LetConfig)
+			if err != nil {
+				return err
+			}
+			go ServeStaticFiles(server, freePort)
 		} else {
 			if len(inputCommand) == 0 {
 				if len(command) == 0 {
@@ -107,10 +149,29 @@ var devCmd = &cobra.Command{
 						switch detectedType {
 						case "gin":
 							return "go run main.go"
-						case "react":
-							return "yarn dev"
-						case "vue":
+						case "react", "vue", "next", "nuxt", "vite", "svelte", "sveltekit", "astro", "remix":
 							return "yarn dev"
+						case "gatsby":
+							return "yarn develop"
+						case "django":
+							return "python manage.py runserver"
+						case "flask":
+							return "flask run"
+						case "fastapi":
+							return "uvicorn main:app --reload"
+						case "rails":
+							return "bin/rails server"
+						case "laravel":
+							return "php artisan serve"
+						case "spring-boot":
+							if deploy.FileExists("pom.xml") {
+								return "./mvnw spring-boot:run"
+							}
+							return "./gradlew bootRun"
+						case "deno":
+							return "deno task dev"
+						case "bun":
+							return "bun run dev"
 						default:
 							return ""
 						}
@@ -121,8 +182,7 @@ var devCmd = &cobra.Command{
 						PlaceHolders:       []string{defaultCommand},
 					})
 					if err != nil {
-						log.Error(err)
-						return
+						return err
 					}
 					command = resultStr
 				}
@@ -132,7 +192,9 @@ var devCmd = &cobra.Command{
 		}
 
 		p.ServeCommand = command
-		cache.SaveProjectInfo(p)
+		if err := cache.SaveProjectInfo(p); err != nil {
+			logrus.WithError(err).Debugln("save project info")
+		}
 		SetupCloseDevelopmentHandler(p.ID)
 
 		defer KillServiceProcess(p.ID)
@@ -148,8 +210,7 @@ var devCmd = &cobra.Command{
 			result, err = requests.StartDevelopment(p.ID)
 
 			if err != nil {
-				log.Error(err)
-				return
+				return errs.Wrap(errs.Network, err)
 			}
 			// using wss://
 			if result.RemotePort == 443 {
@@ -166,13 +227,20 @@ var devCmd = &cobra.Command{
 			// run server command
 			cmdSlice := strings.Split(command, " ")
 			currentCmd := exec.Command(cmdSlice[0], cmdSlice[1:]...)
+			currentCmd.Env = env.Environ(os.Environ(), deploymentCtx.Env)
 
-			go c.RunCmd(currentCmd)
+			runErr := make(chan error, 1)
+			go func() { runErr <- c.RunCmd(currentCmd) }()
 
-			for {
-				// wait for process to start
-				if currentCmd.Process != nil {
-					break
+			// wait for process to start
+			for currentCmd.Process == nil {
+				select {
+				case err := <-runErr:
+					ui.Spinner.Stop()
+					if err != nil {
+						return fmt.Errorf("start service: %w", err)
+					}
+				default:
 				}
 			}
 			ui.Spinner.Stop()
@@ -194,15 +262,11 @@ var devCmd = &cobra.Command{
 				}
 
 				if _, err := ps.FindProcess(currentCmd.Process.Pid); err != nil {
-					log.Error(errors.New("service process existed, please check logs above"))
-					KillServiceProcess(p.ID)
-					return
+					return errors.New("service process existed, please check logs above")
 				}
 
 				if i == 9 {
-					log.Error(errors.New("timeout waiting for service port, please check your service status"))
-					KillServiceProcess(p.ID)
-					return
+					return errors.New("timeout waiting for service port, please check your service status")
 				}
 			}
 		}
@@ -226,9 +290,10 @@ var devCmd = &cobra.Command{
 
 				_, resultStr, err := prompt.Run()
 				if err != nil {
-					KillServiceProcess(p.ID)
-					log.Error(err)
-					return
+					if errors.Is(err, promptui.ErrInterrupt) {
+						return errs.New(errs.Canceled, "canceled")
+					}
+					return err
 				}
 				localEndpoint = "localhost:" + resultStr
 			}
@@ -238,21 +303,27 @@ var devCmd = &cobra.Command{
 
 		// if remote or local endpoint not exists
 		if remoteEndpoint == "" || localEndpoint == "" {
-			log.Error(errors.New("currently under development"))
+			return errors.New("currently under development")
 		}
 		if len(result.Fqdn) == 0 {
-			log.Error(errors.New("missing public visit fqdn"))
+			return errors.New("missing public visit fqdn")
 		}
 
 		// waiting for tunnel to be ready
 		time.Sleep(time.Second / 2)
 
-		fmt.Println("\n"+aurora.BrightCyan("[msg]").Bold().String(),
-			"you can visit remotely at: "+aurora.Bold("https://"+result.Fqdn).String())
-		fmt.Println(aurora.BrightCyan("[msg]").Bold().String(),
-			"or debug requests at: "+aurora.Bold("https://let.sh/console/projects/"+p.Name+"/development").String()+"\n\r")
+		log.Result(map[string]string{
+			"url":     "https://" + result.Fqdn,
+			"console": "https://let.sh/console/projects/" + p.Name + "/development",
+		}, func() {
+			fmt.Println("\n"+aurora.BrightCyan("[msg]").Bold().String(),
+				"you can visit remotely at: "+aurora.Bold("https://"+result.Fqdn).String())
+			fmt.Println(aurora.BrightCyan("[msg]").Bold().String(),
+				"or debug requests at: "+aurora.Bold("https://let.sh/console/projects/"+p.Name+"/development").String()+"\n\r")
+		})
 
 		dev.StartClient(remoteEndpoint, localEndpoint, result.Fqdn)
+		return nil
 	},
 }
 
@@ -276,12 +347,16 @@ func init() {
 	devCmd.Flags().StringVarP(&inputLocalEndpoint, "local", "l", "", "custom local upstream endpoint, e.g. 127.0.0.1:3000")
 
 	devCmd.Flags().BoolVarP(&forceLocal, "force", "f", false, "force local test development")
+	devCmd.Flags().StringSliceVarP(&devInputFilters, "filter", "F", nil,
+		"workspace package to develop by name or path, e.g. web")
+	devCmd.Flags().StringArrayVarP(&devInputEnvFiles, "env-file", "", nil,
+		"env file loaded after .env files of dev channel, could be repeated")
 	deployCmd.Flags().MarkHidden("force")
 }
 
 func SetupCloseDevelopmentHandler(projectID string) {
 	// TODO: trigger stop tunnel
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
@@ -290,7 +365,7 @@ func SetupCloseDevelopmentHandler(projectID string) {
 
 		ui.Spinner.Stop()
 		log.Warning("exited development")
-		os.Exit(0)
+		os.Exit(errs.Canceled.ExitCode())
 	}()
 }
 
@@ -329,11 +404,8 @@ func FindAllChildrenProcess(pid int) (exists bool, childrenPid int) {
 	return false, 0
 }
 
-func ServeStaticFiles(dir string, port int) {
-	fs := http.FileServer(http.Dir(dir))
-	http.Handle("/", fs)
-
-	err := http.ListenAndServe(":"+cast.ToString(port), nil)
+func ServeStaticFiles(handler http.Handler, port int) {
+	err := http.ListenAndServe(":"+cast.ToString(port), handler)
 	if err != nil {
 		log.Error(err)
 		return
@@ -17,20 +17,30 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/let-sh/cli/requests/graphql"
 	"github.com/spf13/cobra"
 )
 
 // domainsCmd represents the domains command
 var domainsCmd = &cobra.Command{
-	Use:   "domains",
-	Short: "Operate domains of let.sh",
-	Long:  ``,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("not implemented yet")
-	},
+	Use:     "domains",
+	Aliases: []string{"domain"},
+	Short:   "Operate domains of let.sh",
+	Long: `Operate domains linked to your project
+
+e.g. lets domains ls
+e.g. lets domains add www.example.com
+e.g. lets domains verify www.example.com
+e.g. lets domains rm www.example.com
+`,
 }
 
+var domainsInputProjectName string
+
 func init() {
 	rootCmd.AddCommand(domainsCmd)
 
@@ -38,9 +48,37 @@ func init() {
 
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
-	// domainsCmd.PersistentFlags().String("foo", "", "A help for foo")
+	domainsCmd.PersistentFlags().StringVarP(&domainsInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
+}
+
+// printDomainRecords prints the dns records required by the domain
+func printDomainRecords(domain graphql.Domain) {
+	if len(domain.Records) == 0 {
+		return
+	}
+
+	fmt.Println("\nplease add the following dns records of " + domain.Hostname + ":")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tNAME\tVALUE")
+	for _, record := range domain.Records {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", record.Type, record.Name, record.Value)
+	}
+	w.Flush()
+	fmt.Println("")
+}
+
+// findDomain returns the linked domain of project by hostname
+func findDomain(projectName, hostname string) (domain graphql.Domain, ok bool, err error) {
+	q, err := graphql.GetDomains(projectName)
+	if err != nil {
+		return domain, false, err
+	}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// domainsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	for _, d := range q.Project.Domains {
+		if strings.EqualFold(d.Hostname, hostname) {
+			return d, true, nil
+		}
+	}
+	return domain, false, nil
 }
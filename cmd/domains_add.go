@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// domainsAddCmd represents the domains add command
+var domainsAddCmd = &cobra.Command{
+	Use:   "add [hostname]",
+	Short: "Link domain to project",
+	Long: `Link domain to project, and show the dns records required
+
+e.g. lets domains add www.example.com
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostname := strings.TrimSpace(args[0])
+
+		p, err := resolveProject(domainsInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		m, err := graphql.Link(p.ID, hostname)
+		if err != nil {
+			return err
+		}
+		if !m.Link {
+			return errors.New("link failed")
+		}
+		domain, ok, err := findDomain(p.Name, hostname)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			domain.Hostname = hostname
+		}
+
+		log.Result(domain, func() {
+			log.Success("linked " + hostname + " to " + p.Name)
+			printDomainRecords(domain)
+			log.Warning("run `lets domains verify " + hostname + "` after dns records added")
+		})
+		return nil
+	},
+}
+
+func init() {
+	domainsCmd.AddCommand(domainsAddCmd)
+}
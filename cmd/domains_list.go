@@ -0,0 +1,73 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// domainsListCmd represents the domains list command
+var domainsListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List domains linked to project",
+	Long: `List domains linked to project with their dns and tls status
+
+e.g. lets domains ls
+e.g. lets domains ls -p hello-world
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := resolveProject(domainsInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		q, err := graphql.GetDomains(p.Name)
+		if err != nil {
+			return err
+		}
+
+		domains := q.Project.Domains
+		if domains == nil {
+			domains = []graphql.Domain{}
+		}
+		log.Result(domains, func() {
+			if len(domains) == 0 {
+				log.Warning("no domain linked to " + p.Name + ", you could link one via `lets domains add`")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "HOSTNAME\tDNS\tTLS")
+			for _, domain := range domains {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", domain.Hostname, domain.DNSStatus, domain.TLSStatus)
+			}
+			w.Flush()
+		})
+		return nil
+	},
+}
+
+func init() {
+	domainsCmd.AddCommand(domainsListCmd)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// domainsRemoveCmd represents the domains remove command
+var domainsRemoveCmd = &cobra.Command{
+	Use:     "rm [hostname]",
+	Aliases: []string{"remove"},
+	Short:   "Unlink domain from project",
+	Long: `Unlink domain from project
+
+e.g. lets domains rm www.example.com
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostname := strings.TrimSpace(args[0])
+
+		p, err := resolveProject(domainsInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		m, err := graphql.Unlink(p.ID, hostname)
+		if err != nil {
+			return err
+		}
+		if !m.Unlink {
+			return errors.New("unlink failed")
+		}
+		log.Result(map[string]interface{}{"hostname": hostname, "unlinked": true}, func() {
+			log.Success("unlinked " + hostname + " from " + p.Name)
+		})
+		return nil
+	},
+}
+
+func init() {
+	domainsCmd.AddCommand(domainsRemoveCmd)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// domainsVerifyCmd represents the domains verify command
+var domainsVerifyCmd = &cobra.Command{
+	Use:   "verify [hostname]",
+	Short: "Verify dns records of linked domain",
+	Long: `Verify dns records of linked domain, keep polling until verification passes
+
+e.g. lets domains verify www.example.com
+e.g. lets domains verify www.example.com --timeout 10m
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostname := strings.TrimSpace(args[0])
+
+		p, err := resolveProject(domainsInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		log.BStart("verifying " + hostname)
+		start := time.Now()
+		var domain graphql.Domain
+		for {
+			m, err := graphql.VerifyDomain(p.ID, hostname)
+			if err != nil {
+				return err
+			}
+			domain = m.VerifyDomain
+
+			if domain.DNSStatus == "Verified" {
+				log.S.StopFail()
+				log.Result(domain, func() {
+					log.Success(hostname + " verified, tls: " + domain.TLSStatus)
+				})
+				return nil
+			}
+			log.BUpdate("verifying " + hostname + ", dns: " + domain.DNSStatus + ", tls: " + domain.TLSStatus)
+
+			if time.Since(start) >= inputVerifyTimeout {
+				break
+			}
+			time.Sleep(5 * time.Second)
+		}
+
+		log.S.StopFail()
+		if !log.JSON() {
+			printDomainRecords(domain)
+		}
+		return errs.Newf(errs.Network, "verify %s timeout, please check your dns records", hostname)
+	},
+}
+
+var inputVerifyTimeout time.Duration
+
+func init() {
+	domainsCmd.AddCommand(domainsVerifyCmd)
+
+	domainsVerifyCmd.Flags().DurationVarP(&inputVerifyTimeout, "timeout", "", 5*time.Minute,
+		"stop polling after timeout")
+}
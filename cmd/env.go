@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/let-sh/cli/handler/deploy"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/spf13/cobra"
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage environment variables of project",
+	Long: `Manage environment variables of project stored by let.sh, injected into deployments.
+Variables are scoped to all channels of project by default, or to one channel with --prod or --dev,
+which override the ones of project. Values are masked in all output.
+
+e.g. lets env ls
+e.g. lets env set API_KEY
+e.g. lets env set API_URL https://api.example.com --prod
+e.g. lets env rm API_KEY
+e.g. lets env pull
+`,
+}
+
+var (
+	envInputProjectName string
+	envInputProd        bool
+	envInputDev         bool
+)
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	envCmd.PersistentFlags().StringVarP(&envInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
+	envCmd.PersistentFlags().BoolVarP(&envInputProd, "prod", "", false, "scope to production channel")
+	envCmd.PersistentFlags().BoolVarP(&envInputDev, "dev", "", false, "scope to development channel")
+}
+
+// envChannel returns the channel chosen by flags, empty for all channels
+func envChannel() (string, error) {
+	switch {
+	case envInputProd && envInputDev:
+		return "", errs.New(errs.Validation, "--prod and --dev are exclusive")
+	case envInputProd:
+		return "prod", nil
+	case envInputDev:
+		return "dev", nil
+	}
+	return "", nil
+}
+
+// envFilePaths returns the absolute paths of env files given by flag, relative ones are of current dir
+func envFilePaths(files []string) ([]string, error) {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		if !deploy.FileExists(path) {
+			return nil, errs.New(errs.Validation, "env file not found: "+file)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// channelName returns the name of channel in output
+func channelName(channel string) string {
+	if channel == "" {
+		return "all"
+	}
+	return channel
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// envListCmd represents the env list command
+var envListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List environment variables of project",
+	Long: `List environment variables of project with masked values,
+only the ones of the channel are listed with --prod or --dev
+
+e.g. lets env ls
+e.g. lets env ls --prod -p hello-world
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		channel, err := envChannel()
+		if err != nil {
+			return err
+		}
+		p, err := resolveProject(envInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		q, err := graphql.GetEnvs(p.Name)
+		if err != nil {
+			return err
+		}
+
+		vars := []graphql.Env{}
+		for _, v := range q.Project.Envs {
+			if channel == "" || v.Channel == channel {
+				v.Value = env.Mask
+				vars = append(vars, v)
+			}
+		}
+		env.Sort(vars)
+		log.Result(vars, func() {
+			if len(vars) == 0 {
+				log.Warning("no environment variable of " + p.Name + ", you could add one via `lets env set`")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tCHANNEL")
+			for _, v := range vars {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, channelName(v.Channel))
+			}
+			w.Flush()
+		})
+		return nil
+	},
+}
+
+func init() {
+	envCmd.AddCommand(envListCmd)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// envPullCmd represents the env pull command
+var envPullCmd = &cobra.Command{
+	Use:   "pull [file]",
+	Short: "Pull environment variables of project to local file",
+	Long: `Pull environment variables of project to a dotenv file for lets dev, default to .env.local.
+Variables of development channel are pulled unless --prod is specified, the file is overwritten.
+
+e.g. lets env pull
+e.g. lets env pull --prod .env.production.local
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := env.LocalFile
+		if len(args) == 1 {
+			file = args[0]
+		}
+		channel, err := envChannel()
+		if err != nil {
+			return err
+		}
+		if channel == "" {
+			channel = "dev"
+		}
+
+		p, err := resolveProject(envInputProjectName)
+		if err != nil {
+			return err
+		}
+		q, err := graphql.GetEnvs(p.Name)
+		if err != nil {
+			return err
+		}
+
+		vars := env.Resolve(q.Project.Envs, channel)
+		header := fmt.Sprintf("environment variables of %s in %s channel, pulled by `lets env pull`\n"+
+			"keep this file out of version control", p.Name, channel)
+		if err := env.WriteFile(file, header, vars); err != nil {
+			return err
+		}
+
+		keys := []string{}
+		for k := range vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		log.Result(map[string]interface{}{"file": file, "channel": channel, "keys": keys}, func() {
+			log.Success(fmt.Sprintf("pulled %d environment variables of %s channel to %s", len(keys), channel, file))
+		})
+		return nil
+	},
+}
+
+func init() {
+	envCmd.AddCommand(envPullCmd)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/spf13/cobra"
+)
+
+// envRemoveCmd represents the env remove command
+var envRemoveCmd = &cobra.Command{
+	Use:     "rm [key]",
+	Aliases: []string{"remove"},
+	Short:   "Remove environment variable of project",
+	Long: `Remove environment variable of project, takes effect from the next deployment.
+Only the one of the channel is removed with --prod or --dev.
+
+e.g. lets env rm API_KEY
+e.g. lets env rm API_URL --prod
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		channel, err := envChannel()
+		if err != nil {
+			return err
+		}
+
+		p, err := resolveProject(envInputProjectName)
+		if err != nil {
+			return err
+		}
+		m, err := graphql.RemoveEnv(p.ID, channel, key)
+		if err != nil {
+			return err
+		}
+		if !m.RemoveEnv {
+			return errors.New("remove environment variable failed")
+		}
+		log.Result(map[string]interface{}{"key": key, "channel": channel, "removed": true}, func() {
+			log.Success("removed " + key + " of " + p.Name + " from " + channelName(channel) + " channels")
+		})
+		return nil
+	},
+}
+
+func init() {
+	envCmd.AddCommand(envRemoveCmd)
+}
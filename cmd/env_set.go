@@ -0,0 +1,82 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// envSetCmd represents the env set command
+var envSetCmd = &cobra.Command{
+	Use:     "set [key] [value]",
+	Aliases: []string{"add"},
+	Short:   "Set environment variable of project",
+	Long: `Set environment variable of project, takes effect from the next deployment.
+Value is asked without echo if omitted, so secrets are kept out of shell history.
+
+e.g. lets env set API_KEY
+e.g. lets env set API_URL https://api.example.com --prod
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		if !env.ValidKey(key) {
+			return errs.Newf(errs.Validation, "invalid key %q, expect letters, digits and _ not starting with a digit",
+				key)
+		}
+		channel, err := envChannel()
+		if err != nil {
+			return err
+		}
+
+		var value string
+		if len(args) == 2 {
+			value = args[1]
+		} else {
+			prompt := promptui.Prompt{Label: "Value of " + key, Mask: '*'}
+			if value, err = prompt.Run(); err != nil {
+				return errs.Wrap(errs.Canceled, err)
+			}
+		}
+
+		p, err := resolveProject(envInputProjectName)
+		if err != nil {
+			return err
+		}
+		m, err := graphql.SetEnv(p.ID, channel, key, value)
+		if err != nil {
+			return err
+		}
+		if !m.SetEnv {
+			return errors.New("set environment variable failed")
+		}
+		log.Result(map[string]interface{}{"key": key, "channel": channel, "set": true}, func() {
+			log.Success("set " + key + " of " + p.Name + " in " + channelName(channel) + " channels")
+		})
+		return nil
+	},
+}
+
+func init() {
+	envCmd.AddCommand(envSetCmd)
+}
@@ -16,13 +16,13 @@ limitations under the License.
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/requests"
 	"github.com/let-sh/cli/utils/download"
 	"github.com/mholt/archiver/v3"
@@ -40,11 +40,11 @@ e.g.:
     lets init react
     lets init react new-react-project
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		projectType := strings.TrimSpace(args[0])
 		if projectType == "" {
-			log.Warning("Please specify the project type")
-			return
+			return errs.New(errs.Validation, "please specify the project type")
 		}
 
 		currentDir, _ := os.Getwd()
@@ -57,8 +57,7 @@ e.g.:
 
 		// check template exists
 		if _, err := requests.GetTemplate(projectType); err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 
 		log.BStart(" checking latest type") // validate project type
@@ -68,30 +67,30 @@ e.g.:
 			fmt.Sprintf("%s/%s.zip", tempDir, projectType),
 			fmt.Sprintf("http://github.com/let-sh/example/releases/latest/download/%s.zip", projectType),
 		); err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 
 		log.BUpdate("downloading project template")
 		logrus.Debug("download: ", fmt.Sprintf("%s/%s.zip", tempDir, projectType))
 		if err := archiver.Unarchive(fmt.Sprintf("%s/%s.zip", tempDir, projectType), tempDir); err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 		// mv to current folder
 		err := os.Rename(fmt.Sprintf("%s/%s", tempDir, projectType), fmt.Sprintf("%s/%s", currentDir, folderName))
 		if err != nil {
-			log.Error(errors.New("cannot init project to current folder: " + err.Error()))
-			//logrus.Debug("current project dir: ", pwd)
-			return
+			log.S.StopFail()
+			return fmt.Errorf("cannot init project to current folder: %w", err)
 		}
 
-		log.S.StopMessage(
-			" Init succeeded\n\n" +
-				"You could directly visit " + folderName + " folder by \n" +
-				"> " + log.CyanUnderline("cd "+folderName),
-		)
-		log.BStop()
+		log.Result(map[string]string{"type": projectType, "dir": fmt.Sprintf("%s/%s", currentDir, folderName)}, func() {
+			log.S.StopMessage(
+				" Init succeeded\n\n" +
+					"You could directly visit " + folderName + " folder by \n" +
+					"> " + log.CyanUnderline("cd "+folderName),
+			)
+			log.BStop()
+		})
+		return nil
 	},
 }
 
@@ -17,12 +17,10 @@ package cmd
 
 import (
 	"errors"
-	"github.com/let-sh/cli/requests"
-	"os"
 	"strings"
 
 	"github.com/let-sh/cli/log"
-	"github.com/let-sh/cli/utils/cache"
+	"github.com/let-sh/cli/requests/graphql"
 	"github.com/spf13/cobra"
 )
 
@@ -32,35 +30,30 @@ var linkCmd = &cobra.Command{
 	Short: "Link domain to current project",
 	Long: `Link domain to current project
 e.g.: lets link test.let.sh.cn
+see also: lets domains
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-
-		//detectedType :=deploy.DetectProjectType()
-		dir, _ := os.Getwd()
-		p, err := cache.GetProjectInfo(dir)
-
-		// if cache exists
-		// todo: support query project
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := resolveProject(linkInputProjectName)
 		if err != nil {
-			log.Error(errors.New("please deploy first"))
-			return
+			return err
 		}
 
-		result, err := requests.Link(p.ID, strings.TrimSpace(args[0]))
+		result, err := graphql.Link(p.ID, strings.TrimSpace(args[0]))
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 
-		if result == false {
-			log.Error(errors.New("link failed"))
-			return
+		if !result.Link {
+			return errors.New("link failed")
 		}
 		log.Success("link success")
-		return
+		return nil
 	},
 }
 
+var linkInputProjectName string
+
 func init() {
 	rootCmd.AddCommand(linkCmd)
 
@@ -72,5 +65,6 @@ func init() {
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
-	// linkCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	linkCmd.Flags().StringVarP(&linkInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
 }
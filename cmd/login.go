@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/requests"
 	"github.com/let-sh/cli/utils/config"
 	"github.com/matishsiao/goInfo"
@@ -41,7 +42,7 @@ var loginCmd = &cobra.Command{
 	//Cobra is a CLI library for Go that empowers applications.
 	//This application is a tool to generate the needed files
 	//to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// select login methods
 		// TODO: support more login methods
 		//prompt := promptui.Select{
@@ -65,8 +66,7 @@ var loginCmd = &cobra.Command{
 		// get ticket id
 		tickeIDInterface, err := requests.GetJsonWithPath("https://api.let-sh.com/oauth/ticket_id", "data")
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 		log.S.StopFail()
 
@@ -80,7 +80,8 @@ var loginCmd = &cobra.Command{
 				"com/oauth/login?method=github&client=cli&ticket_id=" + tickeIDInterface.
 				String() + "&device=" + goInfo.GetInfo().OS + goInfo.GetInfo().Core)
 
-			if shortenedUrl != "" {
+			log.Event("login", map[string]interface{}{"url": shortenedUrl})
+			if shortenedUrl != "" && !log.JSON() {
 				log.S.StopFail()
 				fmt.Println(
 					termenv.
@@ -108,7 +109,7 @@ var loginCmd = &cobra.Command{
 			}
 			qrterminal.GenerateWithConfig(shortenedUrl, config)
 
-			fmt.Println("\nplease use WeChat to scan the QR code above.\n")
+			fmt.Println("\nplease use WeChat to scan the QR code above.")
 		}
 
 		// valid response
@@ -126,14 +127,16 @@ var loginCmd = &cobra.Command{
 			if err == nil && tokenInterface.String() != "" {
 				// verify response
 				config.SetToken(tokenInterface.String())
-				log.S.StopMessage(" login succeed")
-				log.BStop()
-				return
+				log.Result(map[string]bool{"login": true}, func() {
+					log.S.StopMessage(" login succeed")
+					log.BStop()
+				})
+				return nil
 			}
 			time.Sleep(time.Second * 1)
 		}
 		log.S.StopFailMessage(" login timeout")
-		log.S.StopFail()
+		return errs.New(errs.Auth, "login timeout")
 	},
 }
 
@@ -16,11 +16,19 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/let-sh/cli/ui"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/requests"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 )
 
@@ -28,54 +36,112 @@ import (
 var logsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "Show latest logs",
-	Long: `Show latest logs under current project.
+	Long: `Show latest build and runtime logs of the latest deployment under current project.
 
 e.g. 
-"lets logs --tail 10"         print latest 10 line logs
+"lets logs --lines 10"        print latest 10 line logs
 "lets logs -p hello-world"    print latest logs under project 'hello-world'
+"lets logs -f"                keep streaming new logs until Ctrl+C
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("not implemented yet")
-		//data, err := requests.QueryDeployments("gin", 1)
-		//if err != nil {
-		//	log.Error(err)
-		//}
-		//fmt.Println(data)
-		//deploy.InitProject("gin")
-		//ui.Radio(ui.RadioConfig{
-		//	Prefix: "type: gin",
-		//})
-		//ui.InputArea(ui.InputAreaConfig{
-		//	Layout:             "key",
-		//	DefaultValue:       "v",
-		//	DefaultPlaceholder: "value",
-		//	PlaceHolders:       []string{"value", "test"},
-		//})
-		//
-		//fmt.Println("log called")
-		detectedType := "gin"
-		defaultCommand := func() string {
-			switch detectedType {
-			case "gin":
-				return "go run main.go"
-			case "react":
-				return "yarn dev"
-			case "vue":
-				return "yarn dev"
-			default:
-				return ""
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deployments, err := requests.QueryDeployments(logInputProjectName, 1)
+		if err != nil {
+			return err
+		}
+
+		if len(deployments.Edges) == 0 {
+			log.Warning("no deployment found under project " + logInputProjectName)
+			return nil
+		}
+		deploymentID := deployments.Edges[0].Node.ID
+
+		// cursor of the last printed line, per log type
+		cursors := map[string]string{}
+		for _, logType := range logTypes {
+			cursors[logType], err = printLatestLogs(deploymentID, logType, inputLines)
+			if err != nil {
+				return err
 			}
-		}()
-		ui.InputArea(ui.InputAreaConfig{
-			Layout:             "Please enter your command to start service:",
-			DefaultPlaceholder: defaultCommand,
-			PlaceHolders:       []string{defaultCommand},
-		})
+		}
+
+		if !inputFollow {
+			return nil
+		}
+
+		// keep streaming until Ctrl+C
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				for _, logType := range logTypes {
+					if cursors[logType], err = printLogsAfter(deploymentID, logType, cursors[logType]); err != nil {
+						return err
+					}
+				}
+			}
+		}
 	},
 }
 
+// logTypes are printed in order, build logs first
+var logTypes = []string{"build", "runtime"}
+
+// logsPageSize is the number of lines queried at once when following logs
+const logsPageSize = 100
+
+// printLatestLogs prints the latest lines of logs and returns the cursor of the last printed line
+func printLatestLogs(deploymentID, logType string, lines int) (cursor string, err error) {
+	q, err := graphql.GetLogs(deploymentID, logType, lines, "")
+	if err != nil {
+		return "", errors.New("cannot query " + logType + " logs: " + err.Error())
+	}
+	return printLogs(logType, q.Logs, ""), nil
+}
+
+// printLogsAfter prints all logs after cursor page by page, all logs if cursor is empty,
+// returns the cursor of the last printed line
+func printLogsAfter(deploymentID, logType string, after string) (cursor string, err error) {
+	cursor = after
+	for {
+		q, err := graphql.GetLogsPage(deploymentID, logType, logsPageSize, cursor)
+		if err != nil {
+			return cursor, errors.New("cannot query " + logType + " logs: " + err.Error())
+		}
+		cursor = printLogs(logType, q.Logs, cursor)
+		if len(q.Logs) < logsPageSize {
+			return cursor, nil
+		}
+	}
+}
+
+// printLogs prints lines of logType and returns the cursor of the last line, after if no line
+func printLogs(logType string, lines []graphql.LogLine, after string) (cursor string) {
+	cursor = after
+	prefix := aurora.Gray(12, fmt.Sprintf("[%s]", logType)).String()
+	for _, line := range lines {
+		log.Event("log", map[string]interface{}{
+			"type":      logType,
+			"cursor":    line.Cursor,
+			"timestamp": line.Timestamp,
+			"message":   line.Message,
+		})
+		if !log.JSON() {
+			fmt.Println(prefix, line.Message)
+		}
+		cursor = line.Cursor
+	}
+	return cursor
+}
+
 var inputLines int
 var logInputProjectName string
+var inputFollow bool
 
 func init() {
 	rootCmd.AddCommand(logsCmd)
@@ -92,4 +158,5 @@ func init() {
 
 	logsCmd.Flags().IntVarP(&inputLines, "lines", "l", 10, "latest lines of logs")
 	logsCmd.Flags().StringVarP(&logInputProjectName, "project", "p", filepath.Base(dir), "project name, e.g. react")
+	logsCmd.Flags().BoolVarP(&inputFollow, "follow", "f", false, "keep streaming new logs until Ctrl+C")
 }
@@ -33,14 +33,17 @@ var preferenceGetCmd = &cobra.Command{
 
 e.g. lets pref get default_channel
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		value, err := requests.GetPreference(strings.TrimSpace(args[0]))
 		if err != nil {
-			log.Errorf("cannot get preference: %s", value)
-			return
+			return fmt.Errorf("cannot get preference: %w", err)
 		}
 
-		fmt.Println(value)
+		log.Result(map[string]string{"name": strings.TrimSpace(args[0]), "value": value}, func() {
+			fmt.Println(value)
+		})
+		return nil
 	},
 }
 
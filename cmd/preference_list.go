@@ -29,8 +29,9 @@ var preferenceListCmd = &cobra.Command{
 
 e.g. lets pref list
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("pref called")
+		return nil
 	},
 }
 
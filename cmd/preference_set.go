@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"github.com/let-sh/cli/requests"
 	"strings"
 
 	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/spf13/cobra"
 )
 
@@ -33,19 +35,22 @@ var preferenceSetCmd = &cobra.Command{
 
 e.g. lets pref set default_channel dev
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 2 {
-			log.Warning(`error input, you could try as below:
+			return errs.New(errs.Validation, `error input, you could try as below:
 
 e.g. lets pref set channel dev`)
 		}
 		value, err := requests.SetPreference(strings.TrimSpace(args[0]), strings.TrimSpace(args[1]))
-		if err != nil || !value {
-			log.Error(errors.New("cannot set preference: " + err.Error()))
-			return
+		if err != nil {
+			return fmt.Errorf("cannot set preference: %w", err)
+		}
+		if !value {
+			return errors.New("cannot set preference: " + args[0])
 		}
 
 		log.Success("set preference: " + args[0] + "=" + args[1])
+		return nil
 	},
 }
 
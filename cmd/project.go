@@ -0,0 +1,57 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/cache"
+)
+
+// resolveProject finds the project to operate on.
+// projectName from cli flag > cached project of current dir > current dir name
+func resolveProject(projectName string) (project types.Project, err error) {
+	dir, _ := os.Getwd()
+
+	if projectName == "" {
+		if p, err := cache.GetProjectInfo(dir); err == nil && p.ID != "" {
+			return p, nil
+		}
+		projectName = filepath.Base(dir)
+	}
+
+	if p, ok := cache.ProjectsInfo[projectName]; ok && p.ID != "" {
+		return p, nil
+	}
+
+	q, err := graphql.GetProject(projectName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return project, errors.New("project " + projectName + " not found, please deploy first")
+		}
+		return project, err
+	}
+
+	return types.Project{
+		ID:   q.Project.ID,
+		Name: q.Project.Name,
+	}, nil
+}
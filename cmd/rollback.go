@@ -0,0 +1,184 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/ui"
+	"github.com/let-sh/cli/utils/cache"
+	"github.com/logrusorgru/aurora"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [deployment-id]",
+	Short: "Rollback production to an earlier deployment",
+	Long: `Promote an earlier successful deployment back to the prod channel.
+if deployment id is not specified, rollback to the successful prod deployment created before the one serving prod,
+which is the last one promoted by lets rollback from this machine, unless deployed to prod since.
+Specify the deployment id if prod has been rolled back from another machine.
+
+e.g. lets rollback
+e.g. lets rollback 3f0b2a5c-8f0e-4c2b-9d7a-2a1f3c4b5d6e
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := resolveProject(rollbackInputProjectName)
+		if err != nil {
+			return err
+		}
+
+		var target *graphql.DeploymentNode
+		if len(args) > 0 {
+			q, err := graphql.GetDeployment(args[0])
+			if err != nil {
+				return err
+			}
+			d := q.Deployment
+			if d.ID == "" || d.Project.ID != p.ID {
+				return errs.Newf(errs.Validation, "deployment %s not found under project %s", args[0], p.Name)
+			}
+			if d.Status != "Succeeded" {
+				return errs.Newf(errs.Validation, "cannot rollback to deployment in status %s", d.Status)
+			}
+			target = &d.DeploymentNode
+		} else {
+			q, err := graphql.GetDeployments(p.Name, 50)
+			if err != nil {
+				return err
+			}
+			promotion, err := cache.GetPromotion(p.Name)
+			if err != nil {
+				logrus.WithError(err).Debugln("load promotion")
+			}
+			var deployments []graphql.DeploymentNode
+			for _, edge := range q.Deployments.Edges {
+				deployments = append(deployments, edge.Node)
+			}
+			if target, err = rollbackTarget(deployments, promotion); err != nil {
+				return err
+			}
+		}
+
+		if log.JSON() && !inputRollbackAssumeYes {
+			return errs.New(errs.Validation, "rollback requires confirmation, please add --assume-yes in json output mode")
+		}
+
+		confirmed := inputRollbackAssumeYes
+		if !confirmed {
+			confirmed, err = ui.Radio(ui.RadioConfig{
+				Prefix: fmt.Sprintf(
+					"%s\nid: %s\nchannel: %s\nurl: https://%s\nage: %s\n%s",
+					aurora.Index(51, "Rollback prod to deployment:"),
+					target.ID,
+					target.Channel,
+					target.TargetFQDN,
+					humanizeAge(target.CreatedAt),
+					aurora.Index(51, "\ncontinue to rollback?"),
+				),
+				RadioText: aurora.Index(51, "[Y/n]").String(),
+				Default:   true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !confirmed {
+			return errs.New(errs.Canceled, "rollback canceled")
+		}
+
+		m, err := graphql.PromoteDeployment(target.ID, "prod")
+		if err != nil {
+			return err
+		}
+		promotion := types.Promotion{DeploymentID: m.PromoteDeployment.ID, PromotedAt: time.Now()}
+		if err := cache.SavePromotion(p.Name, promotion); err != nil {
+			logrus.WithError(err).Debugln("save promotion")
+		}
+		log.Result(m.PromoteDeployment, func() {
+			log.Success("rollback succeeded, prod is now served by " + m.PromoteDeployment.ID)
+		})
+		return nil
+	},
+}
+
+// rollbackTarget returns the newest successful prod deployment created before the one serving prod,
+// deployments are the newest created first. The one serving prod is the last promoted from this machine,
+// unless a newer one is deployed to prod since, otherwise the newest successful prod deployment.
+func rollbackTarget(deployments []graphql.DeploymentNode, promotion *types.Promotion) (*graphql.DeploymentNode, error) {
+	succeededProd := func(d graphql.DeploymentNode) bool {
+		return d.Status == "Succeeded" && d.Channel == "prod"
+	}
+	current := -1
+	for i, d := range deployments {
+		if succeededProd(d) {
+			current = i
+			break
+		}
+	}
+
+	if promotion != nil {
+		deployedSince := false
+		if current >= 0 {
+			createdAt, err := time.Parse(time.RFC3339, deployments[current].CreatedAt)
+			if err != nil {
+				return nil, errs.New(errs.Validation,
+					"cannot tell the deployment serving prod, please specify the deployment id to rollback to")
+			}
+			deployedSince = createdAt.After(promotion.PromotedAt)
+		}
+		if !deployedSince {
+			current = -1
+			for i, d := range deployments {
+				if d.ID == promotion.DeploymentID {
+					current = i
+				}
+			}
+		}
+	}
+	if current < 0 {
+		return nil, errs.New(errs.Validation,
+			"cannot tell the deployment serving prod, please specify the deployment id to rollback to")
+	}
+
+	for i := current + 1; i < len(deployments); i++ {
+		if succeededProd(deployments[i]) {
+			return &deployments[i], nil
+		}
+	}
+	return nil, errs.Newf(errs.Validation, "no successful production deployment found before %s",
+		deployments[current].ID)
+}
+
+var rollbackInputProjectName string
+var inputRollbackAssumeYes bool
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().StringVarP(&rollbackInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
+	rollbackCmd.Flags().BoolVarP(&inputRollbackAssumeYes, "assume-yes", "y", false,
+		"assume the answer to all prompts is yes")
+}
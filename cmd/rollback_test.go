@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	now := time.Now().UTC()
+	deployment := func(id, channel, status string, age time.Duration) graphql.DeploymentNode {
+		return graphql.DeploymentNode{ID: id, Channel: channel, Status: status,
+			CreatedAt: now.Add(-age).Format(time.RFC3339)}
+	}
+	// the newest created first
+	deployments := []graphql.DeploymentNode{
+		deployment("d5", "prod", "Failed", time.Hour),
+		deployment("d4", "prod", "Succeeded", 2*time.Hour),
+		deployment("d3", "dev", "Succeeded", 3*time.Hour),
+		deployment("d2", "prod", "Succeeded", 4*time.Hour),
+		deployment("d1", "prod", "Succeeded", 5*time.Hour),
+	}
+
+	for _, c := range []struct {
+		name      string
+		promotion *types.Promotion
+		want      string
+	}{
+		{"never rolled back", nil, "d2"},
+		{"rolled back", &types.Promotion{DeploymentID: "d2", PromotedAt: now}, "d1"},
+		{"deployed since rollback", &types.Promotion{DeploymentID: "d1", PromotedAt: now.Add(-3 * time.Hour)}, "d2"},
+		{"rolled back to the oldest", &types.Promotion{DeploymentID: "d1", PromotedAt: now}, ""},
+		{"promoted one not listed", &types.Promotion{DeploymentID: "d0", PromotedAt: now}, ""},
+	} {
+		target, err := rollbackTarget(deployments, c.promotion)
+		if c.want == "" {
+			if errs.KindOf(err) != errs.Validation {
+				t.Errorf("%s: target = %+v, err = %v, want validation error", c.name, target, err)
+			}
+			continue
+		}
+		if err != nil || target.ID != c.want {
+			t.Errorf("%s: target = %+v, err = %v, want %s", c.name, target, err, c.want)
+		}
+	}
+
+	if _, err := rollbackTarget(deployments[2:3], nil); errs.KindOf(err) != errs.Validation {
+		t.Errorf("err = %v, want validation error without prod deployment", err)
+	}
+}
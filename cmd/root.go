@@ -16,10 +16,11 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/let-sh/cli/info"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/ui"
 	"github.com/let-sh/cli/utils/config"
 	"github.com/let-sh/cli/utils/update"
@@ -30,6 +31,7 @@ import (
 
 var cfgFile string
 var Debug bool
+var outputFormat string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -39,19 +41,31 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+
+	// errors are printed by Execute, with exit code by kind
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("exit with error: ", err.Error())
-		os.Exit(1)
+		if errs.KindOf(err) == errs.Canceled && !log.JSON() {
+			log.S.StopFail()
+			log.Warning(err.Error())
+		} else {
+			log.Error(err)
+		}
+		os.Exit(errs.ExitCode(err))
 	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig, update.CheckUpdate)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errs.Wrap(errs.Validation, err)
+	})
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -63,6 +77,8 @@ func init() {
 	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cli.yaml)")
 	//rootCmd.PersistentFlags().String("token", "", "let.sh access token")
 	rootCmd.PersistentFlags().StringVarP(&info.Credentials.Token, "token", "", "", "specify the let.sh access token, ")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", log.OutputText,
+		"output format, optional: text, json. json mode prints one json document per line and never prompts")
 	//
 	//if token, err := rootCmd.PersistentFlags().GetString("token"); err != nil {
 	//	if len(token) > 0 {
@@ -77,6 +93,11 @@ func init() {
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	if err := log.SetOutput(outputFormat); err != nil {
+		log.PrintError(err)
+		os.Exit(errs.Validation.ExitCode())
+	}
+
 	config.Load()
 
 	if Debug || info.Version == "development" {
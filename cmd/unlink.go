@@ -17,13 +17,10 @@ package cmd
 
 import (
 	"errors"
-	"github.com/let-sh/cli/requests"
-	"os"
 	"strings"
 
 	"github.com/let-sh/cli/log"
-	"github.com/let-sh/cli/utils/cache"
-
+	"github.com/let-sh/cli/requests/graphql"
 	"github.com/spf13/cobra"
 )
 
@@ -32,34 +29,30 @@ var unlinkCmd = &cobra.Command{
 	Use:   "unlink",
 	Short: "UnLink domain from current project",
 	Long: `UnLink domain from current project.
-e.g.: lets unlink test.let.sh`,
-	Run: func(cmd *cobra.Command, args []string) {
-		//detectedType :=deploy.DetectProjectType()
-		dir, _ := os.Getwd()
-		p, err := cache.GetProjectInfo(dir)
-
-		// if cache exists
-		// todo: support query project
+e.g.: lets unlink test.let.sh
+see also: lets domains`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := resolveProject(unlinkInputProjectName)
 		if err != nil {
-			log.Error(errors.New("please deploy first"))
-			return
+			return err
 		}
 
-		result, err := requests.Unlink(p.ID, strings.TrimSpace(args[0]))
+		result, err := graphql.Unlink(p.ID, strings.TrimSpace(args[0]))
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 
-		if result == false {
-			log.Error(errors.New("unlink failed"))
-			return
+		if !result.Unlink {
+			return errors.New("unlink failed")
 		}
 		log.Success("unlink success")
-		return
+		return nil
 	},
 }
 
+var unlinkInputProjectName string
+
 func init() {
 	rootCmd.AddCommand(unlinkCmd)
 
@@ -71,5 +64,6 @@ func init() {
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
-	// unlinkCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	unlinkCmd.Flags().StringVarP(&unlinkInputProjectName, "project", "p", "",
+		"project name, default to the project under current dir")
 }
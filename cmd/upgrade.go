@@ -25,8 +25,9 @@ var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "Upgrade let.sh cli ",
 	Long:  `Upgrade let.sh cli to latest version.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		update.UpgradeCli(force, releaseChannel)
+		return nil
 	},
 }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	"github.com/let-sh/cli/info"
+	"github.com/let-sh/cli/log"
 
 	"github.com/spf13/cobra"
 )
@@ -28,8 +29,11 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print current cli version",
 	Long:  `usage 'lets version' or 'lets --version'`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(info.Version)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Result(map[string]string{"version": info.Version}, func() {
+			fmt.Println(info.Version)
+		})
+		return nil
 	},
 }
 
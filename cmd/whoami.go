@@ -17,13 +17,15 @@ var whoamiCmd = &cobra.Command{
 	Use:   "whoami",
 	Short: "Show the current user info",
 	Long:  `Show the current user info`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		u, err := requests.GetUser()
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
-		fmt.Println(u.Name)
+		log.Result(u, func() {
+			fmt.Println(u.Name)
+		})
+		return nil
 	},
 }
 
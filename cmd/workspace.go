@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Fred Liang <fred@oasis.ac>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/let-sh/cli/handler/workspace"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/sirupsen/logrus"
+)
+
+// resolveWorkspacePackages returns the workspace packages to run on,
+// selected by filters, or changed since git ref, or the package contains current dir.
+// workspace is nil if current dir is not under any workspace package, which should run on current dir.
+func resolveWorkspacePackages(filters []string, changedSince string) (*workspace.Workspace, []workspace.Package, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	ws, err := workspace.Find(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ws == nil {
+		if len(filters) > 0 || changedSince != "" {
+			return nil, nil, errs.New(errs.Validation, "not under a workspace, "+
+				"--filter and --changed require pnpm-workspace.yaml, workspaces in package.json or go.work")
+		}
+		return nil, nil, nil
+	}
+	logrus.WithFields(logrus.Fields{"kind": ws.Kind, "root": ws.Root}).Debugln("workspace")
+
+	var packages []workspace.Package
+	switch {
+	case len(filters) > 0:
+		packages, err = ws.Filter(filters)
+		if err != nil {
+			return nil, nil, err
+		}
+	case changedSince != "":
+		packages, err = ws.Changed(changedSince)
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		pkg, ok := ws.PackageOf(dir)
+		if !ok {
+			// under workspace root, keep running on current dir
+			return nil, nil, nil
+		}
+		packages = []workspace.Package{pkg}
+	}
+
+	if changedSince != "" && len(filters) > 0 {
+		// filter the changed packages
+		changed, err := ws.Changed(changedSince)
+		if err != nil {
+			return nil, nil, err
+		}
+		var filtered []workspace.Package
+		for _, p := range packages {
+			for _, c := range changed {
+				if c.Dir == p.Dir {
+					filtered = append(filtered, p)
+				}
+			}
+		}
+		packages = filtered
+	}
+
+	var names []string
+	for _, p := range packages {
+		names = append(names, p.Name)
+	}
+	logrus.Debugln("workspace packages:", strings.Join(names, ", "))
+	return ws, packages, nil
+}
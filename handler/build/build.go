@@ -0,0 +1,80 @@
+// Package build runs the local build step of project by the shell of system,
+// so commands could quote arguments, pipe and chain with && as in terminal.
+// Outputs are cached by the digests of build inputs, to skip the build if nothing relevant changed.
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log/errs"
+)
+
+// Step is the local build step of project
+type Step struct {
+	// Commands run in order in project dir, stop at the first failure
+	Commands []string `json:"commands,omitempty"`
+	// OutputDir is the dir of built static files, relative to project dir
+	OutputDir string `json:"output_dir,omitempty"`
+	// Env are the variables of commands, set over the environment of cli
+	Env map[string]string `json:"-"`
+}
+
+// ExitError reports a command exited with non-zero code
+type ExitError struct {
+	Command string
+	Code    int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("`%s` exited with code %d", e.Command, e.Code)
+}
+
+// Runner runs build steps under project dir
+type Runner struct {
+	// Dir is the project dir, default to current dir
+	Dir string
+	// output of commands, default to os.Stdout and os.Stderr
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run runs the commands of step in order, each command is echoed to Stdout before running.
+// The first failure is returned as errs.BuildFailed, wrapping an *ExitError if the command exited with non-zero code.
+func (r Runner) Run(ctx context.Context, step Step) error {
+	stdout, stderr := r.Stdout, r.Stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	environ := env.Environ(os.Environ(), step.Env)
+
+	for _, command := range step.Commands {
+		fmt.Fprintln(stdout, "$ "+command)
+		cmd := shell(ctx, command)
+		cmd.Dir = r.Dir
+		cmd.Env = environ
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := run(ctx, cmd)
+		if ctx.Err() != nil {
+			return errs.Wrap(errs.Canceled, ctx.Err())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return errs.Wrap(errs.BuildFailed, &ExitError{Command: command, Code: exitErr.ExitCode()})
+		}
+		if err != nil {
+			return errs.Wrap(errs.BuildFailed, fmt.Errorf("run `%s`: %w", command, err))
+		}
+	}
+	return nil
+}
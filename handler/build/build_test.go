@@ -0,0 +1,97 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/let-sh/cli/log/errs"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	dir, err := ioutil.TempDir("", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout bytes.Buffer
+	r := Runner{Dir: dir, Stdout: &stdout, Stderr: &stdout}
+	step := Step{
+		Commands: []string{
+			`mkdir dist && echo "hello  world" > dist/index.html`,
+			`echo $GREETING | tr a-z A-Z`,
+		},
+		Env: map[string]string{"GREETING": "hi there"},
+	}
+	if err := r.Run(context.Background(), step); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "dist", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(content)); got != "hello  world" {
+		t.Errorf("content = %q, want quoted argument kept", got)
+	}
+	want := "$ " + step.Commands[0] + "\n$ " + step.Commands[1] + "\nHI THERE\n"
+	if stdout.String() != want {
+		t.Errorf("output = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	var stdout bytes.Buffer
+	r := Runner{Stdout: &stdout, Stderr: &stdout}
+	err := r.Run(context.Background(), Step{Commands: []string{"exit 3", "echo unreachable"}})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 || exitErr.Command != "exit 3" {
+		t.Fatalf("err = %v, want exit code 3 of the first command", err)
+	}
+	if errs.KindOf(err) != errs.BuildFailed {
+		t.Errorf("kind = %v, want %v", errs.KindOf(err), errs.BuildFailed)
+	}
+	if strings.Contains(stdout.String(), "unreachable") {
+		t.Errorf("commands after the failure should not run, output %q", stdout.String())
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Runner{Stdout: ioutil.Discard}.Run(ctx, Step{Commands: []string{"echo canceled"}})
+	if errs.KindOf(err) != errs.Canceled {
+		t.Errorf("err = %v, want canceled", err)
+	}
+}
+
+func TestRunCanceledKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// sleep is a child of sh holding the output, Run would wait for it if only sh were killed
+	var stdout bytes.Buffer
+	start := time.Now()
+	err := Runner{Stdout: &stdout}.Run(ctx, Step{Commands: []string{"sleep 30; echo unreachable"}})
+	if errs.KindOf(err) != errs.Canceled {
+		t.Errorf("err = %v, want canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("canceled run returned after %s", elapsed)
+	}
+}
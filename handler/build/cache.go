@@ -0,0 +1,283 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/let-sh/cli/utils/cache"
+)
+
+// MaxCached is the number of outputs kept for each project, the least recently used ones are removed
+const MaxCached = 3
+
+const (
+	entryFile = "build.json"
+	outputDir = "output"
+)
+
+// Entry is a build output cached under <root>/<project>/<key>/
+type Entry struct {
+	Project string `json:"project"`
+	Key     string `json:"key"`
+	Inputs  Inputs `json:"inputs"`
+	// Commands built the output
+	Commands []string `json:"commands"`
+	// OutputDir is the output dir relative to project dir, restored from the cached one
+	OutputDir string    `json:"output_dir"`
+	Files     int       `json:"files"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	// UsedAt is the last time the output was built or reused
+	UsedAt time.Time `json:"used_at"`
+}
+
+// Cache stores build outputs by project and the key of inputs
+type Cache struct {
+	Root string
+}
+
+// DefaultCache returns the cache under ~/.let/cache
+func DefaultCache() Cache {
+	return Cache{Root: cache.BuildCacheDir()}
+}
+
+func (c Cache) dir(project, key string) string {
+	return filepath.Join(c.Root, filepath.Base(project), filepath.Base(key))
+}
+
+// Get returns the entry of project by key, nil if not cached
+func (c Cache) Get(project, key string) (*Entry, error) {
+	dir := c.dir(project, key)
+	content, err := ioutil.ReadFile(filepath.Join(dir, entryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, err
+	}
+	// output removed by hand
+	if _, err := os.Stat(filepath.Join(dir, outputDir)); err != nil {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Latest returns the most recently used entry of project, nil if none
+func (c Cache) Latest(project string) (*Entry, error) {
+	entries, err := c.List(project)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// Save copies the files under output inside project dir into the cache as entry,
+// files and size of entry are counted.
+// The least recently used entries of project beyond MaxCached are removed.
+func (c Cache) Save(entry *Entry, projectDir, output string) error {
+	if !Inside(projectDir, output) {
+		return fmt.Errorf("output dir %s is not inside project dir %s", output, projectDir)
+	}
+	entriesDir := filepath.Join(c.Root, filepath.Base(entry.Project))
+	if err := os.MkdirAll(entriesDir, os.ModePerm); err != nil {
+		return err
+	}
+	// copy to a temp dir first, so a broken copy is never read as cached
+	tmp, err := ioutil.TempDir(entriesDir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if entry.Files, entry.Size, err = copyDir(output, filepath.Join(tmp, outputDir)); err != nil {
+		return err
+	}
+	if err := writeEntry(tmp, *entry); err != nil {
+		return err
+	}
+	dir := c.dir(entry.Project, entry.Key)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return err
+	}
+	return c.prune(entry.Project)
+}
+
+// Restore replaces output of project dir by the cached output of entry, then marks entry used.
+// output is removed only if it is inside project dir, never the project dir itself or its ancestors.
+func (c Cache) Restore(entry *Entry, projectDir, output string) error {
+	if !Inside(projectDir, output) {
+		return fmt.Errorf("output dir %s is not inside project dir %s", output, projectDir)
+	}
+	if err := os.RemoveAll(output); err != nil {
+		return err
+	}
+	dir := c.dir(entry.Project, entry.Key)
+	if _, _, err := copyDir(filepath.Join(dir, outputDir), output); err != nil {
+		return err
+	}
+	entry.UsedAt = time.Now()
+	return writeEntry(dir, *entry)
+}
+
+// Inside tells whether path is under dir, neither dir itself nor out of it
+func Inside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// List returns the entries of projects, of all projects if none is given.
+// Entries are sorted by project, then the most recently used first.
+func (c Cache) List(projects ...string) ([]Entry, error) {
+	if len(projects) == 0 {
+		infos, err := ioutil.ReadDir(c.Root)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				projects = append(projects, info.Name())
+			}
+		}
+	}
+
+	entries := []Entry{}
+	for _, project := range projects {
+		infos, err := ioutil.ReadDir(filepath.Join(c.Root, filepath.Base(project)))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, info := range infos {
+			// skip the temp dirs of saving entries
+			if strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			entry, err := c.Get(project, info.Name())
+			if err != nil {
+				return nil, err
+			}
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Project != entries[j].Project {
+			return entries[i].Project < entries[j].Project
+		}
+		return entries[i].UsedAt.After(entries[j].UsedAt)
+	})
+	return entries, nil
+}
+
+// Remove removes the entries of projects not used since before, of all projects if none is given.
+// All of them are removed if before is zero, the removed ones are returned.
+func (c Cache) Remove(before time.Time, projects ...string) ([]Entry, error) {
+	entries, err := c.List(projects...)
+	if err != nil {
+		return nil, err
+	}
+	removed := []Entry{}
+	for _, entry := range entries {
+		if !before.IsZero() && !entry.UsedAt.Before(before) {
+			continue
+		}
+		if err := os.RemoveAll(c.dir(entry.Project, entry.Key)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+
+	// drop the dirs of projects left empty
+	for _, entry := range removed {
+		projectDir := filepath.Join(c.Root, filepath.Base(entry.Project))
+		if infos, err := ioutil.ReadDir(projectDir); err == nil && len(infos) == 0 {
+			os.Remove(projectDir)
+		}
+	}
+	return removed, nil
+}
+
+// prune removes the least recently used entries of project beyond MaxCached
+func (c Cache) prune(project string) error {
+	entries, err := c.List(project)
+	if err != nil {
+		return err
+	}
+	for i := MaxCached; i < len(entries); i++ {
+		if err := os.RemoveAll(c.dir(project, entries[i].Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEntry(dir string, entry Entry) error {
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	// inputs are digests of env values, still not for other users to read
+	return ioutil.WriteFile(filepath.Join(dir, entryFile), content, 0600)
+}
+
+// copyDir copies the files, dirs and symlinks under src to dst, returns the count and size of files
+func copyDir(src, dst string) (files int, size int64, err error) {
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			files++
+			size += info.Size()
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+	return files, size, err
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,189 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tempDir(t *testing.T, prefix string) string {
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestHashInputs(t *testing.T) {
+	dir := tempDir(t, "project")
+	writeFiles(t, dir, map[string]string{
+		"package-lock.json": `{"lockfileVersion": 2}`,
+		"src/index.js":      "console.log(1)",
+		".gitignore":        "*.log",
+	})
+	step := Step{Commands: []string{"npm run build"}, OutputDir: "dist", Env: map[string]string{"API": "a"}}
+	output := filepath.Join(dir, "dist")
+	hash := func(step Step) Inputs {
+		inputs, err := HashInputs(dir, dir, output, step, []byte("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return inputs
+	}
+	base := hash(step)
+
+	// env values are hashed by the key, not to be guessed from the cache entries
+	other, err := HashInputs(dir, dir, output, step, []byte("other key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Config == base.Config {
+		t.Error("config digest unchanged by the secret key")
+	}
+
+	// outputs and ignored files are not inputs
+	writeFiles(t, dir, map[string]string{"dist/index.html": "built", "debug.log": "log"})
+	if changed := hash(step).Changed(base); changed != nil {
+		t.Errorf("changed = %v after building, want none", changed)
+	}
+
+	changedEnv := Step{Commands: step.Commands, OutputDir: "dist", Env: map[string]string{"API": "b"}}
+	for _, c := range []struct {
+		name  string
+		files map[string]string
+		step  Step
+		want  []string
+	}{
+		{"lockfile", map[string]string{"package-lock.json": `{"lockfileVersion": 3}`}, step, []string{"lockfiles"}},
+		{"env", nil, changedEnv, []string{"build config"}},
+		{"source", map[string]string{"src/index.js": "console.log(2)"}, changedEnv, []string{"sources"}},
+	} {
+		writeFiles(t, dir, c.files)
+		inputs := hash(c.step)
+		if changed := inputs.Changed(base); !reflect.DeepEqual(changed, c.want) {
+			t.Errorf("%s: changed = %v, want %v", c.name, changed, c.want)
+		}
+		if inputs.Key() == base.Key() {
+			t.Errorf("%s: key unchanged", c.name)
+		}
+		base = inputs
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := Cache{Root: tempDir(t, "cache")}
+	dir := tempDir(t, "project")
+	output := filepath.Join(dir, "dist")
+	writeFiles(t, dir, map[string]string{"dist/index.html": "<h1>hi</h1>", "dist/assets/app.js": "app()"})
+
+	if entry, err := c.Get("site", "key"); err != nil || entry != nil {
+		t.Fatalf("Get before saving = %v, %v, want nil", entry, err)
+	}
+
+	now := time.Now()
+	entry := &Entry{Project: "site", Key: "key", OutputDir: "dist", CreatedAt: now, UsedAt: now}
+	if err := c.Save(entry, dir, output); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Files != 2 || entry.Size != int64(len("<h1>hi</h1>")+len("app()")) {
+		t.Errorf("saved %d files of %d bytes", entry.Files, entry.Size)
+	}
+
+	// output changed after saving is restored from cache
+	writeFiles(t, dir, map[string]string{"dist/index.html": "stale", "dist/extra.txt": "extra"})
+	cached, err := c.Get("site", "key")
+	if err != nil || cached == nil {
+		t.Fatalf("Get = %v, %v", cached, err)
+	}
+	if err := c.Restore(cached, dir, output); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(output, "index.html")); string(content) != "<h1>hi</h1>" {
+		t.Errorf("restored index.html = %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(output, "extra.txt")); !os.IsNotExist(err) {
+		t.Errorf("stale file kept after restoring: %v", err)
+	}
+	// the project dir itself is never removed
+	if err := c.Restore(cached, dir, dir); err == nil {
+		t.Error("restore into project dir should fail")
+	}
+	if _, err := os.Stat(filepath.Join(output, "index.html")); err != nil {
+		t.Errorf("project dir removed: %v", err)
+	}
+	if !cached.UsedAt.After(now) {
+		t.Errorf("used at = %v, want updated by restoring", cached.UsedAt)
+	}
+
+	// least recently used entries are pruned
+	for i, key := range []string{"k1", "k2", "k3"} {
+		used := now.Add(time.Duration(i+1) * time.Hour)
+		if err := c.Save(&Entry{Project: "site", Key: key, CreatedAt: used, UsedAt: used}, dir, output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Save(&Entry{Project: "blog", Key: "k1", CreatedAt: now, UsedAt: now}, dir, output); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		keys = append(keys, e.Project+"/"+e.Key)
+	}
+	if want := []string{"blog/k1", "site/k3", "site/k2", "site/k1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("entries = %v, want %v", keys, want)
+	}
+
+	removed, err := c.Remove(now.Add(150*time.Minute), "site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d entries used before, want 2", len(removed))
+	}
+	if removed, err = c.Remove(time.Time{}); err != nil || len(removed) != 2 {
+		t.Errorf("removed %d entries, %v, want all of 2", len(removed), err)
+	}
+	if infos, _ := ioutil.ReadDir(c.Root); len(infos) != 0 {
+		t.Errorf("%d dirs left after clearing", len(infos))
+	}
+}
+
+func TestInside(t *testing.T) {
+	dir := filepath.FromSlash("/home/me/site")
+	for _, c := range []struct {
+		path string
+		want bool
+	}{
+		{"/home/me/site/dist", true},
+		{"/home/me/site/a/../dist", true},
+		{"/home/me/site/..dist", true},
+		{"/home/me/site", false},
+		{"/home/me", false},
+		{"/", false},
+		{"/home/me/site-dist", false},
+	} {
+		if got := Inside(dir, filepath.FromSlash(c.path)); got != c.want {
+			t.Errorf("Inside(%s) = %v, want %v", c.path, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package build
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/let-sh/cli/utils/ignore"
+)
+
+// Lockfiles are the lock files of package managers, hashed apart from the other sources,
+// since changed dependencies are the common reason to rebuild
+var Lockfiles = []string{
+	"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb",
+	"go.sum", "Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock", "Pipfile.lock",
+}
+
+// Inputs are the sha256 digests of what a build depends on
+type Inputs struct {
+	// Lockfiles are the lock files under project dir and workspace root
+	Lockfiles string `json:"lockfiles"`
+	// Config are the commands, output dir and env of build step, env values are hashed by the secret key
+	Config string `json:"config"`
+	// Sources are the files not ignored under project dir, except lock files and the output dir
+	Sources string `json:"sources"`
+}
+
+// Key returns the cache key of inputs
+func (i Inputs) Key() string {
+	sum := sha256.Sum256([]byte(i.Lockfiles + i.Config + i.Sources))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Changed returns the names of inputs differing from o
+func (i Inputs) Changed(o Inputs) []string {
+	var changed []string
+	if i.Lockfiles != o.Lockfiles {
+		changed = append(changed, "lockfiles")
+	}
+	if i.Config != o.Config {
+		changed = append(changed, "build config")
+	}
+	if i.Sources != o.Sources {
+		changed = append(changed, "sources")
+	}
+	return changed
+}
+
+// HashInputs hashes the inputs of step for project under dir, root is the workspace root holding the lock files
+// of monorepo packages, the same as dir otherwise. outputDir is skipped from sources, as it's produced by step.
+// key is the secret key to hash env values with, so that cache entries can't be used to guess them.
+func HashInputs(root, dir, outputDir string, step Step, key []byte) (inputs Inputs, err error) {
+	lockfiles := sha256.New()
+	for _, d := range uniqueDirs(root, dir) {
+		for _, name := range Lockfiles {
+			if err := hashFile(lockfiles, filepath.Join(d, name), name); err != nil && !os.IsNotExist(err) {
+				return inputs, err
+			}
+		}
+	}
+
+	// env is encoded with sorted keys
+	env := make(map[string]string, len(step.Env))
+	for name, value := range step.Env {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		env[name] = hex.EncodeToString(mac.Sum(nil))
+	}
+	config, err := json.Marshal(struct {
+		Commands  []string          `json:"commands"`
+		OutputDir string            `json:"output_dir"`
+		Env       map[string]string `json:"env"`
+	}{step.Commands, step.OutputDir, env})
+	if err != nil {
+		return inputs, err
+	}
+
+	output, err := filepath.Rel(dir, outputDir)
+	if err != nil {
+		return inputs, err
+	}
+	output = filepath.ToSlash(output)
+	m, err := ignore.New(dir)
+	if err != nil {
+		return inputs, err
+	}
+	sources := sha256.New()
+	err = m.Walk(func(rel string, info os.FileInfo) error {
+		if rel == output || strings.HasPrefix(rel, output+"/") || isLockfile(rel) || !info.Mode().IsRegular() {
+			return nil
+		}
+		return hashFile(sources, filepath.Join(dir, filepath.FromSlash(rel)), rel)
+	})
+	if err != nil {
+		return inputs, err
+	}
+
+	return Inputs{
+		Lockfiles: hex.EncodeToString(lockfiles.Sum(nil)),
+		Config:    digest(config),
+		Sources:   hex.EncodeToString(sources.Sum(nil)),
+	}, nil
+}
+
+// hashFile writes the name and the digest of file content to h
+func hashFile(h hash.Hash, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	content := sha256.New()
+	if _, err := io.Copy(content, f); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(h, "%s\x00%x\n", name, content.Sum(nil))
+	return err
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isLockfile tells whether rel is a lock file hashed apart, nested ones are hashed as sources
+func isLockfile(rel string) bool {
+	for _, lockfile := range Lockfiles {
+		if rel == lockfile {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueDirs(root, dir string) []string {
+	if root == "" || root == dir {
+		return []string{dir}
+	}
+	return []string{root, dir}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package build
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shell returns the command running line by sh
+func shell(ctx context.Context, line string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// run runs cmd in a process group of its own, the whole group is killed once ctx is done,
+// so that the processes started by the line, e.g. a dev server of npm run, don't outlive sh
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package build
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shell returns the command running line by cmd.exe, the line is passed as is,
+// since cmd.exe does not follow the quoting rules of other programs
+func shell(ctx context.Context, line string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd.exe /d /s /c "` + line + `"`}
+	return cmd
+}
+
+// run runs cmd, cmd.exe is killed once ctx is done
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	return cmd.Run()
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/types"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of config file under project dir, in json, yaml or toml
+var FileNames = []string{FileName, "let.yaml", "let.yml", "let.toml"}
+
+// Channels are the channels to deploy, which could be configured in environments
+var Channels = []string{"prod", "dev"}
+
+// environmentsKey refers the config overrides by channel
+const environmentsKey = "environments"
+
+var errorLine = regexp.MustCompile(`line (\d+)`)
+
+// File is a parsed config file of project
+type File struct {
+	// Path is empty if project has no config file
+	Path string
+	doc  map[string]interface{}
+}
+
+// Find returns the path of config file under dir, empty if not found.
+// Several config files are rejected, since only one of them could be read.
+func Find(dir string) (string, error) {
+	var found []string
+	for _, name := range FileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return filepath.Join(dir, found[0]), nil
+	default:
+		return "", errs.Newf(errs.Validation, "found %s under %s, please keep only one of them",
+			strings.Join(found, " and "), dir)
+	}
+}
+
+// Read reads the config file under dir, invalid config is rejected with the problems.
+// The file is empty if not found.
+func Read(dir string) (*File, error) {
+	path, err := Find(dir)
+	if err != nil || path == "" {
+		return &File{}, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	if err := Error(name, validate(name, dir, data)); err != nil {
+		return nil, err
+	}
+	doc, _, err := decode(name, data)
+	if err != nil {
+		return nil, errs.Wrap(errs.Validation, err)
+	}
+	return &File{Path: path, doc: doc}, nil
+}
+
+// Environments returns the channels configured in environments, sorted
+func (f *File) Environments() []string {
+	envs, _ := f.doc[environmentsKey].(map[string]interface{})
+	var channels []string
+	for channel := range envs {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Config returns the config with the environment of channel merged, no environment is merged if channel is empty
+func (f *File) Config(channel string) (types.LetConfig, error) {
+	return resolve(f.doc, channel)
+}
+
+// resolve merges the environment of channel into the rest of doc, then decodes it
+func resolve(doc map[string]interface{}, channel string) (c types.LetConfig, err error) {
+	base := map[string]interface{}{}
+	for k, v := range doc {
+		if k != schemaKey && k != environmentsKey {
+			base[k] = v
+		}
+	}
+	envs, _ := doc[environmentsKey].(map[string]interface{})
+	if env, ok := envs[channel].(map[string]interface{}); ok && channel != "" {
+		base = Merge(base, env)
+	}
+
+	data, err := json.Marshal(base)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// Merge returns override deep merged into base, neither of them is modified:
+// objects are merged by key, other values including arrays are replaced, null removes the key.
+func Merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		overrideObject, ok := v.(map[string]interface{})
+		if baseObject, isObject := merged[k].(map[string]interface{}); ok && isObject {
+			merged[k] = Merge(baseObject, overrideObject)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// position is the line and column of a field in config file, both start from 1
+type position struct {
+	line, column int
+}
+
+// decode converts config content of file to a json object, positions of fields are returned by path for
+// yaml and toml, syntax errors of json are left to checker.
+func decode(file string, data []byte) (doc map[string]interface{}, positions map[string]position, err error) {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, nil, err
+		}
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		positions = map[string]position{}
+		yamlPositions(&node, "", positions)
+		doc, err := jsonObject(v)
+		return doc, positions, err
+	case ".toml":
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		positions = map[string]position{}
+		tomlPositions(tree, "", positions)
+		doc, err := jsonObject(tree.ToMap())
+		return doc, positions, err
+	default:
+		err = json.Unmarshal(data, &doc)
+		return doc, nil, err
+	}
+}
+
+// jsonObject converts decoded yaml or toml to the object decoded from json,
+// so it could be checked and merged the same way
+func jsonObject(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+	data, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("config should be an object")
+	}
+	return doc, nil
+}
+
+// jsonValue converts the keys of objects to strings, keys of yaml could be numbers
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, item := range v {
+			m[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = jsonValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+	return v
+}
+
+// yamlPositions records the positions of keys for object members and values for array items, as checker does
+func yamlPositions(node *yaml.Node, path string, positions map[string]position) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlPositions(n, path, positions)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			member := join(path, key.Value)
+			positions[member] = position{key.Line, key.Column}
+			yamlPositions(value, member, positions)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			item := fmt.Sprintf("%s[%d]", path, i)
+			positions[item] = position{n.Line, n.Column}
+			yamlPositions(n, item, positions)
+		}
+	}
+}
+
+// tomlPositions records the positions of keys and tables, items of plain arrays are positioned by the array
+func tomlPositions(tree *toml.Tree, path string, positions map[string]position) {
+	for _, key := range tree.Keys() {
+		member := join(path, key)
+		pos := tree.GetPositionPath([]string{key})
+		positions[member] = position{pos.Line, pos.Col}
+		switch v := tree.GetPath([]string{key}).(type) {
+		case *toml.Tree:
+			tomlPositions(v, member, positions)
+		case []*toml.Tree:
+			for i, t := range v {
+				item := fmt.Sprintf("%s[%d]", member, i)
+				positions[item] = position{t.Position().Line, t.Position().Col}
+				tomlPositions(t, item, positions)
+			}
+		}
+	}
+}
+
+// errorPosition returns the position reported by yaml or toml error, the start of file if unknown
+func errorPosition(err error) position {
+	var line, column int
+	if _, scanErr := fmt.Sscanf(err.Error(), "(%d, %d)", &line, &column); scanErr == nil {
+		return position{line, column}
+	}
+	if m := errorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		return position{line, 1}
+	}
+	return position{1, 1}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/let-sh/cli/log/errs"
+)
+
+func TestMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"name":      "site",
+		"env":       map[string]interface{}{"A": "1", "B": "2"},
+		"redirects": []interface{}{map[string]interface{}{"source": "/a", "destination": "/b"}},
+		"cn":        true,
+	}
+	override := map[string]interface{}{
+		"env":       map[string]interface{}{"B": "3", "C": "4"},
+		"redirects": []interface{}{},
+		"cn":        nil,
+	}
+	want := map[string]interface{}{
+		"name":      "site",
+		"env":       map[string]interface{}{"A": "1", "B": "3", "C": "4"},
+		"redirects": []interface{}{},
+	}
+	if got := Merge(base, override); !reflect.DeepEqual(got, want) {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+	if env := base["env"].(map[string]interface{}); len(env) != 2 || env["B"] != "2" || base["cn"] != true {
+		t.Errorf("base modified by merge: %v", base)
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	for file, c := range map[string]struct {
+		content string
+		want    []string
+	}{
+		"let.yaml": {`name: site
+cn: yes
+redirects:
+  - source: /a
+    destination: /a
+environments:
+  prod:
+    env:
+      A: 1
+`, []string{
+			`let.yaml:2:1: cn: expect boolean, got string`,
+			`let.yaml:9:7: environments.prod.env.A: expect string, got number`,
+		}},
+		"let.yml": {"redirects:\n  - source: /a\n    destination: /a\n", []string{
+			`let.yml:2:5: redirects[0]: redirect loop: /a -> /a`,
+		}},
+		"let.toml": {`name = "site"
+Static = "public"
+
+[[redirects]]
+source = "/a"
+destination = "b"
+
+[sourceLimit]
+max = 1024
+`, []string{
+			`let.toml:2:1: Static: unknown key "Static", did you mean "static"?`,
+			`let.toml:9:1: sourceLimit.max: expect string, got number`,
+		}},
+		"let.json": {`{"name": "site"}`, nil},
+	} {
+		var got []string
+		for _, p := range Validate(file, []byte(c.content)) {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: problems =\n%q\nwant\n%q", file, got, c.want)
+		}
+	}
+}
+
+func TestValidateFormatSyntax(t *testing.T) {
+	for file, content := range map[string]string{
+		"let.yaml": "name: site\nenv: a: b\n",
+		"let.toml": "name = \"site\"\nenv = \n",
+	} {
+		problems := Validate(file, []byte(content))
+		if len(problems) != 1 || problems[0].Line == 1 {
+			t.Errorf("problems of %s = %v, want a syntax error after line 1", file, problems)
+		}
+	}
+	problems := Validate("let.yaml", []byte("- a\n- b\n"))
+	if len(problems) != 1 || problems[0].Message != "config should be an object" {
+		t.Errorf("problems of array = %v", problems)
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := Read(dir)
+	if err != nil || f.Path != "" || len(f.Environments()) != 0 {
+		t.Fatalf("read without config file = %+v, %v", f, err)
+	}
+
+	content := `name = "site"
+static = "public"
+
+[env]
+API = "https://api.dev.example.com"
+MODE = "web"
+
+[environments.prod]
+static = "dist"
+
+[environments.prod.env]
+API = "https://api.example.com"
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "let.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Environments(); !reflect.DeepEqual(got, []string{"prod"}) {
+		t.Errorf("environments = %v, want prod", got)
+	}
+	for channel, want := range map[string][]string{
+		"":     {"public", "https://api.dev.example.com"},
+		"dev":  {"public", "https://api.dev.example.com"},
+		"prod": {"dist", "https://api.example.com"},
+	} {
+		c, err := f.Config(channel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Name != "site" || c.Static != want[0] || c.Env["API"] != want[1] || c.Env["MODE"] != "web" {
+			t.Errorf("config of %q = %+v, want %v", channel, c, want)
+		}
+		if c.Environments != nil {
+			t.Errorf("config of %q keeps environments %v", channel, c.Environments)
+		}
+	}
+
+	// only one config file could be read
+	if err := ioutil.WriteFile(filepath.Join(dir, FileName), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Read(dir)
+	if errs.KindOf(err) != errs.Validation || !strings.Contains(err.Error(), "let.json and let.toml") {
+		t.Errorf("read several config files: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build ignore
+
+// gen_schema writes the JSON Schema of let.json to the file of first argument
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/let-sh/cli/handler/config"
+)
+
+func main() {
+	content, err := config.MarshalSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(os.Args[1], content, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,189 @@
+// Package config validates and merges config files of project against types.LetConfig, and generates its JSON Schema.
+package config
+
+//go:generate go run gen_schema.go ../../schema/let.schema.json
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/let-sh/cli/types"
+)
+
+// FileName is the config file under project dir
+const FileName = "let.json"
+
+// schemaKey refers the schema of config file for editors, ignored on deploy
+const schemaKey = "$schema"
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// descriptions of fields by path, array items are referred by []
+var descriptions = map[string]string{
+	"name":                    "project name, default to the name of project dir",
+	"type":                    "project type, detected if omitted, e.g. static, react, gin",
+	"env":                     "environment variables of deployment",
+	"static":                  "dir of static files, relative to project dir",
+	"redirects":               "redirects applied before serving files, the first matched one wins",
+	"redirects[].source":      "path pattern like /blog/:slug or /docs/*",
+	"redirects[].destination": "path or http(s) url, params of source are referred by :name",
+	"redirects[].type":        "status code of redirect, 301 if omitted",
+	"rewrites":                "rewrites of requests to missing files, the first matched one wins",
+	"rewrites[].source":       "path pattern like /app/*",
+	"rewrites[].destination":  "path served instead, params of source are referred by :name",
+	"link":                    "projects linked to this one",
+	"cn":                      "deploy to mainland China",
+	"web3":                    "deploy to web3 infra",
+	"headers":                 "response headers of static files, later rules win",
+	"headers[].source":        "gitignore style glob of files relative to static dir, e.g. *.html or fonts/**",
+	"headers[].headers":       "headers to set, an empty value removes the default one",
+	"sourceLimit":             "lower size limits of source code than the plan",
+	"sourceLimit.confirm":     "size to confirm before uploading, e.g. 20MB",
+	"sourceLimit.max":         "size to abort uploading, e.g. 40MB",
+	"build":                   "local build step, overrides the one of project type",
+	"build.command":           "shell command to build, e.g. npm ci && npm run build",
+	"build.outputDir":         "dir of built static files inside project dir, overrides static",
+	"build.env":               "environment variables only for the build command, over env",
+	"environments":            "config overrides by channel, objects are merged by key, other values are replaced",
+}
+
+// enums of fields by path
+var enums = map[string][]interface{}{
+	"redirects[].type": {301, 302, 303, 307, 308},
+}
+
+// Schema returns the JSON Schema of let.json generated from types.LetConfig
+func Schema() map[string]interface{} {
+	schema := typeSchema("", reflect.TypeOf(types.LetConfig{}))
+	schema["properties"].(map[string]interface{})[schemaKey] = map[string]interface{}{
+		"description": "schema of this file, for completion and validation in editors",
+		"type":        "string",
+	}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "let.json"
+	schema["description"] = "config of let.sh project"
+	return schema
+}
+
+// MarshalSchema returns the indented JSON Schema, as published in schema/let.schema.json
+func MarshalSchema() ([]byte, error) {
+	content, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func typeSchema(path string, t reflect.Type) map[string]interface{} {
+	schema := map[string]interface{}{}
+	// fields of environments are described as the top level ones
+	key := strings.TrimPrefix(path, environmentsKey+"[].")
+	if d, ok := descriptions[key]; ok {
+		schema["description"] = d
+	}
+	if e, ok := enums[key]; ok {
+		schema["enum"] = e
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// decoded from string like datasize.ByteSize of "20MB"
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		schema["type"] = "string"
+		return schema
+	}
+
+	schema["type"] = kindType(t.Kind())
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for _, f := range fields(t) {
+			if f.name == environmentsKey {
+				if path == "" {
+					properties[f.name] = environmentsSchema(f.typ)
+				}
+				// environments could not be nested
+				continue
+			}
+			properties[f.name] = typeSchema(join(path, f.name), f.typ)
+		}
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	case reflect.Slice:
+		schema["items"] = typeSchema(path+"[]", t.Elem())
+	case reflect.Map:
+		schema["additionalProperties"] = typeSchema(path+"[]", t.Elem())
+	}
+	return schema
+}
+
+// environmentsSchema returns the schema of environments of type t, keyed by channels
+func environmentsSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, channel := range Channels {
+		schema := typeSchema(environmentsKey+"[]", t.Elem())
+		schema["description"] = "merged into the config on deploying to " + channel + " channel"
+		properties[channel] = schema
+	}
+	return map[string]interface{}{
+		"description":          descriptions[environmentsKey],
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func kindType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		return "array"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+type field struct {
+	name string
+	typ  reflect.Type
+}
+
+// fields returns the json fields of struct t, fields of embedded structs are promoted
+func fields(t reflect.Type) []field {
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			result = append(result, fields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		result = append(result, field{name: name, typ: f.Type})
+	}
+	return result
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
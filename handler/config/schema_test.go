@@ -0,0 +1,32 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSchemaPublished(t *testing.T) {
+	published, err := ioutil.ReadFile("../../schema/let.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := MarshalSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published) != string(content) {
+		t.Error("schema/let.schema.json is outdated, please run go generate ./handler/config")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	properties := Schema()["properties"].(map[string]interface{})
+	limit := properties["sourceLimit"].(map[string]interface{})["properties"].(map[string]interface{})
+	if typ := limit["max"].(map[string]interface{})["type"]; typ != "string" {
+		t.Errorf("type of sourceLimit.max = %v, want string", typ)
+	}
+	redirect := properties["redirects"].(map[string]interface{})["items"].(map[string]interface{})
+	if redirect["additionalProperties"] != false {
+		t.Errorf("redirect schema = %v", redirect)
+	}
+}
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/let-sh/cli/handler/routing"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/types"
+)
+
+// Problem is a problem of config file at line and column, both start from 1
+type Problem struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Path is the json path of the field, e.g. redirects[0].source, empty for the whole file
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	s := fmt.Sprintf("%s:%d:%d: ", p.File, p.Line, p.Column)
+	if p.Path != "" {
+		s += p.Path + ": "
+	}
+	return s + p.Message
+}
+
+// Validate reports syntax errors, unknown keys and type errors of config content in the format of file,
+// then the semantic problems of redirects, rewrites, headers and output dirs if it is well typed,
+// with the environment of each channel merged. Output dirs are resolved against the dir of file.
+func Validate(file string, data []byte) []Problem {
+	return validate(file, filepath.Dir(file), data)
+}
+
+// validate validates the config content of file under project dir
+func validate(file, dir string, data []byte) []Problem {
+	c := &checker{file: file, dir: dir, data: data, offsets: map[string]int{}}
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml", ".toml":
+		// checked as the converted json, problems are positioned by path
+		doc, positions, err := decode(file, data)
+		if err != nil {
+			pos := errorPosition(err)
+			return []Problem{{File: file, Line: pos.line, Column: pos.column, Message: err.Error()}}
+		}
+		if c.data, err = json.Marshal(doc); err != nil {
+			return []Problem{{File: file, Line: 1, Column: 1, Message: err.Error()}}
+		}
+		c.positions = positions
+	}
+	return c.check()
+}
+
+// check walks the json content, then validates the routing of config and its environments
+func (c *checker) check() []Problem {
+	c.dec = json.NewDecoder(bytes.NewReader(c.data))
+	c.dec.UseNumber()
+
+	if err := c.value("", reflect.TypeOf(types.LetConfig{})); err != nil {
+		c.syntaxError(err)
+		return c.problems
+	}
+	rest := c.data[c.dec.InputOffset():]
+	if trimmed := bytes.TrimLeft(rest, " \t\r\n"); len(trimmed) > 0 {
+		c.report(len(c.data)-len(trimmed), "", "unexpected content after the config object")
+		return c.problems
+	}
+	if len(c.problems) > 0 {
+		return c.problems
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(c.data, &doc); err != nil {
+		c.report(0, "", err.Error())
+		return c.problems
+	}
+	base, err := resolve(doc, "")
+	if err != nil {
+		c.report(0, "", err.Error())
+		return c.problems
+	}
+	for _, p := range c.semantics(base) {
+		c.report(c.offsetOf(p.Path), p.Path, p.Message)
+	}
+
+	// problems of environments are reported only for the fields they override
+	envs, _ := doc[environmentsKey].(map[string]interface{})
+	for _, channel := range Channels {
+		env, ok := envs[channel].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		config, err := resolve(doc, channel)
+		if err != nil {
+			c.report(c.offsetOf(environmentsKey+"."+channel), environmentsKey+"."+channel, err.Error())
+			continue
+		}
+		for _, p := range c.semantics(config) {
+			if _, overridden := env[topKey(p.Path)]; overridden {
+				path := join(environmentsKey+"."+channel, p.Path)
+				c.report(c.offsetOf(path), path, p.Message)
+			}
+		}
+	}
+	return c.problems
+}
+
+// semantics returns the problems of routing and output dirs of resolved config
+func (c *checker) semantics(config types.LetConfig) []routing.Problem {
+	problems := routing.Validate(config)
+	check := func(path, output string) {
+		if output != "" && containsDir(output, c.dir) {
+			problems = append(problems, routing.Problem{Path: path,
+				Message: fmt.Sprintf("%q contains the project dir, the output dir must be inside it", output)})
+		}
+	}
+	check("static", config.Static)
+	if config.Build != nil {
+		check("build.outputDir", config.Build.OutputDir)
+	}
+	return problems
+}
+
+// containsDir tells whether output resolved against dir is an ancestor of dir
+func containsDir(output, dir string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+	rel, err := filepath.Rel(filepath.Clean(output), dir)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Check returns a validation error listing the problems of config content, nil if valid
+func Check(file string, data []byte) error {
+	return Error(file, Validate(file, data))
+}
+
+// Error returns a validation error listing problems, nil if no problem
+func Error(file string, problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	lines := []string{"invalid " + file + ":"}
+	for _, p := range problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return errs.New(errs.Validation, strings.Join(lines, "\n"))
+}
+
+// checker walks the json tokens along the type of config
+type checker struct {
+	file string
+	// dir is the project dir, output dirs are resolved against it
+	dir      string
+	data     []byte
+	dec      *json.Decoder
+	problems []Problem
+	// offsets of fields by path, keys for object members and values for array items
+	offsets map[string]int
+	// positions of fields by path in the yaml or toml file converted to data, nil for json
+	positions map[string]position
+}
+
+// value checks the next value against type t, only syntax errors are returned
+func (c *checker) value(path string, t reflect.Type) error {
+	start := c.next()
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		// null leaves the zero value
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch {
+		case v == '{' && t.Kind() == reflect.Struct:
+			return c.object(path, func(key string) (reflect.Type, bool) {
+				if path == "" && key == schemaKey {
+					return reflect.TypeOf(""), true
+				}
+				if path != "" && key == environmentsKey {
+					// environments could not be nested
+					return nil, false
+				}
+				for _, f := range fields(t) {
+					if f.name == key {
+						return f.typ, true
+					}
+				}
+				return nil, false
+			})
+		case v == '{' && t.Kind() == reflect.Map:
+			return c.object(path, func(key string) (reflect.Type, bool) {
+				return t.Elem(), path != environmentsKey || isChannel(key)
+			})
+		case v == '[' && t.Kind() == reflect.Slice:
+			for i := 0; c.dec.More(); i++ {
+				item := fmt.Sprintf("%s[%d]", path, i)
+				c.offsets[item] = c.next()
+				if err := c.value(item, t.Elem()); err != nil {
+					return err
+				}
+			}
+			_, err := c.dec.Token()
+			return err
+		}
+		c.mismatch(start, path, t, map[json.Delim]string{'{': "object", '[': "array"}[v])
+		return c.skipRest()
+	case string:
+		if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+			if err := reflect.New(t).Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v)); err != nil {
+				c.report(start, path, fmt.Sprintf("invalid value %q: %s", v, err))
+			}
+			return nil
+		}
+		if t.Kind() != reflect.String {
+			c.mismatch(start, path, t, "string")
+		}
+	case json.Number:
+		if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+			c.mismatch(start, path, t, "number")
+			return nil
+		}
+		switch kindType(t.Kind()) {
+		case "integer":
+			unsigned := t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64
+			if n, err := strconv.ParseInt(v.String(), 10, 64); err != nil {
+				c.report(start, path, fmt.Sprintf("expect integer, got %s", v))
+			} else if n < 0 && unsigned {
+				c.report(start, path, fmt.Sprintf("expect non-negative integer, got %s", v))
+			}
+		case "number":
+		default:
+			c.mismatch(start, path, t, "number")
+		}
+	case bool:
+		if t.Kind() != reflect.Bool {
+			c.mismatch(start, path, t, "boolean")
+		}
+	}
+	return nil
+}
+
+// object checks the members of an object after its '{', fieldType returns the type of key if known
+func (c *checker) object(path string, fieldType func(key string) (reflect.Type, bool)) error {
+	for c.dec.More() {
+		start := c.next()
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		member := join(path, key)
+		c.offsets[member] = start
+
+		t, ok := fieldType(key)
+		if !ok {
+			c.report(start, member, fmt.Sprintf("unknown key %q%s", key, suggest(key, fieldType)))
+			if err := c.skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.value(member, t); err != nil {
+			return err
+		}
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+// suggest hints the known key differs in case, plural form, naming style or is the full name of channel
+func suggest(key string, fieldType func(key string) (reflect.Type, bool)) string {
+	if key == "" {
+		return ""
+	}
+	lower := strings.ToLower(key)
+	for _, k := range []string{lower, lower + "s", strings.TrimSuffix(lower, "s"), channelAliases[lower], camel(key),
+		snake(key)} {
+		if _, ok := fieldType(k); ok && k != key {
+			return fmt.Sprintf(", did you mean %q?", k)
+		}
+	}
+	return ""
+}
+
+// camel converts snake_case key to camelCase, e.g. output_dir to outputDir
+func camel(key string) string {
+	parts := strings.Split(strings.ToLower(key), "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// snake converts camelCase key to snake_case, e.g. outputDir to output_dir
+func snake(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// skip consumes the next value
+func (c *checker) skip() error {
+	var v json.RawMessage
+	return c.dec.Decode(&v)
+}
+
+// skipRest consumes the rest of object or array after its opening delim
+func (c *checker) skipRest() error {
+	for depth := 1; depth > 0; {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// next returns the offset of next token, skipping spaces and separators
+func (c *checker) next() int {
+	offset := int(c.dec.InputOffset())
+	for offset < len(c.data) && strings.IndexByte(" \t\r\n,:", c.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (c *checker) mismatch(offset int, path string, t reflect.Type, got string) {
+	expect := kindType(t.Kind())
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		expect = "string"
+	}
+	c.report(offset, path, fmt.Sprintf("expect %s, got %s", expect, got))
+}
+
+func (c *checker) syntaxError(err error) {
+	offset := len(c.data)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset)
+	}
+	c.report(offset, "", err.Error())
+}
+
+// offsetOf returns the offset of path, or its nearest parent recorded
+func (c *checker) offsetOf(path string) int {
+	return c.offsets[nearest(path, func(p string) bool {
+		_, ok := c.offsets[p]
+		return ok
+	})]
+}
+
+func (c *checker) report(offset int, path, message string) {
+	if c.positions != nil {
+		pos, ok := c.positions[nearest(path, func(p string) bool {
+			_, ok := c.positions[p]
+			return ok
+		})]
+		if !ok {
+			pos = position{1, 1}
+		}
+		c.problems = append(c.problems, Problem{File: c.file, Line: pos.line, Column: pos.column, Path: path,
+			Message: message})
+		return
+	}
+	if offset > len(c.data) {
+		offset = len(c.data)
+	}
+	line := 1 + bytes.Count(c.data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(c.data[:offset], '\n')
+	c.problems = append(c.problems, Problem{File: c.file, Line: line, Column: column, Path: path, Message: message})
+}
+
+// nearest returns path or its nearest parent recorded, empty if none
+func nearest(path string, recorded func(path string) bool) string {
+	for path != "" {
+		if recorded(path) {
+			return path
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return ""
+}
+
+// topKey returns the key of top level field in path, e.g. redirects of redirects[0].source
+func topKey(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// channelAliases are the full names of channels, which are often used as environments by mistake
+var channelAliases = map[string]string{"production": "prod", "development": "dev"}
+
+func isChannel(name string) bool {
+	for _, channel := range Channels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/log/errs"
+)
+
+func TestValidate(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"valid", `{
+  "$schema": "../schema/let.schema.json",
+  "name": "site",
+  "cn": true,
+  "redirects": [{"source": "/old/:slug", "destination": "/new/:slug", "type": 308}],
+  "sourceLimit": {"confirm": "20MB", "max": "40MB"}
+}`, nil},
+		{"types", `{
+  "Name": "site",
+  "cn": "yes",
+  "env": {"A": 1},
+  "redirects": {"source": "/a"},
+  "sourceLimit": {"max": "1.5MB", "confirm": 1024},
+  "channel": "prod",
+  "redirect": []
+}`, []string{
+			`let.json:2:3: Name: unknown key "Name", did you mean "name"?`,
+			`let.json:3:9: cn: expect boolean, got string`,
+			`let.json:4:16: env.A: expect string, got number`,
+			`let.json:5:16: redirects: expect array, got object`,
+			`let.json:6:26: sourceLimit.max: invalid value "1.5MB": ` + byteSizeError("1.5MB"),
+			`let.json:6:46: sourceLimit.confirm: expect string, got number`,
+			`let.json:7:3: channel: unknown key "channel"`,
+			`let.json:8:3: redirect: unknown key "redirect", did you mean "redirects"?`,
+		}},
+		{"build", `{
+  "build": {"command": "npm run build", "output_dir": "dist", "env": {"CI": true}},
+  "source_limit": {}
+}`, []string{
+			`let.json:2:41: build.output_dir: unknown key "output_dir", did you mean "outputDir"?`,
+			`let.json:2:77: build.env.CI: expect string, got boolean`,
+			`let.json:3:3: source_limit: unknown key "source_limit", did you mean "sourceLimit"?`,
+		}},
+		{"semantic", `{
+  "redirects": [
+    {"source": "/a", "destination": "/b"},
+    {"source": "/b", "destination": "/a", "type": 200}
+  ],
+  "headers": [{"source": "*.html", "headers": {"X-Frame-Options": "DENY\n"}}]
+}`, []string{
+			`let.json:4:43: redirects[1].type: invalid status code 200, expect one of 301, 302, 303, 307 and 308`,
+			`let.json:6:48: headers[0].headers.X-Frame-Options: header value should be a single line`,
+			`let.json:3:5: redirects[0]: redirect loop: /a -> /b -> /a`,
+			`let.json:4:5: redirects[1]: redirect loop: /b -> /a -> /b`,
+		}},
+		{"environments", `{
+  "redirects": [{"source": "/a", "destination": "/b"}],
+  "environments": {
+    "production": {},
+    "dev": {"environments": {}, "cn": 1},
+    "prod": {"redirects": [{"source": "/b", "destination": "/a"}, {"source": "/c", "destination": "/c"}]}
+  }
+}`, []string{
+			`let.json:4:5: environments.production: unknown key "production", did you mean "prod"?`,
+			`let.json:5:13: environments.dev.environments: unknown key "environments"`,
+			`let.json:5:39: environments.dev.cn: expect boolean, got number`,
+		}},
+		{"environment routing", `{
+  "redirects": [{"source": "/a", "destination": "/b"}],
+  "environments": {
+    "dev": {"env": {"A": "1"}},
+    "prod": {"redirects": [{"source": "/b", "destination": "/a"}, {"source": "/c", "destination": "/c"}]}
+  }
+}`, []string{
+			`let.json:5:67: environments.prod.redirects[1]: redirect loop: /c -> /c`,
+		}},
+		{"output dirs", `{
+  "static": ".",
+  "build": {"outputDir": ".."},
+  "environments": {
+    "prod": {"static": "../.."},
+    "dev": {"static": "../site"}
+  }
+}`, []string{
+			`let.json:3:13: build.outputDir: ".." contains the project dir, the output dir must be inside it`,
+			`let.json:5:14: environments.prod.static: "../.." contains the project dir, the output dir must be inside it`,
+		}},
+	} {
+		var got []string
+		for _, p := range Validate("let.json", []byte(c.content)) {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: problems =\n%q\nwant\n%q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestValidateSyntax(t *testing.T) {
+	// messages of syntax errors are of encoding/json
+	for content, want := range map[string]string{
+		"{\n  \"name\": \"site\",\n  \"type\": \n}": "let.json:4:",
+		`{"name": "site"`:     "let.json:1:16:",
+		`{"name": "site"} {}`: "let.json:1:18: unexpected content",
+		"":                    "let.json:1:1:",
+	} {
+		problems := Validate("let.json", []byte(content))
+		if len(problems) != 1 || !strings.HasPrefix(problems[0].String(), want) {
+			t.Errorf("problems of %q = %v, want %s", content, problems, want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("let.json", []byte(`{"name": "site"}`)); err != nil {
+		t.Errorf("check valid config: %v", err)
+	}
+	if err := Check("let.json", []byte(`{"name": 1}`)); errs.KindOf(err) != errs.Validation {
+		t.Errorf("check invalid config: %v", err)
+	}
+}
+
+func byteSizeError(s string) string {
+	var size datasize.ByteSize
+	return size.UnmarshalText([]byte(s)).Error()
+}
@@ -0,0 +1,174 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/requests"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/s3"
+	gql "github.com/shurcooL/graphql"
+)
+
+// API is the remote side of a deployment,
+// RemoteAPI talks to let.sh, tests could drive the pipeline with a fake one.
+type API interface {
+	// ProjectExists tells whether project has been created
+	ProjectExists(ctx context.Context, projectName string) (bool, error)
+	// ChannelPreference returns the channel to deploy preferred by user
+	ChannelPreference(ctx context.Context) (string, error)
+	// PreDeploy queries the bundle id, build template and channel preference
+	PreDeploy(ctx context.Context, c *DeployContext) (PreDeployRequest, error)
+	// UploadStatic uploads the files under dir as static assets of bundle, headers override the defaults
+	UploadStatic(ctx context.Context, dir, projectName, bundleID string, cn bool, headers []types.HeaderRule) error
+	// SourceSizeLimit returns the source size limit of the plan of project, zero values are unset by the plan
+	SourceSizeLimit(ctx context.Context, projectName string) (types.SizeLimit, error)
+	// UploadSource uploads the source tarball read from r, size is the estimated size for progress
+	UploadSource(ctx context.Context, r io.Reader, size int64, filename, projectName string, cn bool) error
+	// Deploy triggers the deployment
+	Deploy(ctx context.Context, input DeployInput) (Deployment, error)
+	// DeploymentStatus returns the current status of deployment
+	DeploymentStatus(ctx context.Context, id string) (DeploymentStatus, error)
+	// CancelDeployment cancels the deployment, returns false if the cancellation is rejected
+	CancelDeployment(ctx context.Context, id string) (bool, error)
+}
+
+type DeployInput struct {
+	Type       string
+	Name       string
+	Config     string
+	Channel    string
+	CN         bool
+	CheckRunID int64
+}
+
+type Deployment struct {
+	ID         string `json:"id"`
+	TargetFQDN string `json:"targetFQDN"`
+	Status     string `json:"status"`
+	ProjectID  string `json:"projectID"`
+}
+
+type DeploymentStatus struct {
+	TargetFQDN   string         `json:"targetFQDN"`
+	NetworkStage string         `json:"networkStage"`
+	PackerStage  string         `json:"packerStage"`
+	Status       string         `json:"status"`
+	Done         bool           `json:"done"`
+	ErrorLogs    string         `json:"errorLogs"`
+	Web3         *requests.Web3 `json:"web3,omitempty"`
+}
+
+// RemoteAPI is the API of let.sh
+type RemoteAPI struct {
+	// Upload tunes the uploads of static files and source code
+	Upload s3.UploadOptions
+}
+
+func (RemoteAPI) ProjectExists(ctx context.Context, projectName string) (bool, error) {
+	_, err := requests.QueryProject(projectName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return false, nil
+		}
+		return false, errs.Wrap(errs.Network, err)
+	}
+	return true, nil
+}
+
+func (RemoteAPI) ChannelPreference(ctx context.Context) (string, error) {
+	var query graphql.QueryPreference
+	err := graphql.NewClient().Query(ctx, &query, map[string]interface{}{
+		"name": gql.String("channel"),
+	})
+	if err != nil {
+		return "", graphql.Classify(err)
+	}
+	return query.Preference, nil
+}
+
+func (RemoteAPI) PreDeploy(ctx context.Context, c *DeployContext) (query PreDeployRequest, err error) {
+	err = graphql.NewClient().Query(ctx, &query, map[string]interface{}{
+		"projectName": gql.String(c.Name),
+		"tokenType":   gql.String("buildBundle"),
+		"type":        gql.String(c.Type),
+		"cn":          gql.Boolean(*c.CN),
+		"name":        gql.String("channel"),
+	})
+	if err != nil {
+		var graphqlError *gql.GraphQLError
+		if errors.As(err, &graphqlError) && len(graphqlError.GraphqlErrors) > 0 {
+			return query, errs.Wrap(errs.KindOf(graphql.Classify(err)), errors.New(graphqlError.GraphqlErrors[0].Message))
+		}
+		return query, graphql.Classify(err)
+	}
+	return query, nil
+}
+
+func (a RemoteAPI) UploadStatic(ctx context.Context, dir, projectName, bundleID string, cn bool,
+	headers []types.HeaderRule) error {
+	opts := a.Upload
+	opts.Headers = headers
+	return uploadError(s3.UploadDirToStaticSource(ctx, dir, projectName, bundleID, cn, opts))
+}
+
+func (RemoteAPI) SourceSizeLimit(ctx context.Context, projectName string) (types.SizeLimit, error) {
+	var query graphql.QuerySourceSizeLimit
+	err := graphql.NewClient().Query(ctx, &query, map[string]interface{}{
+		"projectName": gql.String(projectName),
+	})
+	if err != nil {
+		return types.SizeLimit{}, graphql.Classify(err)
+	}
+	return types.SizeLimit{
+		Confirm: datasize.ByteSize(query.SourceSizeLimit.Confirm),
+		Max:     datasize.ByteSize(query.SourceSizeLimit.Max),
+	}, nil
+}
+
+func (a RemoteAPI) UploadSource(ctx context.Context, r io.Reader, size int64, filename, projectName string,
+	cn bool) error {
+	return uploadError(s3.UploadFileToCodeSource(ctx, r, size, filename, projectName, cn, a.Upload))
+}
+
+// uploadError classifies errors of uploads, canceled uploads are not network errors
+func uploadError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return errs.Wrap(errs.Canceled, err)
+	}
+	return errs.Wrap(errs.Network, err)
+}
+
+func (RemoteAPI) Deploy(ctx context.Context, input DeployInput) (Deployment, error) {
+	if input.CheckRunID == 0 {
+		d, err := requests.Deploy(input.Type, input.Name, input.Config, input.Channel, input.CN)
+		if err != nil {
+			return Deployment{}, errs.Wrap(errs.Network, err)
+		}
+		return Deployment{ID: d.ID, TargetFQDN: d.TargetFQDN, Status: d.Status, ProjectID: d.Project.ID}, nil
+	}
+
+	d, err := requests.DeployWithCheckRunID(input.Type, input.Name, input.Config, input.Channel, input.CN,
+		input.CheckRunID)
+	if err != nil {
+		return Deployment{}, errs.Wrap(errs.Network, err)
+	}
+	return Deployment{ID: d.ID, TargetFQDN: d.TargetFQDN, Status: d.Status, ProjectID: d.Project.ID}, nil
+}
+
+func (RemoteAPI) DeploymentStatus(ctx context.Context, id string) (DeploymentStatus, error) {
+	d, err := requests.GetDeploymentStatus(id)
+	if err != nil {
+		return DeploymentStatus{}, errs.Wrap(errs.Network, err)
+	}
+	return DeploymentStatus(d), nil
+}
+
+func (RemoteAPI) CancelDeployment(ctx context.Context, id string) (bool, error) {
+	return requests.CancelDeployment(id)
+}
@@ -1,10 +1,16 @@
 package deploy
 
-import "github.com/let-sh/cli/utils/cache"
+import (
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/cache"
+)
 
-func (c *DeployContext) LoadProjectInfoCache() {
-	if i, err := cache.GetProjectInfo(c.Name); err == nil {
-		c.LetConfig.Name = i.Name
-		c.LetConfig.Type = i.Type
+// SavedConfig returns the project info saved by the last deployment from dir
+func SavedConfig(dir string) types.LetConfig {
+	var c types.LetConfig
+	if i, err := cache.GetProjectInfo(dir); err == nil {
+		c.Name = i.Name
+		c.Type = i.Type
 	}
+	return c
 }
@@ -2,55 +2,89 @@ package deploy
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"os"
 
-	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/handler/config"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/types"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
 )
 
-func (c *DeployContext) LoadLetJson() {
-	_, err := os.Stat("let.json")
-	if err == nil {
-		jsonFile, err := os.Open("let.json")
-		// if we os.Open returns an error then handle it
+// Layers are the sources of project config, from the lowest precedence to the highest
+type Layers struct {
+	// Detected are the name of project dir, and the type and static dir detected from project files
+	Detected types.LetConfig `json:"detected"`
+	// Saved is the project info saved by the last deployment from project dir
+	Saved types.LetConfig `json:"saved"`
+	// File is the config file with the environment of channel merged, and the variables of env files
+	File types.LetConfig `json:"file"`
+	// Flags are the cli flags
+	Flags types.LetConfig `json:"flags"`
+}
+
+// Merge returns the effective config of layers: cli flag > config file > auto saved config > detected.
+// Objects like env are merged by key, other fields are overridden by the higher layers if set.
+func (l Layers) Merge() (c types.LetConfig, err error) {
+	merged := map[string]interface{}{}
+	for _, layer := range []types.LetConfig{l.Detected, l.Saved, l.File, l.Flags} {
+		data, err := json.Marshal(layer)
 		if err != nil {
-			log.Error(err)
-			return
+			return c, err
 		}
-		// defer the closing of our jsonFile so that we can parse it later on
-		defer jsonFile.Close()
-		byteValue, _ := ioutil.ReadAll(jsonFile)
-		configStr := string(byteValue)
-		logrus.WithFields(logrus.Fields{"configFile": configStr}).Debugln("let.json")
-		err = json.Unmarshal(byteValue, &c)
-		if err != nil {
-			logrus.Error(err)
-			return
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return c, err
 		}
+		merged = config.Merge(merged, fields)
 	}
-}
 
-func (c *DeployContext) LoadCliFlag(inputProjectName, inputProjectType string) {
-	if inputProjectName != "" {
-		c.Name = inputProjectName
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return c, err
 	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
 
-	if inputProjectType != "" {
-		c.Type = inputProjectType
+// LoadConfigFile loads the config file under dir with the environment of channel merged,
+// then the variables of env files of channel and envFiles, see env.Load.
+// The local env files are loaded too, so it's for local development only.
+// Invalid config is rejected with the problems.
+func LoadConfigFile(dir, channel string, envFiles []string) (types.LetConfig, error) {
+	file, err := config.Read(dir)
+	if err != nil {
+		return types.LetConfig{}, err
 	}
+	return fileLayer(file, dir, channel, true, envFiles)
 }
 
-func (c *DeployContext) LoadRegion(cmd *cobra.Command, inputCN bool) {
-	var cn bool
-	// user custom by json config
-	if c.CN == nil {
-		c.CN = &cn
+// fileLayer returns the config of file in channel, with the variables of env files merged,
+// the local env files are loaded if local is set
+func fileLayer(file *config.File, dir, channel string, local bool, envFiles []string) (types.LetConfig, error) {
+	c, err := file.Config(channel)
+	if err != nil {
+		return c, errs.Wrap(errs.Validation, err)
 	}
+	vars, err := env.Load(dir, channel, local, envFiles)
+	if err != nil {
+		return c, errs.Wrap(errs.Validation, err)
+	}
+	if len(vars) > 0 && c.Env == nil {
+		c.Env = map[string]string{}
+	}
+	for k, v := range vars {
+		c.Env[k] = v
+	}
+	logrus.WithFields(logrus.Fields{"file": file.Path, "channel": channel}).Debugln("config file loaded")
+	return c, nil
+}
 
-	if cmd.Flags().Changed("cn") {
-		// user custom by cli flag
-		c.CN = &inputCN
+// FlagConfig returns the config layer of cli flags in opts
+func FlagConfig(opts Options) types.LetConfig {
+	return types.LetConfig{
+		Name: opts.ProjectName,
+		Type: opts.ProjectType,
+		CN:   opts.CN,
+		Web3: opts.Web3,
 	}
 }
@@ -0,0 +1,49 @@
+package deploy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/types"
+)
+
+func TestLayersMerge(t *testing.T) {
+	yes, no := true, false
+	layers := Layers{
+		Detected: types.LetConfig{Name: "web", Type: "react", Static: "build"},
+		Saved:    types.LetConfig{Name: "site", Type: "vue"},
+		File: types.LetConfig{
+			Type:        "static",
+			Env:         map[string]string{"A": "file", "B": "file"},
+			CN:          &yes,
+			SourceLimit: &types.SizeLimit{Max: 40 * datasize.MB},
+		},
+		Flags: types.LetConfig{Name: "flag", CN: &no, Env: map[string]string{"B": "flag"}},
+	}
+
+	got, err := layers.Merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := types.LetConfig{
+		Name:        "flag",
+		Type:        "static",
+		Static:      "build",
+		Env:         map[string]string{"A": "file", "B": "flag"},
+		CN:          &no,
+		SourceLimit: &types.SizeLimit{Max: 40 * datasize.MB},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged = %+v, want %+v", got, want)
+	}
+
+	// unset fields of higher layers keep the lower ones
+	got, err = Layers{Detected: layers.Detected, Flags: types.LetConfig{Type: "static"}}.Merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "web" || got.Type != "static" || got.Static != "build" || got.CN != nil {
+		t.Errorf("merged = %+v", got)
+	}
+}
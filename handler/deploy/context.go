@@ -1,17 +1,34 @@
 package deploy
 
 import (
+	"time"
+
+	"github.com/let-sh/cli/handler/build"
 	"github.com/let-sh/cli/requests/graphql"
 	"github.com/let-sh/cli/types"
 )
 
 type DeployContext struct {
 	types.LetConfig
-	Channel          string `json:"channel,omitempty"`
-	PreDeployRequest struct {
-		graphql.QueryCheckDeployCapability
-		graphql.QueryBuildTemplate
-		graphql.QueryStsToken
-		graphql.QueryPreference
-	} `json:"-"`
+	Channel string `json:"channel,omitempty"`
+	// Workdir is the path of project relative to the uploaded workspace root
+	Workdir          string           `json:"workdir,omitempty"`
+	PreDeployRequest PreDeployRequest `json:"-"`
+	// Source is the size of source code, set in package stage
+	Source SourceReport `json:"-"`
+	// BuildStep is the local build step, set in pre_deploy stage
+	BuildStep build.Step `json:"-"`
+	// BuildDuration is the time taken by the build step, set in build stage
+	BuildDuration time.Duration `json:"-"`
+	// BuildCache is the cached output reused instead of building, set in build stage
+	BuildCache *build.Entry `json:"-"`
+}
+
+// PreDeployRequest is the combined query made before uploading,
+// tells the bundle id, how to build and where to upload
+type PreDeployRequest struct {
+	graphql.QueryCheckDeployCapability
+	graphql.QueryBuildTemplate
+	graphql.QueryStsToken
+	graphql.QueryPreference
 }
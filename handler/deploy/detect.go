@@ -2,180 +2,269 @@ package deploy
 
 import (
 	"encoding/json"
-	"github.com/let-sh/cli/log"
-	"github.com/let-sh/cli/types"
-	"github.com/pelletier/go-toml"
-	"github.com/rogpeppe/go-internal/modfile"
-	"golang.org/x/exp/maps"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/let-sh/cli/types"
+	"github.com/rogpeppe/go-internal/modfile"
+	"github.com/sirupsen/logrus"
 )
 
-func (c *DeployContext) DetectProjectType() (projectType string) {
-	// handle rust project
-	if FileExists("Cargo.toml") {
-		content, err := ioutil.ReadFile("Cargo.toml")
+// ambiguousMargin is the max confidence gap between the best candidates
+// to let user choose the project type
+const ambiguousMargin = 10
 
-		if err != nil {
-			log.Error(err)
-			return
-		}
-		cargo, err := toml.Load(string(content))
-		if err != nil {
-			log.Error(err)
-			return
-		}
+// Candidate is a project type recognized by detector
+type Candidate struct {
+	Type string `json:"type"`
+	// Confidence ranges from 0 to 100
+	Confidence int      `json:"confidence"`
+	Evidence   []string `json:"evidence"`
+	// Static is the static dir, if known by detector
+	Static string `json:"static,omitempty"`
+}
 
-		dependencies := cargo.Get("dependencies").(*toml.Tree)
+// Detector recognizes project types from the files of project
+type Detector interface {
+	Detect(f *Files) []Candidate
+}
 
-		if dependencies.Has("rocket") {
-			c.Type = "rocket"
-			return "rocket"
-		}
+// DetectorFunc is a function as Detector
+type DetectorFunc func(f *Files) []Candidate
 
-	}
+func (fn DetectorFunc) Detect(f *Files) []Candidate {
+	return fn(f)
+}
 
-	// handle golang framework
-	if FileExists("go.mod") {
-		b, err := ioutil.ReadFile("go.mod")
+type namedDetector struct {
+	name     string
+	detector Detector
+}
 
-		if err != nil {
-			log.Error(err)
-		}
+var detectors []namedDetector
 
-		f, err := modfile.Parse("go.mod", b, nil)
-		if err != nil {
-			log.Error(err)
-		}
-		for _, v := range f.Require {
-			if v.Mod.Path == "github.com/gin-gonic/gin" {
-				c.Type = "gin"
-				return "gin"
+// RegisterDetector adds detector to the registry, name is only used for debugging
+func RegisterDetector(name string, d Detector) {
+	detectors = append(detectors, namedDetector{name: name, detector: d})
+}
+
+// Detect runs all registered detectors on dir,
+// returns candidates sorted by confidence, the same type is merged.
+func Detect(dir string) []Candidate {
+	f := NewFiles(dir)
+
+	var candidates []Candidate
+	index := map[string]int{}
+	for _, d := range detectors {
+		for _, candidate := range d.detector.Detect(f) {
+			logrus.WithFields(logrus.Fields{
+				"detector":   d.name,
+				"type":       candidate.Type,
+				"confidence": candidate.Confidence,
+			}).Debugln("detected")
+
+			i, ok := index[candidate.Type]
+			if !ok {
+				index[candidate.Type] = len(candidates)
+				candidates = append(candidates, candidate)
+				continue
 			}
-			if v.Mod.Path == "github.com/go-martini/martini" {
-				c.Type = "martini"
-				return "martini"
+			merged := &candidates[i]
+			merged.Evidence = append(merged.Evidence, candidate.Evidence...)
+			if candidate.Confidence > merged.Confidence {
+				merged.Confidence = candidate.Confidence
+			}
+			if merged.Static == "" {
+				merged.Static = candidate.Static
 			}
 		}
+	}
 
-		return "go"
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates
+}
+
+// Ambiguous tells whether the best candidates are too close to choose one automatically
+func Ambiguous(candidates []Candidate) bool {
+	return len(candidates) > 1 && candidates[0].Confidence-candidates[1].Confidence <= ambiguousMargin
+}
+
+// DetectProjectType sets the type of the best candidate under dir,
+// type is "unknown" if nothing detected.
+func (c *DeployContext) DetectProjectType(dir string) []Candidate {
+	candidates := Detect(dir)
+	if len(candidates) == 0 {
+		c.Type = "unknown"
+		return candidates
 	}
+	c.SetCandidate(candidates[0])
+	return candidates
+}
 
-	// handle javascript framework
-	if FileExists("package.json") {
-		// frontend
-		jsonBytes, err := ioutil.ReadFile("package.json")
-		if err != nil {
-			log.Error(err)
-		}
-		var packageConfig types.PackageDotJson
-		json.Unmarshal(jsonBytes, &packageConfig)
-		if packageConfig.Dependencies == nil {
-			packageConfig.Dependencies = map[string]string{}
-		}
-		if packageConfig.DevDependencies == nil {
-			packageConfig.DevDependencies = map[string]string{}
-		}
-		maps.Copy(packageConfig.Dependencies, packageConfig.DevDependencies)
-		for k := range packageConfig.Dependencies {
-			if strings.Contains(k, "@surgio/gateway") {
-				c.Type = "surgio"
-				return "surgio"
-			}
+// SetCandidate sets project type and static dir by candidate
+func (c *DeployContext) SetCandidate(candidate Candidate) {
+	c.Type = candidate.Type
+	if candidate.Static != "" {
+		c.Static = candidate.Static
+	}
+}
 
-			if strings.Contains(k, "express") {
-				c.Type = "express"
-				return "express"
-			}
+// Files reads the files under project dir for detectors, parsed manifests are cached
+type Files struct {
+	Dir string
 
-			if strings.Contains(k, "@docusaurus") {
-				c.Type = "docusaurus"
-				return "docusaurus"
-			}
+	packageJSON *types.PackageDotJson
+	goMod       *modfile.File
+	loaded      map[string]bool
+}
 
-			if strings.Contains(k, "next") {
-				c.Type = "next"
-				return "next"
-			}
+func NewFiles(dir string) *Files {
+	return &Files{Dir: dir, loaded: map[string]bool{}}
+}
 
-			if strings.Contains(k, "vuepress") {
-				c.Type = "vuepress"
-				return "vuepress"
-			}
+// Exists tells whether file or dir exists under project dir
+func (f *Files) Exists(name string) bool {
+	return FileExists(filepath.Join(f.Dir, name))
+}
 
-			if strings.Contains(k, "react") {
-				c.Type = "react"
-				return "react"
-			}
+// Read returns the content of file, nil if not readable
+func (f *Files) Read(name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join(f.Dir, name))
+	if err != nil {
+		return nil
+	}
+	return content
+}
 
-			if strings.Contains(k, "@nuxt") {
-				c.Type = "nuxt"
-				return "nuxt"
-			}
-			if strings.Contains(k, "@vue") || strings.Contains(k, "vue") {
-				c.Type = "vue"
-				return "vue"
+// PackageJSON returns the parsed package.json, nil if not exists or invalid
+func (f *Files) PackageJSON() *types.PackageDotJson {
+	if !f.loaded["package.json"] {
+		f.loaded["package.json"] = true
+		if content := f.Read("package.json"); content != nil {
+			var p types.PackageDotJson
+			if err := json.Unmarshal(content, &p); err != nil {
+				logrus.Debug("parse package.json: ", err)
+			} else {
+				f.packageJSON = &p
 			}
-			if strings.Contains(k, "@angular") {
-				c.Type = "angular"
-				return "angular"
+		}
+	}
+	return f.packageJSON
+}
+
+// Dependency returns the dependency of package.json matches name exactly,
+// name ends with "/" matches all packages of the scope, e.g. "@nuxt/".
+func (f *Files) Dependency(name string) (string, bool) {
+	p := f.PackageJSON()
+	if p == nil {
+		return "", false
+	}
+	for _, deps := range []map[string]string{p.Dependencies, p.DevDependencies} {
+		if strings.HasSuffix(name, "/") {
+			for k := range deps {
+				if strings.HasPrefix(k, name) {
+					return k, true
+				}
 			}
+			continue
+		}
+		if _, ok := deps[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
 
-			if strings.Contains(k, "hexo") {
-				c.Type = "hexo"
-				return "hexo"
+// GoMod returns the parsed go.mod, nil if not exists or invalid
+func (f *Files) GoMod() *modfile.File {
+	if !f.loaded["go.mod"] {
+		f.loaded["go.mod"] = true
+		if content := f.Read("go.mod"); content != nil {
+			mod, err := modfile.Parse("go.mod", content, nil)
+			if err != nil {
+				logrus.Debug("parse go.mod: ", err)
+				mod = &modfile.File{}
 			}
+			f.goMod = mod
+		}
+	}
+	return f.goMod
+}
 
+// GoRequires tells whether go.mod requires module path
+func (f *Files) GoRequires(path string) bool {
+	mod := f.GoMod()
+	if mod == nil {
+		return false
+	}
+	for _, v := range mod.Require {
+		if v.Mod.Path == path {
+			return true
 		}
 	}
+	return false
+}
 
-	// handle python framework
-	if FileExists("requirements.txt") || FileExists("Pipfile") {
-		if FileExists("requirements.txt") {
-			reqFile, _ := ioutil.ReadFile("requirements.txt")
-			if strings.Contains(strings.ToLower(string(reqFile)), "flask") {
-				c.Type = "flask"
-				return "flask"
+// PythonRequirement returns the file requires python package name, empty if not required
+func (f *Files) PythonRequirement(name string) string {
+	for _, file := range []string{"requirements.txt", "Pipfile", "pyproject.toml", "poetry.lock"} {
+		content := f.Read(file)
+		if content == nil {
+			continue
+		}
+		for _, pkg := range pythonPackages(file, string(content)) {
+			if pkg == name {
+				return file
 			}
+		}
+	}
+	return ""
+}
 
-			if strings.Contains(strings.ToLower(string(reqFile)), "fastapi") {
-				c.Type = "fastapi"
-				return "fastapi"
-			}
+// pythonPackages returns the lower case package names in python manifest
+func pythonPackages(file, content string) (packages []string) {
+	for _, line := range strings.Split(strings.ToLower(content), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
 		}
+		line = strings.TrimSpace(line)
 
-		if FileExists("Pipfile") {
-			reqFile, _ := ioutil.ReadFile("Pipfile")
-			if strings.Contains(strings.ToLower(string(reqFile)), "flask") {
-				c.Type = "flask"
-				return "flask"
+		// poetry.lock lists packages as: name = "django"
+		if file == "poetry.lock" {
+			if strings.HasPrefix(line, "name = ") {
+				packages = append(packages, strings.Trim(strings.TrimPrefix(line, "name = "), `"`))
 			}
+			continue
+		}
 
-			if strings.Contains(strings.ToLower(string(reqFile)), "fastapi") {
-				c.Type = "fastapi"
-				return "fastapi"
+		// requirement specifiers, e.g. flask[async]>=2.0
+		// and quoted ones in pyproject.toml, e.g. dependencies = ["django>=4.0"]
+		specs := []string{line}
+		if quoted := strings.Split(line, `"`); len(quoted) > 2 {
+			for i := 1; i < len(quoted); i += 2 {
+				specs = append(specs, quoted[i])
+			}
+		}
+		for _, spec := range specs {
+			if i := strings.IndexAny(spec, " =<>~![;"); i >= 0 {
+				spec = spec[:i]
+			}
+			if spec != "" {
+				packages = append(packages, spec)
 			}
 		}
 	}
+	return packages
+}
 
-	// handle static files
-	// check if static by index.html
-	_, err := os.Stat("index.html")
-	if !os.IsNotExist(err) {
-		c.Type = "static"
-		c.Static = "./"
-		return "static"
-	}
-
-	// hugo
-	if FileExists("config.toml") && FileExists("themes") {
-		c.Type = "hugo"
-		return "hugo"
-	}
-
-	c.Type = "unknown"
-	return "unknown"
+// Contains tells whether file contains substr
+func (f *Files) Contains(name, substr string) bool {
+	return strings.Contains(string(f.Read(name)), substr)
 }
 
 func FileExists(path string) bool {
@@ -0,0 +1,109 @@
+package deploy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func detectFiles(t *testing.T, files map[string]string) []Candidate {
+	dir, err := ioutil.TempDir("", "detect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Detect(dir)
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      string
+		ambiguous bool
+	}{
+		{"next over react", map[string]string{
+			"package.json": `{"dependencies":{"react":"^17","next":"^12"}}`,
+		}, "next", false},
+		{"similar package name", map[string]string{
+			"package.json": `{"dependencies":{"vue-something":"1","react":"^17"}}`,
+		}, "react", false},
+		{"framework over static", map[string]string{
+			"package.json": `{"devDependencies":{"vue":"^3"}}`,
+			"index.html":   "<html></html>",
+		}, "vue", false},
+		{"express with react", map[string]string{
+			"package.json": `{"dependencies":{"express":"^4","react":"^17"}}`,
+		}, "express", true},
+		{"gin", map[string]string{
+			"go.mod": "module example.com/app\n\nrequire github.com/gin-gonic/gin v1.7.0\n",
+		}, "gin", false},
+		{"flask with version", map[string]string{
+			"requirements.txt": "Flask>=2.0\nrequests\n",
+		}, "flask", false},
+		{"sveltekit over vite and svelte", map[string]string{
+			"package.json": `{"devDependencies":{"@sveltejs/kit":"1","svelte":"3","vite":"4"}}`,
+		}, "sveltekit", false},
+		{"vite spa", map[string]string{
+			"package.json":   `{"dependencies":{"react":"18"},"devDependencies":{"vite":"4"}}`,
+			"vite.config.ts": "",
+		}, "vite", false},
+		{"django in pyproject", map[string]string{
+			"pyproject.toml": "[project]\ndependencies = [\"Django>=4.2\", \"requests\"]\n",
+			"manage.py":      "",
+		}, "django", false},
+		{"django in poetry.lock", map[string]string{
+			"poetry.lock": "[[package]]\nname = \"django\"\nversion = \"4.2\"\n",
+		}, "django", false},
+		{"rails", map[string]string{
+			"Gemfile": "source 'https://rubygems.org'\ngem 'rails', '~> 7.0'\n",
+		}, "rails", false},
+		{"laravel", map[string]string{
+			"composer.json": `{"require":{"php":"^8.1","laravel/framework":"^10.0"}}`,
+		}, "laravel", false},
+		{"spring boot gradle", map[string]string{
+			"build.gradle": "plugins {\n  id 'org.springframework.boot' version '3.1.0'\n}\n",
+		}, "spring-boot", false},
+		{"deno", map[string]string{
+			"deno.json": `{"tasks":{"dev":"deno run main.ts"}}`,
+		}, "deno", false},
+		{"astro with bun", map[string]string{
+			"package.json": `{"dependencies":{"astro":"2"}}`,
+			"bun.lockb":    "",
+		}, "astro", false},
+		{"static", map[string]string{
+			"index.html": "<html></html>",
+		}, "static", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := detectFiles(t, tt.files)
+			if len(candidates) == 0 {
+				t.Fatalf("nothing detected, want %s", tt.want)
+			}
+			if candidates[0].Type != tt.want {
+				t.Errorf("detected %s, want %s, candidates: %+v", candidates[0].Type, tt.want, candidates)
+			}
+			if Ambiguous(candidates) != tt.ambiguous {
+				t.Errorf("ambiguous = %v, want %v, candidates: %+v", !tt.ambiguous, tt.ambiguous, candidates)
+			}
+			if len(candidates[0].Evidence) == 0 {
+				t.Errorf("no evidence of %s", candidates[0].Type)
+			}
+		})
+	}
+}
+
+func TestDetectUnknown(t *testing.T) {
+	var c DeployContext
+	c.DetectProjectType(os.TempDir() + "/not-exists")
+	if c.Type != "unknown" {
+		t.Errorf("type = %s, want unknown", c.Type)
+	}
+}
@@ -0,0 +1,233 @@
+package deploy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/sirupsen/logrus"
+)
+
+// nodeFramework is a framework recognized by the dependencies of package.json,
+// the specific frameworks (e.g. next) are more confident than the libraries they built on (e.g. react).
+type nodeFramework struct {
+	Type       string
+	Packages   []string
+	Confidence int
+	// Configs are the config files of framework, raise confidence if exists
+	Configs []string
+}
+
+var nodeFrameworks = []nodeFramework{
+	{Type: "next", Packages: []string{"next"}, Confidence: 90,
+		Configs: []string{"next.config.js", "next.config.mjs"}},
+	{Type: "nuxt", Packages: []string{"nuxt", "nuxt3", "@nuxt/"}, Confidence: 90,
+		Configs: []string{"nuxt.config.js", "nuxt.config.ts"}},
+	{Type: "docusaurus", Packages: []string{"@docusaurus/core"}, Confidence: 90,
+		Configs: []string{"docusaurus.config.js"}},
+	{Type: "vuepress", Packages: []string{"vuepress", "@vuepress/"}, Confidence: 90,
+		Configs: []string{".vuepress"}},
+	{Type: "surgio", Packages: []string{"surgio", "@surgio/gateway"}, Confidence: 90,
+		Configs: []string{"surgio.conf.js"}},
+	{Type: "sveltekit", Packages: []string{"@sveltejs/kit"}, Confidence: 90,
+		Configs: []string{"svelte.config.js"}},
+	{Type: "astro", Packages: []string{"astro"}, Confidence: 90,
+		Configs: []string{"astro.config.mjs", "astro.config.ts", "astro.config.js"}},
+	{Type: "remix", Packages: []string{"@remix-run/"}, Confidence: 90,
+		Configs: []string{"remix.config.js"}},
+	{Type: "gatsby", Packages: []string{"gatsby"}, Confidence: 90,
+		Configs: []string{"gatsby-config.js", "gatsby-config.ts"}},
+	{Type: "hexo", Packages: []string{"hexo"}, Confidence: 80,
+		Configs: []string{"_config.yml"}},
+	{Type: "angular", Packages: []string{"@angular/core"}, Confidence: 80,
+		Configs: []string{"angular.json"}},
+	{Type: "vite", Packages: []string{"vite"}, Confidence: 70,
+		Configs: []string{"vite.config.js", "vite.config.ts", "vite.config.mjs"}},
+	{Type: "express", Packages: []string{"express"}, Confidence: 70},
+	{Type: "react", Packages: []string{"react"}, Confidence: 60},
+	{Type: "vue", Packages: []string{"vue"}, Confidence: 60,
+		Configs: []string{"vue.config.js"}},
+	{Type: "svelte", Packages: []string{"svelte"}, Confidence: 60},
+}
+
+func detectNode(f *Files) (candidates []Candidate) {
+	for _, framework := range nodeFrameworks {
+		for _, pkg := range framework.Packages {
+			dep, ok := f.Dependency(pkg)
+			if !ok {
+				continue
+			}
+			candidate := Candidate{
+				Type:       framework.Type,
+				Confidence: framework.Confidence,
+				Evidence:   []string{"package.json depends on " + dep},
+			}
+			for _, config := range framework.Configs {
+				if f.Exists(config) {
+					candidate.Confidence += 5
+					candidate.Evidence = append(candidate.Evidence, config+" exists")
+					break
+				}
+			}
+			candidates = append(candidates, candidate)
+			break
+		}
+	}
+	return candidates
+}
+
+func detectGo(f *Files) []Candidate {
+	if f.GoMod() == nil {
+		return nil
+	}
+	for _, framework := range []struct{ Type, Path string }{
+		{"gin", "github.com/gin-gonic/gin"},
+		{"martini", "github.com/go-martini/martini"},
+	} {
+		if f.GoRequires(framework.Path) {
+			return []Candidate{{
+				Type:       framework.Type,
+				Confidence: 90,
+				Evidence:   []string{"go.mod requires " + framework.Path},
+			}}
+		}
+	}
+	return []Candidate{{Type: "go", Confidence: 40, Evidence: []string{"go.mod exists"}}}
+}
+
+func detectRust(f *Files) []Candidate {
+	content := f.Read("Cargo.toml")
+	if content == nil {
+		return nil
+	}
+	cargo, err := toml.Load(string(content))
+	if err != nil {
+		logrus.Debug("parse Cargo.toml: ", err)
+		return nil
+	}
+	if dependencies, ok := cargo.Get("dependencies").(*toml.Tree); ok && dependencies.Has("rocket") {
+		return []Candidate{{Type: "rocket", Confidence: 90, Evidence: []string{"Cargo.toml depends on rocket"}}}
+	}
+	return nil
+}
+
+func detectPython(f *Files) (candidates []Candidate) {
+	for _, framework := range []string{"django", "flask", "fastapi"} {
+		if file := f.PythonRequirement(framework); file != "" {
+			candidate := Candidate{
+				Type:       framework,
+				Confidence: 80,
+				Evidence:   []string{file + " requires " + framework},
+			}
+			if framework == "django" && f.Exists("manage.py") {
+				candidate.Confidence += 5
+				candidate.Evidence = append(candidate.Evidence, "manage.py exists")
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+func detectRuby(f *Files) []Candidate {
+	content := f.Read("Gemfile")
+	if content == nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(strings.NewReplacer(`'`, " ", `"`, " ", ",", " ").Replace(line))
+		if len(fields) > 1 && fields[0] == "gem" && fields[1] == "rails" {
+			candidate := Candidate{Type: "rails", Confidence: 90, Evidence: []string{"Gemfile requires rails"}}
+			if f.Exists("config/routes.rb") {
+				candidate.Evidence = append(candidate.Evidence, "config/routes.rb exists")
+			}
+			return []Candidate{candidate}
+		}
+	}
+	return nil
+}
+
+func detectPHP(f *Files) []Candidate {
+	content := f.Read("composer.json")
+	if content == nil {
+		return nil
+	}
+	var composer struct {
+		Require map[string]string `json:"require"`
+	}
+	if err := json.Unmarshal(content, &composer); err != nil {
+		logrus.Debug("parse composer.json: ", err)
+		return nil
+	}
+	if _, ok := composer.Require["laravel/framework"]; ok {
+		candidate := Candidate{Type: "laravel", Confidence: 90,
+			Evidence: []string{"composer.json requires laravel/framework"}}
+		if f.Exists("artisan") {
+			candidate.Evidence = append(candidate.Evidence, "artisan exists")
+		}
+		return []Candidate{candidate}
+	}
+	return nil
+}
+
+func detectJava(f *Files) []Candidate {
+	if f.Contains("pom.xml", "spring-boot") {
+		return []Candidate{{Type: "spring-boot", Confidence: 90, Evidence: []string{"pom.xml depends on spring-boot"}}}
+	}
+	for _, file := range []string{"build.gradle", "build.gradle.kts"} {
+		if f.Contains(file, "org.springframework.boot") {
+			return []Candidate{{Type: "spring-boot", Confidence: 90,
+				Evidence: []string{file + " applies org.springframework.boot"}}}
+		}
+	}
+	return nil
+}
+
+func detectDeno(f *Files) []Candidate {
+	for _, file := range []string{"deno.json", "deno.jsonc"} {
+		if f.Exists(file) {
+			return []Candidate{{Type: "deno", Confidence: 80, Evidence: []string{file + " exists"}}}
+		}
+	}
+	return nil
+}
+
+func detectBun(f *Files) []Candidate {
+	// bun is a runtime, frameworks in package.json are more specific
+	for _, file := range []string{"bun.lockb", "bun.lock", "bunfig.toml"} {
+		if f.Exists(file) {
+			return []Candidate{{Type: "bun", Confidence: 50, Evidence: []string{file + " exists"}}}
+		}
+	}
+	return nil
+}
+
+func detectHugo(f *Files) []Candidate {
+	if f.Exists("config.toml") && f.Exists("themes") {
+		return []Candidate{{Type: "hugo", Confidence: 70, Evidence: []string{"config.toml and themes exist"}}}
+	}
+	return nil
+}
+
+func detectStatic(f *Files) []Candidate {
+	// check if static by index.html,
+	// least confident as frameworks usually contain an index.html too
+	if f.Exists("index.html") {
+		return []Candidate{{Type: "static", Confidence: 30, Evidence: []string{"index.html exists"}, Static: "./"}}
+	}
+	return nil
+}
+
+func init() {
+	RegisterDetector("node", DetectorFunc(detectNode))
+	RegisterDetector("go", DetectorFunc(detectGo))
+	RegisterDetector("rust", DetectorFunc(detectRust))
+	RegisterDetector("python", DetectorFunc(detectPython))
+	RegisterDetector("ruby", DetectorFunc(detectRuby))
+	RegisterDetector("php", DetectorFunc(detectPHP))
+	RegisterDetector("java", DetectorFunc(detectJava))
+	RegisterDetector("deno", DetectorFunc(detectDeno))
+	RegisterDetector("bun", DetectorFunc(detectBun))
+	RegisterDetector("hugo", DetectorFunc(detectHugo))
+	RegisterDetector("static", DetectorFunc(detectStatic))
+}
@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSourceLimit applies if neither the plan nor let.json sets the limit
+var DefaultSourceLimit = types.SizeLimit{
+	Confirm: 20 * datasize.MB,
+	Max:     40 * datasize.MB,
+}
+
+// largestEntries is the number of dirs or files listed in SourceReport
+const largestEntries = 5
+
+// SourceReport is the size of source code to upload, set in package stage
+type SourceReport struct {
+	Size  datasize.ByteSize
+	Limit types.SizeLimit
+	// Largest are the dirs or files taking most space, set if Size exceeds the confirm limit
+	Largest []SizeEntry
+}
+
+// SizeEntry is the total size of files under Path, Path ends with "/" for dirs
+type SizeEntry struct {
+	Path string
+	Size datasize.ByteSize
+}
+
+// Breakdown returns the lines of largest entries, e.g. "  public/    31.5 MB"
+func (r SourceReport) Breakdown() string {
+	width := 0
+	for _, e := range r.Largest {
+		if len(e.Path) > width {
+			width = len(e.Path)
+		}
+	}
+	var b strings.Builder
+	for _, e := range r.Largest {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, e.Path, e.Size.HR())
+	}
+	return b.String()
+}
+
+// mergeSourceLimit returns the limit of plan, lowered by let.json.
+// Zero values of plan and config are unset, the ones unset by plan default to DefaultSourceLimit,
+// so that let.json could never raise the limits. Confirm is never larger than Max.
+func mergeSourceLimit(plan types.SizeLimit, config *types.SizeLimit) types.SizeLimit {
+	limit := DefaultSourceLimit
+	if plan.Confirm > 0 {
+		limit.Confirm = plan.Confirm
+	}
+	if plan.Max > 0 {
+		limit.Max = plan.Max
+	}
+	if config != nil {
+		if config.Confirm > 0 && config.Confirm < limit.Confirm {
+			limit.Confirm = config.Confirm
+		}
+		if config.Max > 0 && config.Max < limit.Max {
+			limit.Max = config.Max
+		}
+	}
+	if limit.Confirm > limit.Max {
+		limit.Confirm = limit.Max
+	}
+	return limit
+}
+
+// sourceLimit returns the limit of current project, the default of plan is used if failed to query
+func (p *Pipeline) sourceLimit(ctx context.Context) types.SizeLimit {
+	plan, err := p.API.SourceSizeLimit(ctx, p.Context.Name)
+	if err != nil {
+		logrus.WithError(err).Debugln("query source size limit")
+	}
+	return mergeSourceLimit(plan, p.Context.SourceLimit)
+}
+
+// largest sums the size of files under root by top level dirs, returns the n largest entries
+func largest(root string, files []string, n int) []SizeEntry {
+	sizes := map[string]int64{}
+	for _, f := range files {
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(f)))
+		if err != nil {
+			continue
+		}
+		key := f
+		if i := strings.Index(f, "/"); i >= 0 {
+			key = f[:i+1]
+		}
+		sizes[key] += info.Size()
+	}
+
+	var entries []SizeEntry
+	for path, size := range sizes {
+		entries = append(entries, SizeEntry{Path: path, Size: datasize.ByteSize(size)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
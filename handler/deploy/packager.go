@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression of source tarball
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// Ext returns the file extension of tarball
+func (c Compression) Ext() string {
+	if c == CompressionZstd {
+		return ".tar.zst"
+	}
+	return ".tar.gz"
+}
+
+// ParseCompression validates the compression name, empty defaults to gzip
+func ParseCompression(name string) (Compression, error) {
+	switch Compression(name) {
+	case "", CompressionGzip:
+		return CompressionGzip, nil
+	case CompressionZstd:
+		return CompressionZstd, nil
+	}
+	return "", fmt.Errorf("unsupported compression %q, expect gzip or zstd", name)
+}
+
+// WriteTar streams files under root into w as a compressed tarball.
+// files are slash separated paths relative to root, file modes and symlinks are preserved.
+func WriteTar(w io.Writer, root string, files []string, compression Compression) error {
+	var cw io.WriteCloser
+	switch compression {
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		cw = zw
+	default:
+		cw = gzip.NewWriter(w)
+	}
+
+	tw := tar.NewWriter(cw)
+	for _, name := range files {
+		if err := writeTarEntry(tw, root, name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+func writeTarEntry(tw *tar.Writer, root, name string) error {
+	path := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
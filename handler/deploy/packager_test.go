@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// readTar returns the entry names of tarball
+func readTar(t *testing.T, r io.Reader, compression Compression) (names []string) {
+	headers := readTarHeaders(t, r, compression)
+	for _, h := range headers {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func readTarHeaders(t *testing.T, r io.Reader, compression Compression) (headers []*tar.Header) {
+	var cr io.Reader
+	switch compression {
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		cr = zr
+	default:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cr = gr
+	}
+
+	tr := tar.NewReader(cr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return headers
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, h)
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "bin", "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"bin/run.sh", "main.go"}
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("bin/run.sh", filepath.Join(dir, "run")); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, "run")
+	}
+
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		var buf bytes.Buffer
+		if err := WriteTar(&buf, dir, files, compression); err != nil {
+			t.Fatal(err)
+		}
+
+		headers := readTarHeaders(t, &buf, compression)
+		var names []string
+		for _, h := range headers {
+			names = append(names, h.Name)
+			switch h.Name {
+			case "bin/run.sh":
+				if runtime.GOOS != "windows" && h.FileInfo().Mode().Perm() != 0755 {
+					t.Errorf("%s: mode of run.sh = %v, want 0755", compression, h.FileInfo().Mode())
+				}
+			case "run":
+				if h.Typeflag != tar.TypeSymlink || h.Linkname != "bin/run.sh" {
+					t.Errorf("%s: run = %+v, want symlink to bin/run.sh", compression, h)
+				}
+			}
+		}
+		if !reflect.DeepEqual(names, files) {
+			t.Errorf("%s: entries = %v, want %v", compression, names, files)
+		}
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for name, want := range map[string]Compression{"": CompressionGzip, "gzip": CompressionGzip,
+		"zstd": CompressionZstd} {
+		if got, err := ParseCompression(name); err != nil || got != want {
+			t.Errorf("ParseCompression(%q) = %s, %v, want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseCompression("bzip2"); err == nil {
+		t.Error("ParseCompression(bzip2) should fail")
+	}
+}
@@ -0,0 +1,831 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/handler/build"
+	"github.com/let-sh/cli/handler/config"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/handler/workspace"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/utils/cache"
+	"github.com/let-sh/cli/utils/ignore"
+	"github.com/let-sh/cli/utils/s3"
+	"github.com/sirupsen/logrus"
+)
+
+// Stage is a step of deploy pipeline
+type Stage string
+
+const (
+	StageValidate  Stage = "validate"
+	StageConfig    Stage = "config"
+	StageConfirm   Stage = "confirm"
+	StagePreDeploy Stage = "pre_deploy"
+	StageBuild     Stage = "build"
+	StageUpload    Stage = "upload"
+	StagePackage   Stage = "package"
+	StageDeploy    Stage = "deploy"
+	StageAwait     Stage = "await"
+	StageDone      Stage = "done"
+)
+
+// Question is asked to listener before deploying
+type Question int
+
+const (
+	// QuestionNewProject asks whether to create the project
+	QuestionNewProject Question = iota
+	// QuestionDirChanged asks whether to continue when project dir differs from the cached one
+	QuestionDirChanged
+	// QuestionLargeSource asks whether to upload source code larger than the confirm limit
+	QuestionLargeSource
+)
+
+// Listener receives the progress of pipeline
+type Listener interface {
+	// OnStage is called before stage begins
+	OnStage(stage Stage, c *DeployContext)
+	// OnDeployment is called once the deployment is triggered
+	OnDeployment(d Deployment, c *DeployContext)
+	// OnStatus is called with every polled deployment status
+	OnStatus(s DeploymentStatus)
+	// Confirm returns whether to continue deploying
+	Confirm(q Question, c *DeployContext) (bool, error)
+	// Choose returns the project type of candidates, which are too close to choose automatically
+	Choose(candidates []Candidate, c *DeployContext) (Candidate, error)
+}
+
+// NopListener ignores all events, confirms all questions and chooses the best candidate
+type NopListener struct{}
+
+func (NopListener) OnStage(Stage, *DeployContext)                  {}
+func (NopListener) OnDeployment(Deployment, *DeployContext)        {}
+func (NopListener) OnStatus(DeploymentStatus)                      {}
+func (NopListener) Confirm(Question, *DeployContext) (bool, error) { return true, nil }
+func (NopListener) Choose(candidates []Candidate, c *DeployContext) (Candidate, error) {
+	return candidates[0], nil
+}
+
+// Options are the cli flags of deploy
+type Options struct {
+	// Dir is the project dir, default to current dir
+	Dir string
+	// Root is the dir to upload source code from, default to Dir,
+	// e.g. the workspace root of a monorepo package
+	Root string
+	// Include are the paths relative to Root to upload, default to all
+	Include     []string
+	ProjectName string
+	ProjectType string
+	// Channel to deploy, default to the preference of user
+	Channel string
+	// EnvFiles are loaded after the env files of channel under Dir, see env.Load
+	EnvFiles []string
+	// CN and Web3 overwrite let.json if not nil
+	CN   *bool
+	Web3 *bool
+	// Compression of source tarball, default to gzip
+	Compression Compression
+	// Concurrency of static file uploads, default to s3.DefaultConcurrency
+	Concurrency int
+	// MaxBandwidth limits the upload speed per second, unlimited if zero
+	MaxBandwidth datasize.ByteSize
+	// Detach returns after the deployment is triggered
+	Detach     bool
+	CheckRunID int64
+	// DryRun lists the files to ship instead of deploying
+	DryRun bool
+	// BuildOnly runs the local build step without uploading or deploying
+	BuildOnly bool
+	// Plan resolves what would be deployed and diffs it against the last deployment, instead of deploying
+	Plan bool
+	// NoCache runs the build step even if the output is cached, the new output is cached still
+	NoCache bool
+	// PollInterval of deployment status, default to 1s
+	PollInterval time.Duration
+	// output of local build commands, default to os.Stdout and os.Stderr
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Pipeline deploys a project in stages:
+// validate, config, confirm, pre_deploy, build, upload, package, deploy, await
+type Pipeline struct {
+	Context  *DeployContext
+	API      API
+	Listener Listener
+	Options  Options
+
+	// BuildCache stores the outputs of build step
+	BuildCache build.Cache
+	// RecordDir stores the plans of succeeded deployments, see Record
+	RecordDir string
+
+	// Candidates are the detected project types
+	Candidates []Candidate
+	// Layers are the sources of config, set in the config stage
+	Layers Layers
+	// Deployment is set after the deploy stage
+	Deployment Deployment
+	// Status is set to the final status after the await stage
+	Status DeploymentStatus
+	// Files are the files to ship, set in dry run
+	Files FileList
+	// Plan and the record of the last deployment to diff against are set in plan mode
+	Plan     Plan
+	Previous *Record
+}
+
+// FileList lists the files to ship, paths are slash separated
+type FileList struct {
+	// StaticDir is the dir static files uploaded from, relative to project dir, Static are relative to it
+	StaticDir  string   `json:"static_dir,omitempty"`
+	Static     []string `json:"static,omitempty"`
+	StaticSize int64    `json:"static_size"`
+	// Source are relative to Options.Root, archived as the source code of dynamic project
+	Source     []string `json:"source,omitempty"`
+	SourceSize int64    `json:"source_size"`
+}
+
+// NewPipeline returns a pipeline deploys via RemoteAPI
+func NewPipeline(opts Options, listener Listener) *Pipeline {
+	if listener == nil {
+		listener = NopListener{}
+	}
+	api := RemoteAPI{Upload: s3.UploadOptions{
+		Concurrency:  opts.Concurrency,
+		MaxBandwidth: int64(opts.MaxBandwidth),
+	}}
+	return &Pipeline{
+		Context:    &DeployContext{},
+		API:        api,
+		Listener:   listener,
+		Options:    opts,
+		BuildCache: build.DefaultCache(),
+		RecordDir:  cache.DeploymentsDir(),
+	}
+}
+
+// step is a stage and the function running it
+type step struct {
+	stage Stage
+	run   func(ctx context.Context) error
+}
+
+// Run runs all stages in order, stops at the first error.
+// In dry run, only the local stages and pre_deploy are run, then files to ship are listed.
+// In build only mode, the build stage is run after them instead.
+// In plan mode, the plan is made after them, see Plan.
+// Run stops with a Canceled error once ctx is canceled, the triggered deployment is canceled too.
+func (p *Pipeline) Run(ctx context.Context) error {
+	var err error
+	switch {
+	case p.Options.DryRun:
+		err = p.dryRun(ctx)
+	case p.Options.Plan:
+		err = p.plan(ctx)
+	case p.Options.BuildOnly:
+		err = p.buildOnly(ctx)
+	default:
+		err = p.deploy(ctx)
+	}
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if p.Deployment.ID != "" && !p.Status.Done {
+		return p.cancelDeployment()
+	}
+	// stages interrupted may fail otherwise, e.g. the build commands killed
+	if errs.KindOf(err) != errs.Canceled {
+		return errs.Wrap(errs.Canceled, err)
+	}
+	return err
+}
+
+// runStages runs steps in order, stops at the first error or once ctx is canceled
+func (p *Pipeline) runStages(ctx context.Context, steps []step) error {
+	for _, s := range steps {
+		if err := ctx.Err(); err != nil {
+			return errs.Wrap(errs.Canceled, err)
+		}
+		p.Listener.OnStage(s.stage, p.Context)
+		if err := s.run(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) deploy(ctx context.Context) error {
+	steps := []step{
+		{StageValidate, p.Validate},
+		{StageConfig, p.LoadConfig},
+		{StageConfirm, p.Confirm},
+		{StagePreDeploy, p.PreDeploy},
+		{StageBuild, p.Build},
+		{StageUpload, p.Upload},
+		{StagePackage, p.Package},
+		{StageDeploy, p.Deploy},
+	}
+	if !p.Options.Detach {
+		steps = append(steps, step{StageAwait, p.Await})
+	}
+	if err := p.runStages(ctx, steps); err != nil {
+		return err
+	}
+	// plans are diffed against succeeded deployments, the result of detached ones is unknown
+	if !p.Options.Detach {
+		p.record()
+	}
+	p.Listener.OnStage(StageDone, p.Context)
+	return nil
+}
+
+// cancelDeployment cancels the triggered deployment of interrupted pipeline
+func (p *Pipeline) cancelDeployment() error {
+	// ctx of pipeline is done already
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	canceled, err := p.API.CancelDeployment(ctx, p.Deployment.ID)
+	if err != nil {
+		return errs.Wrap(errs.Canceled, fmt.Errorf("cancel deployment %s: %w", p.Deployment.ID, err))
+	}
+	if !canceled {
+		return errs.Newf(errs.Canceled, "deployment %s is still running, cancellation failed", p.Deployment.ID)
+	}
+	return errs.New(errs.Canceled, "deployment canceled")
+}
+
+func (p *Pipeline) dryRun(ctx context.Context) error {
+	err := p.runStages(ctx, []step{
+		{StageValidate, p.Validate},
+		{StageConfig, p.LoadConfig},
+		{StagePreDeploy, p.PreDeploy},
+	})
+	if err != nil {
+		return err
+	}
+
+	files, err := p.ListFiles()
+	if err != nil {
+		return err
+	}
+	p.Files = files
+	p.Listener.OnStage(StageDone, p.Context)
+	return nil
+}
+
+func (p *Pipeline) buildOnly(ctx context.Context) error {
+	err := p.runStages(ctx, []step{
+		{StageValidate, p.Validate},
+		{StageConfig, p.LoadConfig},
+		{StagePreDeploy, p.PreDeploy},
+		{StageBuild, p.Build},
+	})
+	if err != nil {
+		return err
+	}
+	p.Listener.OnStage(StageDone, p.Context)
+	return nil
+}
+
+func (p *Pipeline) plan(ctx context.Context) error {
+	err := p.runStages(ctx, []step{
+		{StageValidate, p.Validate},
+		{StageConfig, p.LoadConfig},
+		{StagePreDeploy, p.PreDeploy},
+	})
+	if err != nil {
+		return err
+	}
+
+	files, err := p.ListFiles()
+	if err != nil {
+		return err
+	}
+	p.Plan = NewPlan(p.Context, p.channel(), files)
+	if p.Previous, err = LoadRecord(p.RecordDir, p.Context.Name, p.Plan.Channel); err != nil {
+		logrus.WithError(err).Debugln("load deployment record")
+	}
+	p.Listener.OnStage(StageDone, p.Context)
+	return nil
+}
+
+// ListFiles returns the files would be shipped by upload and package stages,
+// static files are listed as they are, without compiling.
+func (p *Pipeline) ListFiles() (files FileList, err error) {
+	template := p.Context.PreDeployRequest.BuildTemplate
+	if template.ContainsStatic {
+		dir := p.staticDir()
+		// recorded relative to project dir, so plans don't differ by where the project is
+		files.StaticDir = filepath.ToSlash(dir)
+		if rel, err := filepath.Rel(p.dir(), dir); err == nil {
+			files.StaticDir = filepath.ToSlash(rel)
+		}
+		if _, err := os.Stat(dir); err == nil {
+			if files.Static, files.StaticSize, err = listFiles(dir, nil); err != nil {
+				return files, err
+			}
+		}
+	}
+	if template.ContainsDynamic {
+		if files.Source, files.SourceSize, err = listFiles(p.root(), p.included); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// listFiles returns the files not ignored under dir, filtered by include if not nil
+func listFiles(dir string, include func(rel string) bool) (files []string, size int64, err error) {
+	m, err := ignore.New(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = m.Walk(func(rel string, info os.FileInfo) error {
+		if include == nil || include(rel) {
+			files = append(files, rel)
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size, err
+}
+
+func (p *Pipeline) dir() string {
+	if p.Options.Dir != "" {
+		return p.Options.Dir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+func (p *Pipeline) root() string {
+	if p.Options.Root != "" {
+		return p.Options.Root
+	}
+	return p.dir()
+}
+
+// included tells whether the slash separated path relative to root should be uploaded
+func (p *Pipeline) included(rel string) bool {
+	if len(p.Options.Include) == 0 {
+		return true
+	}
+	for _, include := range p.Options.Include {
+		if workspace.Contains(include, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pipeline) bundleID() string {
+	return p.Context.Name + "-" + p.Context.PreDeployRequest.CheckDeployCapability.HashID
+}
+
+// Validate checks the project dir is deployable
+func (p *Pipeline) Validate(ctx context.Context) error {
+	dir := p.dir()
+
+	// check not home dir
+	if usr, err := user.Current(); err == nil && dir == usr.HomeDir {
+		return errs.New(errs.Validation, "currently under home dir, please switch to your project dir")
+	}
+
+	// limit files to 10000
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) > 10000 {
+		return errs.New(errs.Validation, "too many files in current dir, please check whether in the "+
+			"correct directory")
+	}
+	return nil
+}
+
+// ResolveConfig loads the layers of config and merges them into the context, see Layers.Merge.
+// The environment of config file is chosen by channel, the preference of user is queried if not specified.
+func (p *Pipeline) ResolveConfig(ctx context.Context) error {
+	layers := &p.Layers
+
+	// detect current project config first
+	// init current project name
+	detected := DeployContext{}
+	detected.Name = filepath.Base(p.dir())
+	p.Candidates = detected.DetectProjectType(p.dir())
+	layers.Detected = detected.LetConfig
+
+	// get cache config
+	layers.Saved = SavedConfig(p.dir())
+
+	// load user config and environment variables
+	file, err := config.Read(p.dir())
+	if err != nil {
+		return err
+	}
+	channel := p.Options.Channel
+	if channel == "" && (len(file.Environments()) > 0 || env.ChannelSpecific(p.dir())) {
+		if channel, err = p.API.ChannelPreference(ctx); err != nil {
+			return err
+		}
+	}
+	p.Context.Channel = channel
+	// local env files are never deployed, .env.local could hold the secrets of dev channel
+	if layers.File, err = fileLayer(file, p.dir(), channel, false, p.Options.EnvFiles); err != nil {
+		return err
+	}
+
+	// merge cli flag config
+	layers.Flags = FlagConfig(p.Options)
+	return p.merge()
+}
+
+// merge sets the config of context to the merged layers
+func (p *Pipeline) merge() error {
+	merged, err := p.Layers.Merge()
+	if err != nil {
+		return err
+	}
+	if merged.CN == nil {
+		cn := false
+		merged.CN = &cn
+	}
+	p.Context.LetConfig = merged
+	return nil
+}
+
+// LoadConfig resolves config, then lets listener choose the project type if several are plausible
+func (p *Pipeline) LoadConfig(ctx context.Context) error {
+	if err := p.ResolveConfig(ctx); err != nil {
+		return err
+	}
+
+	if p.root() != p.dir() {
+		workdir, err := filepath.Rel(p.root(), p.dir())
+		if err != nil {
+			return err
+		}
+		p.Context.Workdir = filepath.ToSlash(workdir)
+	}
+
+	// several frameworks are plausible, and type is neither specified nor configured
+	if p.Options.ProjectType == "" && Ambiguous(p.Candidates) && p.Context.Type == p.Candidates[0].Type {
+		chosen, err := p.Listener.Choose(p.Candidates, p.Context)
+		if err != nil {
+			return err
+		}
+		detected := DeployContext{LetConfig: p.Layers.Detected}
+		detected.SetCandidate(chosen)
+		p.Layers.Detected = detected.LetConfig
+		if err := p.merge(); err != nil {
+			return err
+		}
+	}
+
+	if p.Context.Type == "unknown" || p.Context.Type == "" {
+		return errs.New(errs.Validation, "unknown project type, please check your project directory. "+
+			"or you could specify project type with `-t` flag")
+	}
+	return nil
+}
+
+// Confirm asks listener whether to deploy a new project, or a project moved to another dir
+func (p *Pipeline) Confirm(ctx context.Context) error {
+	exists, err := p.API.ProjectExists(ctx, p.Context.Name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := p.confirm(QuestionNewProject); err != nil {
+			return err
+		}
+	}
+
+	// check if user dir is changed
+	if cached, ok := cache.ProjectsInfo[p.Context.Name]; ok {
+		logrus.Debug("cached project dir: ", cached.Dir)
+		logrus.Debug("current project dir: ", p.dir())
+
+		if p.dir() != cached.Dir {
+			return p.confirm(QuestionDirChanged)
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) confirm(q Question) error {
+	confirmed, err := p.Listener.Confirm(q, p.Context)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errs.New(errs.Canceled, "deployment canceled")
+	}
+	return nil
+}
+
+// PreDeploy queries the bundle id and build template of project
+func (p *Pipeline) PreDeploy(ctx context.Context) error {
+	query, err := p.API.PreDeploy(ctx, p.Context)
+	if err != nil {
+		return err
+	}
+	p.Context.PreDeployRequest = query
+
+	// check whether is dynamic project
+	if p.Context.Web3 != nil && *p.Context.Web3 && query.BuildTemplate.ContainsDynamic {
+		return errs.New(errs.Validation, "you cannot deploy dynamic project to web3 infra yet")
+	}
+
+	// build.outputDir > static > dist dir of project type
+	if p.Context.Build != nil && p.Context.Build.OutputDir != "" {
+		p.Context.Static = p.Context.Build.OutputDir
+	}
+	if p.Context.Static == "" {
+		p.Context.Static = query.BuildTemplate.DistDir
+	}
+	p.Context.BuildStep = p.buildStep()
+	return nil
+}
+
+// buildStep resolves the local build step, build.command of config replaces the compile commands of template,
+// which are run only for the compiled static files
+func (p *Pipeline) buildStep() build.Step {
+	template := p.Context.PreDeployRequest.BuildTemplate
+	step := build.Step{OutputDir: p.Context.Static, Env: map[string]string{}}
+	for k, v := range p.Context.Env {
+		step.Env[k] = v
+	}
+
+	c := p.Context.Build
+	if c != nil {
+		for k, v := range c.Env {
+			step.Env[k] = v
+		}
+	}
+	switch {
+	case c != nil && c.Command != "":
+		step.Commands = []string{c.Command}
+	case template.ContainsStatic && template.LocalCompiling && p.Context.Type != "static":
+		step.Commands = template.CompileCommands
+	}
+	return step
+}
+
+// Build runs the local build step by shell, see build.Runner.
+// The cached output is reused instead if the inputs of build are unchanged, see build.HashInputs.
+func (p *Pipeline) Build(ctx context.Context) error {
+	step := p.Context.BuildStep
+	if len(step.Commands) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	inputs, cacheable := p.buildInputs(step)
+	if cacheable && !p.Options.NoCache {
+		if entry := p.restoreBuild(inputs); entry != nil {
+			p.Context.BuildCache = entry
+			p.Context.BuildDuration = time.Since(start)
+			return nil
+		}
+	}
+
+	runner := build.Runner{Dir: p.dir(), Stdout: p.Options.Stdout, Stderr: p.Options.Stderr}
+	if err := runner.Run(ctx, step); err != nil {
+		return err
+	}
+	p.Context.BuildDuration = time.Since(start)
+
+	if !p.Context.PreDeployRequest.BuildTemplate.ContainsStatic {
+		return nil
+	}
+	if _, err := os.Stat(p.staticDir()); err != nil {
+		return errs.Newf(errs.BuildFailed, "output dir %s not found after building, "+
+			"please set build.outputDir in let.json", p.Context.Static)
+	}
+
+	if cacheable {
+		now := time.Now()
+		entry := &build.Entry{
+			Project:   p.Context.Name,
+			Key:       inputs.Key(),
+			Inputs:    inputs,
+			Commands:  step.Commands,
+			OutputDir: p.Context.Static,
+			CreatedAt: now,
+			UsedAt:    now,
+		}
+		if err := p.BuildCache.Save(entry, p.dir(), p.staticDir()); err != nil {
+			logrus.WithError(err).Debugln("save build cache")
+		}
+	}
+	return nil
+}
+
+// buildInputs hashes the inputs of step, false if the output could not be cached:
+// no static files are built, or they are not built inside project dir,
+// since the output dir is replaced when restored from cache
+func (p *Pipeline) buildInputs(step build.Step) (build.Inputs, bool) {
+	template := p.Context.PreDeployRequest.BuildTemplate
+	if !template.ContainsStatic || !build.Inside(p.dir(), p.staticDir()) {
+		return build.Inputs{}, false
+	}
+	// env values are hashed by the secret key of deployment records
+	key, err := secretKey(p.RecordDir, true)
+	if err != nil {
+		logrus.WithError(err).Debugln("load secret key")
+		return build.Inputs{}, false
+	}
+	inputs, err := build.HashInputs(p.root(), p.dir(), p.staticDir(), step, key)
+	if err != nil {
+		logrus.WithError(err).Debugln("hash build inputs")
+		return inputs, false
+	}
+	return inputs, true
+}
+
+// restoreBuild restores the cached output of inputs into static dir, nil if not cached
+func (p *Pipeline) restoreBuild(inputs build.Inputs) *build.Entry {
+	entry, err := p.BuildCache.Get(p.Context.Name, inputs.Key())
+	if err != nil {
+		logrus.WithError(err).Debugln("get build cache")
+		return nil
+	}
+	if entry == nil {
+		if latest, _ := p.BuildCache.Latest(p.Context.Name); latest != nil {
+			logrus.Debugf("build cache missed, %s changed", strings.Join(inputs.Changed(latest.Inputs), ", "))
+		}
+		return nil
+	}
+	if err := p.BuildCache.Restore(entry, p.dir(), p.staticDir()); err != nil {
+		logrus.WithError(err).Debugln("restore build cache")
+		return nil
+	}
+	return entry
+}
+
+// Upload uploads the static files of project
+func (p *Pipeline) Upload(ctx context.Context) error {
+	if !p.Context.PreDeployRequest.BuildTemplate.ContainsStatic {
+		return nil
+	}
+	return p.API.UploadStatic(ctx, p.staticDir(), p.Context.Name, p.bundleID(), *p.Context.CN, p.Context.Headers)
+}
+
+// staticDir returns the static dir resolved against project dir
+func (p *Pipeline) staticDir() string {
+	if filepath.IsAbs(p.Context.Static) {
+		return p.Context.Static
+	}
+	return filepath.Join(p.dir(), p.Context.Static)
+}
+
+// Package archives the source code of dynamic project, then uploads the tarball
+func (p *Pipeline) Package(ctx context.Context) error {
+	if !p.Context.PreDeployRequest.BuildTemplate.ContainsDynamic {
+		return nil
+	}
+	dirPath := p.root()
+
+	// respect .gitignore and .letignore
+	files, size, err := listFiles(dirPath, p.included)
+	if err != nil {
+		return err
+	}
+
+	// source code is too big
+	// <= confirm limit directly upload
+	// confirm limit < files <= max limit confirm
+	// > max limit abort
+	report := SourceReport{Size: datasize.ByteSize(size), Limit: p.sourceLimit(ctx)}
+	if report.Size > report.Limit.Confirm || report.Size > report.Limit.Max {
+		report.Largest = largest(dirPath, files, largestEntries)
+	}
+	p.Context.Source = report
+	if report.Size > report.Limit.Max {
+		return errs.Newf(errs.Quota, "your source code is %s, larger than the limit %s, the largest are:\n%s"+
+			"you could remove the irrelevant via .letignore or .gitignore.",
+			report.Size.HR(), report.Limit.Max.HR(), report.Breakdown())
+	}
+	if report.Size > report.Limit.Confirm {
+		if err := p.confirm(QuestionLargeSource); err != nil {
+			return err
+		}
+	}
+
+	// stream the tarball to upload, without copies on disk
+	filename := p.bundleID() + p.Options.Compression.Ext()
+	pr, pw := io.Pipe()
+	writeErr := make(chan error, 1)
+	go func() {
+		err := WriteTar(pw, dirPath, files, p.Options.Compression)
+		pw.CloseWithError(err)
+		writeErr <- err
+	}()
+
+	err = p.API.UploadSource(ctx, pr, size, filename, p.Context.Name, *p.Context.CN)
+	// unblock the writer if upload stopped reading
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err := <-writeErr; err != nil && err != io.ErrClosedPipe {
+		return fmt.Errorf("package source: %w", err)
+	}
+	return err
+}
+
+// Deploy triggers the deployment in channel
+func (p *Pipeline) Deploy(ctx context.Context) error {
+	// values of env are secrets, kept out of logs
+	masked := *p.Context
+	masked.LetConfig = env.MaskedConfig(masked.LetConfig)
+	logrus.WithFields(logrus.Fields{
+		"json": masked,
+	}).Debugln("deploymentCtx")
+
+	configBytes, err := json.Marshal(p.Context)
+	if err != nil {
+		return err
+	}
+
+	channel := p.channel()
+	p.Context.Channel = channel
+
+	p.Deployment, err = p.API.Deploy(ctx, DeployInput{
+		Type:       p.Context.Type,
+		Name:       p.Context.Name,
+		Config:     string(configBytes),
+		Channel:    channel,
+		CN:         *p.Context.CN,
+		CheckRunID: p.Options.CheckRunID,
+	})
+	if err != nil {
+		return err
+	}
+	p.Listener.OnDeployment(p.Deployment, p.Context)
+	return nil
+}
+
+// channel returns the channel to deploy, the preference of user if not resolved with config
+func (p *Pipeline) channel() string {
+	if p.Context.Channel != "" {
+		return p.Context.Channel
+	}
+	return p.Context.PreDeployRequest.Preference
+}
+
+// record saves the plan of the succeeded deployment, for the next plan to diff against
+func (p *Pipeline) record() {
+	files, err := p.ListFiles()
+	if err != nil {
+		logrus.WithError(err).Debugln("list files to record")
+		return
+	}
+	record := Record{
+		ID:         p.Deployment.ID,
+		DeployedAt: time.Now(),
+		Plan:       NewPlan(p.Context, p.Context.Channel, files),
+	}
+	if err := SaveRecord(p.RecordDir, record); err != nil {
+		logrus.WithError(err).Debugln("save deployment record")
+	}
+}
+
+// Await polls the deployment status until done
+func (p *Pipeline) Await(ctx context.Context) error {
+	interval := p.Options.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	for {
+		status, err := p.API.DeploymentStatus(ctx, p.Deployment.ID)
+		if err != nil {
+			logrus.WithError(err).Debugln("get deployment status")
+		} else {
+			p.Listener.OnStatus(status)
+
+			if status.Done {
+				p.Status = status
+				if status.Status == "Failed" {
+					return errs.New(errs.BuildFailed, "build logs: "+status.ErrorLogs)
+				}
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errs.Wrap(errs.Canceled, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,874 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/handler/build"
+	"github.com/let-sh/cli/log/errs"
+	"github.com/let-sh/cli/types"
+)
+
+type fakeAPI struct {
+	exists     bool
+	preference string
+	preDeploy  PreDeployRequest
+	statuses   []DeploymentStatus
+	limit      types.SizeLimit
+
+	uploadedStatic string
+	uploadedSource string
+	sourceTarball  []byte
+	deployed       DeployInput
+	statusCalls    int
+	canceled       string
+}
+
+func (f *fakeAPI) ProjectExists(ctx context.Context, projectName string) (bool, error) {
+	return f.exists, nil
+}
+
+func (f *fakeAPI) ChannelPreference(ctx context.Context) (string, error) {
+	return f.preference, nil
+}
+
+func (f *fakeAPI) PreDeploy(ctx context.Context, c *DeployContext) (PreDeployRequest, error) {
+	return f.preDeploy, nil
+}
+
+func (f *fakeAPI) UploadStatic(ctx context.Context, dir, projectName, bundleID string, cn bool,
+	headers []types.HeaderRule) error {
+	f.uploadedStatic = dir
+	return nil
+}
+
+func (f *fakeAPI) SourceSizeLimit(ctx context.Context, projectName string) (types.SizeLimit, error) {
+	return f.limit, nil
+}
+
+func (f *fakeAPI) UploadSource(ctx context.Context, r io.Reader, size int64, filename, projectName string,
+	cn bool) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.uploadedSource = filename
+	f.sourceTarball = content
+	return nil
+}
+
+func (f *fakeAPI) Deploy(ctx context.Context, input DeployInput) (Deployment, error) {
+	f.deployed = input
+	return Deployment{ID: "deployment-id", Status: "Queuing"}, nil
+}
+
+func (f *fakeAPI) DeploymentStatus(ctx context.Context, id string) (DeploymentStatus, error) {
+	s := f.statuses[f.statusCalls]
+	f.statusCalls++
+	return s, nil
+}
+
+func (f *fakeAPI) CancelDeployment(ctx context.Context, id string) (bool, error) {
+	f.canceled = id
+	return true, nil
+}
+
+type recordListener struct {
+	NopListener
+	stages    []Stage
+	questions []Question
+	confirm   bool
+}
+
+func (l *recordListener) OnStage(stage Stage, c *DeployContext) {
+	l.stages = append(l.stages, stage)
+}
+
+func (l *recordListener) Confirm(q Question, c *DeployContext) (bool, error) {
+	l.questions = append(l.questions, q)
+	return l.confirm, nil
+}
+
+// newTestPipeline creates a pipeline under a temp project dir with files
+func newTestPipeline(t *testing.T, api API, listener Listener, opts Options, files map[string]string) *Pipeline {
+	dir, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// config files are read from current dir
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	opts.Dir = dir
+	opts.PollInterval = time.Millisecond
+	p := NewPipeline(opts, listener)
+	p.API = api
+
+	// keep build cache and deployment records out of home and project dir
+	cacheDir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+	p.BuildCache = build.Cache{Root: filepath.Join(cacheDir, "build")}
+	p.RecordDir = filepath.Join(cacheDir, "deployments")
+	return p
+}
+
+func staticTemplate() PreDeployRequest {
+	var q PreDeployRequest
+	q.CheckDeployCapability.HashID = "hash"
+	q.BuildTemplate.ContainsStatic = true
+	q.BuildTemplate.DistDir = "dist"
+	q.Preference = "dev"
+	return q
+}
+
+func TestPipelineStatic(t *testing.T) {
+	api := &fakeAPI{
+		exists:    true,
+		preDeploy: staticTemplate(),
+		statuses: []DeploymentStatus{
+			{Status: "Queuing"},
+			{Status: "Succeeded", Done: true, TargetFQDN: "example.let.sh"},
+		},
+	}
+	listener := &recordListener{confirm: true}
+	p := newTestPipeline(t, api, listener, Options{ProjectType: "static"}, nil)
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Stage{StageValidate, StageConfig, StageConfirm, StagePreDeploy, StageBuild, StageUpload,
+		StagePackage, StageDeploy, StageAwait, StageDone}
+	if !reflect.DeepEqual(listener.stages, want) {
+		t.Errorf("stages = %v, want %v", listener.stages, want)
+	}
+	if want := filepath.Join(p.Options.Dir, "dist"); api.uploadedStatic != want {
+		t.Errorf("uploaded static dir = %q, want %q", api.uploadedStatic, want)
+	}
+	if api.uploadedSource != "" {
+		t.Errorf("static project should not upload source, got %q", api.uploadedSource)
+	}
+	if api.deployed.Channel != "dev" || api.deployed.Type != "static" {
+		t.Errorf("deployed %+v, want static project to dev channel", api.deployed)
+	}
+	if p.Status.TargetFQDN != "example.let.sh" {
+		t.Errorf("status = %+v", p.Status)
+	}
+}
+
+func TestPipelineChannelFlag(t *testing.T) {
+	api := &fakeAPI{exists: true, preDeploy: staticTemplate()}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "static", Channel: "prod", Detach: true}, nil)
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.deployed.Channel != "prod" {
+		t.Errorf("channel = %q, want prod", api.deployed.Channel)
+	}
+	if api.statusCalls != 0 {
+		t.Errorf("detached pipeline polled status %d times", api.statusCalls)
+	}
+}
+
+func TestPipelineEnvironments(t *testing.T) {
+	files := map[string]string{
+		"let.yaml": `env:
+  API: https://api.dev.example.com
+  MODE: web
+static: public
+environments:
+  prod:
+    env:
+      API: https://api.example.com
+    cn: true
+`,
+	}
+	for _, c := range []struct {
+		name       string
+		channel    string
+		preference string
+		want       string
+		cn         bool
+	}{
+		{"flag", "prod", "dev", "https://api.example.com", true},
+		{"preference", "", "prod", "https://api.example.com", true},
+		{"without environment", "", "dev", "https://api.dev.example.com", false},
+	} {
+		api := &fakeAPI{exists: true, preference: c.preference, preDeploy: staticTemplate()}
+		opts := Options{ProjectType: "static", Channel: c.channel, Detach: true}
+		p := newTestPipeline(t, api, nil, opts, files)
+
+		if err := p.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var deployed DeployContext
+		if err := json.Unmarshal([]byte(api.deployed.Config), &deployed); err != nil {
+			t.Fatal(err)
+		}
+		if deployed.Env["API"] != c.want || deployed.Env["MODE"] != "web" || deployed.Static != "public" {
+			t.Errorf("%s: deployed config = %+v, want API %s merged", c.name, deployed.LetConfig, c.want)
+		}
+		if api.deployed.CN != c.cn || deployed.Environments != nil {
+			t.Errorf("%s: deployed cn = %v, environments = %v", c.name, api.deployed.CN, deployed.Environments)
+		}
+		channel := c.channel
+		if channel == "" {
+			channel = c.preference
+		}
+		if api.deployed.Channel != channel || deployed.Channel != channel {
+			t.Errorf("%s: deployed to %s, config of %s, want %s", c.name, api.deployed.Channel, deployed.Channel,
+				channel)
+		}
+	}
+}
+
+func TestPipelineEnvFile(t *testing.T) {
+	// channel is resolved by preference for the env files of channel
+	api := &fakeAPI{exists: true, preference: "prod", preDeploy: staticTemplate()}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "static", Detach: true}, map[string]string{
+		"let.json":         `{"env": {"LETS_TEST_MODE": "web", "LETS_TEST_TOKEN": "config"}}`,
+		".env":             "LETS_TEST_TOKEN=secret\nLETS_TEST_HOST=example.com\n",
+		".env.production":  "LETS_TEST_URL=https://${LETS_TEST_HOST}\n",
+		".env.development": "LETS_TEST_URL=http://localhost\n",
+		"extra.env":        "LETS_TEST_EXTRA=${LETS_TEST_URL}/extra\n",
+	})
+	p.Options.EnvFiles = []string{filepath.Join(p.Options.Dir, "extra.env")}
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var deployed DeployContext
+	if err := json.Unmarshal([]byte(api.deployed.Config), &deployed); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"LETS_TEST_MODE":  "web",
+		"LETS_TEST_TOKEN": "secret",
+		"LETS_TEST_HOST":  "example.com",
+		"LETS_TEST_URL":   "https://example.com",
+		"LETS_TEST_EXTRA": "https://example.com/extra",
+	}
+	if !reflect.DeepEqual(deployed.Env, want) || api.deployed.Channel != "prod" {
+		t.Errorf("deployed env = %v to %s, want %v to prod", deployed.Env, api.deployed.Channel, want)
+	}
+	if v, ok := os.LookupEnv("LETS_TEST_TOKEN"); ok {
+		t.Errorf("env file leaks into the environment of cli: %q", v)
+	}
+}
+
+func TestPipelineLocalEnvFile(t *testing.T) {
+	// secrets of dev channel pulled to .env.local are never deployed
+	api := &fakeAPI{exists: true, preDeploy: staticTemplate()}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "static", Channel: "prod", Detach: true},
+		map[string]string{
+			".env":                  "LETS_TEST_HOST=example.com\n",
+			".env.local":            "LETS_TEST_TOKEN=dev-secret\nLETS_TEST_HOST=localhost\n",
+			".env.production.local": "LETS_TEST_TOKEN=prod-secret\n",
+		})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var deployed DeployContext
+	if err := json.Unmarshal([]byte(api.deployed.Config), &deployed); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"LETS_TEST_HOST": "example.com"}; !reflect.DeepEqual(deployed.Env, want) {
+		t.Errorf("deployed env = %v, want %v", deployed.Env, want)
+	}
+}
+
+func TestPipelineDynamic(t *testing.T) {
+	var q PreDeployRequest
+	q.CheckDeployCapability.HashID = "hash"
+	q.BuildTemplate.ContainsDynamic = true
+	api := &fakeAPI{
+		exists:    true,
+		preDeploy: q,
+		statuses:  []DeploymentStatus{{Status: "Succeeded", Done: true}},
+	}
+	p := newTestPipeline(t, api, nil, Options{ProjectName: "app", ProjectType: "gin"}, map[string]string{
+		"main.go": "package main",
+	})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.uploadedSource != "app-hash.tar.gz" {
+		t.Errorf("uploaded source = %q, want app-hash.tar.gz", api.uploadedSource)
+	}
+	if names := readTar(t, bytes.NewReader(api.sourceTarball), CompressionGzip); !reflect.DeepEqual(names,
+		[]string{"main.go"}) {
+		t.Errorf("tarball files = %v, want main.go", names)
+	}
+}
+
+func TestPipelineDryRun(t *testing.T) {
+	var q PreDeployRequest
+	q.BuildTemplate.ContainsDynamic = true
+	api := &fakeAPI{preDeploy: q}
+	listener := &recordListener{}
+	p := newTestPipeline(t, api, listener, Options{ProjectType: "gin", DryRun: true}, map[string]string{
+		"main.go":    "package main",
+		".gitignore": "*.log",
+		"debug.log":  "",
+		".env":       "SECRET=1",
+	})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []Stage{StageValidate, StageConfig, StagePreDeploy, StageDone}
+	if !reflect.DeepEqual(listener.stages, want) {
+		t.Errorf("stages = %v, want %v", listener.stages, want)
+	}
+	if files := []string{".gitignore", "main.go"}; !reflect.DeepEqual(p.Files.Source, files) {
+		t.Errorf("source files = %v, want %v", p.Files.Source, files)
+	}
+	if api.uploadedSource != "" || api.deployed.Type != "" {
+		t.Errorf("dry run should not upload or deploy, got %q %+v", api.uploadedSource, api.deployed)
+	}
+}
+
+func compiledTemplate(commands ...string) PreDeployRequest {
+	q := staticTemplate()
+	q.BuildTemplate.LocalCompiling = true
+	q.BuildTemplate.CompileCommands = commands
+	return q
+}
+
+func TestPipelineCompileCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate(`mkdir dist`, `echo "$LETS_TEST_API" > 'dist/api.txt'`)}
+	var output bytes.Buffer
+	opts := Options{ProjectType: "react", Detach: true, Stdout: &output, Stderr: &output}
+	p := newTestPipeline(t, api, nil, opts, map[string]string{
+		"let.json": `{"env": {"LETS_TEST_API": "https://api.example.com"}}`,
+	})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("%v, output:\n%s", err, output.String())
+	}
+	content, err := ioutil.ReadFile(filepath.Join(p.Options.Dir, "dist", "api.txt"))
+	if err != nil || strings.TrimSpace(string(content)) != "https://api.example.com" {
+		t.Errorf("built api.txt = %q, %v, want env of config", content, err)
+	}
+	if want := filepath.Join(p.Options.Dir, "dist"); api.uploadedStatic != want {
+		t.Errorf("uploaded static dir = %q, want %q", api.uploadedStatic, want)
+	}
+}
+
+func TestPipelineBuildConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("exit 1")}
+	var output bytes.Buffer
+	opts := Options{ProjectType: "react", Detach: true, Stdout: &output, Stderr: &output}
+	p := newTestPipeline(t, api, nil, opts, map[string]string{
+		"let.json": `{
+  "env": {"LETS_TEST_MODE": "runtime", "LETS_TEST_API": "https://api.example.com"},
+  "build": {
+    "command": "mkdir -p out && echo \"$LETS_TEST_MODE $LETS_TEST_API\" | tee out/index.html",
+    "outputDir": "out",
+    "env": {"LETS_TEST_MODE": "build"}
+  }
+}`,
+	})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("%v, output:\n%s", err, output.String())
+	}
+	if want := "build https://api.example.com\n"; !strings.HasSuffix(output.String(), want) {
+		t.Errorf("output = %q, want build env over env", output.String())
+	}
+	if want := filepath.Join(p.Options.Dir, "out"); api.uploadedStatic != want {
+		t.Errorf("uploaded static dir = %q, want outputDir %q", api.uploadedStatic, want)
+	}
+	var deployed DeployContext
+	if err := json.Unmarshal([]byte(api.deployed.Config), &deployed); err != nil {
+		t.Fatal(err)
+	}
+	if deployed.Env["LETS_TEST_MODE"] != "runtime" {
+		t.Errorf("deployed env = %v, build env should not be deployed", deployed.Env)
+	}
+}
+
+func TestPipelineBuildCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	// runs of build command are counted out of project dir
+	runs, err := ioutil.TempFile("", "runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs.Close()
+	defer os.Remove(runs.Name())
+
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("echo run >> " + runs.Name() +
+		" && mkdir -p dist && cat src.txt > dist/index.html")}
+	opts := Options{ProjectType: "react", BuildOnly: true, Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+	p := newTestPipeline(t, api, nil, opts, map[string]string{"src.txt": "v1"})
+	run := func(opts Options) *Pipeline {
+		pipeline := NewPipeline(opts, nil)
+		pipeline.API, pipeline.BuildCache = api, p.BuildCache
+		if err := pipeline.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return pipeline
+	}
+	check := func(name string, wantRuns int, wantCached bool, wantContent string) {
+		t.Helper()
+		pipeline := run(p.Options)
+		content, _ := ioutil.ReadFile(runs.Name())
+		if got := strings.Count(string(content), "run"); got != wantRuns {
+			t.Errorf("%s: build command run %d times, want %d", name, got, wantRuns)
+		}
+		if cached := pipeline.Context.BuildCache != nil; cached != wantCached {
+			t.Errorf("%s: cached = %v, want %v", name, cached, wantCached)
+		}
+		output, _ := ioutil.ReadFile(filepath.Join(p.Options.Dir, "dist", "index.html"))
+		if string(output) != wantContent {
+			t.Errorf("%s: output = %q, want %q", name, output, wantContent)
+		}
+	}
+
+	check("first build", 1, false, "v1")
+	os.RemoveAll(filepath.Join(p.Options.Dir, "dist"))
+	check("unchanged", 1, true, "v1")
+	if err := ioutil.WriteFile(filepath.Join(p.Options.Dir, "src.txt"), []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check("source changed", 2, false, "v2")
+
+	noCache := p.Options
+	noCache.NoCache = true
+	run(noCache)
+	check("cached after no cache build", 3, true, "v2")
+}
+
+func TestPipelineBuildFailed(t *testing.T) {
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("exit 2")}
+	listener := &recordListener{confirm: true}
+	opts := Options{ProjectType: "react", Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+	p := newTestPipeline(t, api, listener, opts, nil)
+
+	err := p.Run(context.Background())
+	var exitErr *build.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 2 || errs.KindOf(err) != errs.BuildFailed {
+		t.Fatalf("err = %v, want build failed with exit code 2", err)
+	}
+	if api.uploadedStatic != "" || api.deployed.Type != "" {
+		t.Errorf("failed build should not upload or deploy, got %q %+v", api.uploadedStatic, api.deployed)
+	}
+}
+
+func TestPipelineMissingOutputDir(t *testing.T) {
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("echo built")}
+	opts := Options{ProjectType: "react", Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+	p := newTestPipeline(t, api, nil, opts, nil)
+
+	err := p.Run(context.Background())
+	if errs.KindOf(err) != errs.BuildFailed || !strings.Contains(err.Error(), "build.outputDir") {
+		t.Fatalf("err = %v, want missing output dir", err)
+	}
+}
+
+func TestPipelineBuildOutsideProject(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("echo built > ../built.txt")}
+	opts := Options{ProjectType: "react", BuildOnly: true, Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+	p := newTestPipeline(t, api, nil, opts, nil)
+
+	// the project is nested, its parent holds a sibling of it
+	parent, project := p.Options.Dir, filepath.Join(p.Options.Dir, "site")
+	for name, content := range map[string]string{
+		filepath.Join(parent, "sibling.txt"): "sibling",
+		filepath.Join(project, "index.html"): "index",
+	} {
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(config string) error {
+		if err := ioutil.WriteFile(filepath.Join(project, "let.json"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		opts := p.Options
+		opts.Dir = project
+		pipeline := NewPipeline(opts, nil)
+		pipeline.API, pipeline.BuildCache = api, p.BuildCache
+		return pipeline.Run(context.Background())
+	}
+
+	if err := run(`{"build": {"outputDir": ".."}}`); errs.KindOf(err) != errs.Validation {
+		t.Errorf("err = %v, want output dir containing project rejected", err)
+	}
+
+	// the dist dir of template is not validated, the output out of project is never cached nor replaced
+	api.preDeploy.BuildTemplate.DistDir = ".."
+	for i := 0; i < 2; i++ {
+		if err := run(`{}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, err := p.BuildCache.List(); err != nil || len(entries) != 0 {
+		t.Errorf("cached = %+v, %v, want nothing", entries, err)
+	}
+	for _, name := range []string{filepath.Join(parent, "sibling.txt"), filepath.Join(project, "index.html")} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s removed: %v", name, err)
+		}
+	}
+}
+
+func TestPipelineBuildOnly(t *testing.T) {
+	api := &fakeAPI{preDeploy: compiledTemplate("mkdir dist")}
+	listener := &recordListener{}
+	opts := Options{ProjectType: "react", BuildOnly: true, Stdout: ioutil.Discard, Stderr: ioutil.Discard}
+	p := newTestPipeline(t, api, listener, opts, nil)
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []Stage{StageValidate, StageConfig, StagePreDeploy, StageBuild, StageDone}
+	if !reflect.DeepEqual(listener.stages, want) {
+		t.Errorf("stages = %v, want %v", listener.stages, want)
+	}
+	if _, err := os.Stat(filepath.Join(p.Options.Dir, "dist")); err != nil {
+		t.Errorf("build step not run: %v", err)
+	}
+	if api.uploadedStatic != "" || api.deployed.Type != "" {
+		t.Errorf("build only should not upload or deploy, got %q %+v", api.uploadedStatic, api.deployed)
+	}
+}
+
+func TestPipelinePlan(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	api := &fakeAPI{exists: true, preDeploy: compiledTemplate("npm run build")}
+	files := map[string]string{"let.json": `{"env": {"TOKEN": "secret"}}`}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "react", Plan: true}, files)
+	if err := os.MkdirAll(filepath.Join(p.Options.Dir, "dist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plan := func() *Pipeline {
+		pipeline := NewPipeline(p.Options, nil)
+		pipeline.API, pipeline.BuildCache, pipeline.RecordDir = api, p.BuildCache, p.RecordDir
+		if err := pipeline.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return pipeline
+	}
+
+	first := plan()
+	if first.Previous != nil || api.deployed.Type != "" {
+		t.Errorf("plan without deployment has previous %+v, deployed %+v", first.Previous, api.deployed)
+	}
+	got := first.Plan
+	if got.Name != first.Context.Name || got.Type != "react" || got.Channel != "dev" ||
+		!reflect.DeepEqual(got.BuildCommands, []string{"npm run build"}) || got.Config.Env["TOKEN"] != "********" {
+		t.Errorf("plan = %+v, want react project to dev channel with masked env", got)
+	}
+	if got.Files.StaticDir != "dist" {
+		t.Errorf("static dir = %q, want dist relative to project dir", got.Files.StaticDir)
+	}
+
+	// deploy as planned, then plan the changes since it
+	deploy := func(status string) error {
+		deployed := NewPipeline(Options{Dir: p.Options.Dir, ProjectType: "react",
+			Stdout: ioutil.Discard, Stderr: ioutil.Discard}, nil)
+		deployed.API, deployed.BuildCache, deployed.RecordDir = api, p.BuildCache, p.RecordDir
+		api.statuses, api.statusCalls = []DeploymentStatus{{Status: status, Done: true}}, 0
+		return deployed.Run(context.Background())
+	}
+	api.preDeploy = compiledTemplate("mkdir -p dist")
+	if err := deploy("Succeeded"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p.Options.Dir, "dist", "app.js"), []byte("app()"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p.Options.Dir, "let.json"), []byte(`{"env": {"TOKEN": "rotated"}}`),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+	// failed deployments are not recorded
+	if err := deploy("Failed"); errs.KindOf(err) != errs.BuildFailed {
+		t.Fatalf("err = %v, want build failed", err)
+	}
+
+	second := plan()
+	if second.Previous == nil || second.Previous.ID != "deployment-id" {
+		t.Fatalf("previous = %+v, want the recorded deployment", second.Previous)
+	}
+	var changes, want []string
+	for _, c := range second.Plan.Diff(second.Previous) {
+		if c.Op != "" {
+			changes = append(changes, c.Op+" "+c.Path)
+		}
+	}
+	want = []string{"~ config.env.TOKEN", "~ files.static_size", "+ files.static/app.js"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestPipelineLargeSource(t *testing.T) {
+	var q PreDeployRequest
+	q.BuildTemplate.ContainsDynamic = true
+	files := map[string]string{
+		"main.go": "package main",
+		"big.bin": strings.Repeat("0", 200),
+	}
+
+	for _, c := range []struct {
+		name      string
+		limit     types.SizeLimit
+		confirm   bool
+		ok        bool
+		kind      errs.Kind
+		questions []Question
+	}{
+		{"under confirm limit", types.SizeLimit{Confirm: 1 * datasize.KB, Max: 2 * datasize.KB}, false, true, 0, nil},
+		{"confirmed", types.SizeLimit{Confirm: 100, Max: 1 * datasize.KB}, true, true, 0,
+			[]Question{QuestionLargeSource}},
+		{"declined", types.SizeLimit{Confirm: 100, Max: 1 * datasize.KB}, false, false, errs.Canceled,
+			[]Question{QuestionLargeSource}},
+		{"over max limit", types.SizeLimit{Confirm: 100, Max: 150}, true, false, errs.Quota, nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			api := &fakeAPI{exists: true, preDeploy: q, limit: c.limit}
+			listener := &recordListener{confirm: c.confirm}
+			p := newTestPipeline(t, api, listener, Options{ProjectType: "gin", Detach: true}, files)
+
+			err := p.Run(context.Background())
+			if c.ok && err != nil {
+				t.Fatal(err)
+			}
+			if !c.ok && errs.KindOf(err) != c.kind {
+				t.Fatalf("error = %v, want %s", err, c.kind)
+			}
+			if !reflect.DeepEqual(listener.questions, c.questions) {
+				t.Errorf("questions = %v, want %v", listener.questions, c.questions)
+			}
+			if c.kind == errs.Quota && !strings.Contains(err.Error(), "big.bin") {
+				t.Errorf("error should list the largest files, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeSourceLimit(t *testing.T) {
+	for _, c := range []struct {
+		plan   types.SizeLimit
+		config *types.SizeLimit
+		want   types.SizeLimit
+	}{
+		{types.SizeLimit{}, nil, DefaultSourceLimit},
+		{types.SizeLimit{Max: 100 * datasize.MB}, nil, types.SizeLimit{Confirm: 20 * datasize.MB, Max: 100 * datasize.MB}},
+		{types.SizeLimit{Confirm: 60 * datasize.MB, Max: 100 * datasize.MB},
+			&types.SizeLimit{Confirm: 50 * datasize.MB, Max: 80 * datasize.MB},
+			types.SizeLimit{Confirm: 50 * datasize.MB, Max: 80 * datasize.MB}},
+		// let.json could not exceed the plan, or the default if unset by plan
+		{types.SizeLimit{Max: 100 * datasize.MB}, &types.SizeLimit{Confirm: 50 * datasize.MB, Max: 200 * datasize.MB},
+			types.SizeLimit{Confirm: 20 * datasize.MB, Max: 100 * datasize.MB}},
+		{types.SizeLimit{}, &types.SizeLimit{Max: 200 * datasize.MB}, DefaultSourceLimit},
+		// confirm is capped at max
+		{types.SizeLimit{}, &types.SizeLimit{Max: 10 * datasize.MB},
+			types.SizeLimit{Confirm: 10 * datasize.MB, Max: 10 * datasize.MB}},
+	} {
+		if got := mergeSourceLimit(c.plan, c.config); got != c.want {
+			t.Errorf("mergeSourceLimit(%+v, %+v) = %+v, want %+v", c.plan, c.config, got, c.want)
+		}
+	}
+}
+
+func TestPipelineFailed(t *testing.T) {
+	api := &fakeAPI{
+		exists:    true,
+		preDeploy: staticTemplate(),
+		statuses:  []DeploymentStatus{{Status: "Failed", Done: true, ErrorLogs: "exit 1"}},
+	}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "static"}, nil)
+
+	err := p.Run(context.Background())
+	if errs.KindOf(err) != errs.BuildFailed {
+		t.Errorf("error = %v, want build failed", err)
+	}
+}
+
+func TestPipelineInvalidConfig(t *testing.T) {
+	for config, want := range map[string]string{
+		`{"redirects": [{"source": "/a", "destination": "/a"}]}`: "redirect loop",
+		`{"cn": "yes"}`:   "let.json:1:8: cn: expect boolean, got string",
+		`{"name": "site"`: "let.json:1:",
+	} {
+		api := &fakeAPI{exists: true, preDeploy: staticTemplate()}
+		p := newTestPipeline(t, api, nil, Options{ProjectType: "static"}, map[string]string{"let.json": config})
+
+		err := p.Run(context.Background())
+		if errs.KindOf(err) != errs.Validation || !strings.Contains(err.Error(), want) {
+			t.Errorf("error of %s = %v, want %s", config, err, want)
+		}
+		if api.deployed.Name != "" {
+			t.Errorf("invalid config deployed %+v", api.deployed)
+		}
+	}
+}
+
+func TestPipelineNewProjectCanceled(t *testing.T) {
+	api := &fakeAPI{exists: false, preDeploy: staticTemplate()}
+	listener := &recordListener{confirm: false}
+	p := newTestPipeline(t, api, listener, Options{ProjectType: "static"}, nil)
+
+	err := p.Run(context.Background())
+	if errs.KindOf(err) != errs.Canceled {
+		t.Errorf("error = %v, want canceled", err)
+	}
+	if api.deployed.Name != "" {
+		t.Errorf("canceled pipeline deployed %+v", api.deployed)
+	}
+}
+
+// interruptListener cancels the pipeline once the deployment status is polled, as Ctrl+C does
+type interruptListener struct {
+	NopListener
+	cancel context.CancelFunc
+}
+
+func (l interruptListener) OnStatus(status DeploymentStatus) {
+	l.cancel()
+}
+
+func TestPipelineInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := &fakeAPI{
+		exists:    true,
+		preDeploy: staticTemplate(),
+		statuses:  []DeploymentStatus{{Status: "Building"}, {Status: "Building"}},
+	}
+	p := newTestPipeline(t, api, interruptListener{cancel: cancel},
+		Options{ProjectType: "static", PollInterval: time.Hour}, nil)
+
+	err := p.Run(ctx)
+	if errs.KindOf(err) != errs.Canceled || err.Error() != "deployment canceled" {
+		t.Errorf("error = %v, want deployment canceled", err)
+	}
+	if api.canceled != "deployment-id" {
+		t.Errorf("canceled deployment = %q, want the triggered one", api.canceled)
+	}
+
+	// nothing is deployed if interrupted before
+	api = &fakeAPI{exists: true, preDeploy: staticTemplate()}
+	p = newTestPipeline(t, api, nil, Options{ProjectType: "static"}, nil)
+	err = p.Run(ctx)
+	if errs.KindOf(err) != errs.Canceled || api.deployed.Name != "" || api.canceled != "" {
+		t.Errorf("error = %v, deployed %+v, canceled %q", err, api.deployed, api.canceled)
+	}
+}
+
+func TestPipelineWeb3Dynamic(t *testing.T) {
+	var q PreDeployRequest
+	q.BuildTemplate.ContainsDynamic = true
+	web3 := true
+	api := &fakeAPI{exists: true, preDeploy: q}
+	p := newTestPipeline(t, api, nil, Options{ProjectType: "gin", Web3: &web3}, nil)
+
+	err := p.Run(context.Background())
+	if errs.KindOf(err) != errs.Validation {
+		t.Errorf("error = %v, want validation error", err)
+	}
+}
+
+type chooseListener struct {
+	NopListener
+	choices []Candidate
+}
+
+func (l *chooseListener) Choose(candidates []Candidate, c *DeployContext) (Candidate, error) {
+	l.choices = candidates
+	return candidates[1], nil
+}
+
+func TestPipelineChooseAmbiguousType(t *testing.T) {
+	api := &fakeAPI{exists: true, preDeploy: staticTemplate()}
+	listener := &chooseListener{}
+	p := newTestPipeline(t, api, listener, Options{Detach: true}, map[string]string{
+		"package.json": `{"dependencies":{"express":"^4","react":"^17"}}`,
+	})
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.choices) != 2 {
+		t.Fatalf("choices = %+v, want express and react", listener.choices)
+	}
+	if api.deployed.Type != "react" {
+		t.Errorf("deployed type = %s, want the chosen react", api.deployed.Type)
+	}
+}
+
+func TestPipelineWorkspacePackage(t *testing.T) {
+	api := &fakeAPI{exists: true, preDeploy: staticTemplate()}
+	p := newTestPipeline(t, api, &recordListener{confirm: true}, Options{ProjectType: "static", Detach: true}, nil)
+
+	// deploy apps/web of the workspace at the temp dir
+	p.Options.Root = p.Options.Dir
+	p.Options.Dir = filepath.Join(p.Options.Root, "apps", "web")
+	p.Options.Include = []string{"package.json", "apps/web"}
+	if err := os.MkdirAll(p.Options.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.Context.Name != "web" || p.Context.Workdir != "apps/web" {
+		t.Errorf("name = %s, workdir = %s, want web at apps/web", p.Context.Name, p.Context.Workdir)
+	}
+	for file, want := range map[string]bool{
+		"package.json":            true,
+		"apps/web/index.html":     true,
+		"apps/api/index.js":       false,
+		"apps/web-legacy/main.js": false,
+	} {
+		if got := p.included(file); got != want {
+			t.Errorf("included(%s) = %v, want %v", file, got, want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package deploy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/types"
+)
+
+// Plan is what lets deploy would do, resolved without uploading or deploying.
+// It's deterministic for the same project and config, so that it could be reviewed and diffed.
+type Plan struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	// Config is the effective config with values of env masked
+	Config types.LetConfig `json:"config"`
+	// BuildCommands are the commands of local build step, from build.command or the build template of type
+	BuildCommands []string `json:"build_commands"`
+	// Files are the files to upload, static files are listed as they are before building
+	Files FileList `json:"files"`
+
+	// secrets are the env values by line path, to tell the changed ones while masked.
+	// They are never saved, only their digests keyed by secretKey are recorded.
+	secrets map[string]string
+}
+
+// Record is the plan of a succeeded deployment, the next plan of project and channel is diffed against it
+type Record struct {
+	ID         string    `json:"id"`
+	DeployedAt time.Time `json:"deployed_at"`
+	Plan       Plan      `json:"plan"`
+	// Secrets are the HMAC digests of env values by line path, see secretKey
+	Secrets map[string]string `json:"secrets,omitempty"`
+
+	// key is the secret key of the records dir, to digest the secrets of next plan alike
+	key []byte
+}
+
+// Change is a line of plan compared with the previous one
+type Change struct {
+	// Op is + for added, - for removed, ~ for changed, empty for unchanged
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// line is a field of plan, flattened by path
+type line struct {
+	path, value string
+}
+
+// NewPlan returns the plan of the resolved context, with the files to upload
+func NewPlan(c *DeployContext, channel string, files FileList) Plan {
+	plan := Plan{
+		Name:          c.Name,
+		Type:          c.Type,
+		Channel:       channel,
+		Config:        env.MaskedConfig(c.LetConfig),
+		BuildCommands: c.BuildStep.Commands,
+		Files:         files,
+		secrets:       map[string]string{},
+	}
+	for k, v := range c.Env {
+		plan.secrets["config.env."+k] = v
+	}
+	if c.Build != nil {
+		for k, v := range c.Build.Env {
+			plan.secrets["config.build.env."+k] = v
+		}
+	}
+	return plan
+}
+
+// secretDigests returns the HMAC-SHA256 of secrets by key, digests of short secrets can't be brute forced
+// without the key
+func secretDigests(key []byte, secrets map[string]string) map[string]string {
+	digests := make(map[string]string, len(secrets))
+	for path, value := range secrets {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		digests[path] = hex.EncodeToString(mac.Sum(nil))
+	}
+	return digests
+}
+
+// lines flattens plan into the lines to diff, in the order of printing
+func (p Plan) lines() []line {
+	lines := []line{{"name", p.Name}, {"type", p.Type}, {"channel", p.Channel}}
+	for i, command := range p.BuildCommands {
+		lines = append(lines, line{fmt.Sprintf("build_commands[%d]", i), command})
+	}
+
+	var config interface{}
+	if data, err := json.Marshal(p.Config); err == nil {
+		json.Unmarshal(data, &config)
+	}
+	lines = flatten(lines, "config", config)
+
+	lines = append(lines,
+		line{"files.static_dir", p.Files.StaticDir},
+		line{"files.static_size", datasize.ByteSize(p.Files.StaticSize).HR()},
+		line{"files.source_size", datasize.ByteSize(p.Files.SourceSize).HR()},
+	)
+	for _, f := range sorted(p.Files.Static) {
+		lines = append(lines, line{"files.static/" + f, ""})
+	}
+	for _, f := range sorted(p.Files.Source) {
+		lines = append(lines, line{"files.source/" + f, ""})
+	}
+	return lines
+}
+
+// flatten appends the scalars of v by path, keys of objects are sorted
+func flatten(lines []line, path string, v interface{}) []line {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return append(lines, line{path, "{}"})
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = flatten(lines, path+"."+k, v[k])
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return append(lines, line{path, "[]"})
+		}
+		for i, item := range v {
+			lines = flatten(lines, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case string:
+		lines = append(lines, line{path, v})
+	default:
+		data, _ := json.Marshal(v)
+		lines = append(lines, line{path, string(data)})
+	}
+	return lines
+}
+
+func sorted(files []string) []string {
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+	return files
+}
+
+// Diff returns the lines of plan compared with the one of previous deployment, unchanged lines included.
+// All lines are added if previous is nil. Lines are merged in the order of both plans.
+func (p Plan) Diff(previous *Record) []Change {
+	current := p.lines()
+	if previous == nil {
+		changes := make([]Change, 0, len(current))
+		for _, l := range current {
+			changes = append(changes, Change{Op: "+", Path: l.path, New: l.value})
+		}
+		return changes
+	}
+
+	digests := secretDigests(previous.key, p.secrets)
+	old := previous.Plan.lines()
+	oldValues := make(map[string]string, len(old))
+	for _, l := range old {
+		oldValues[l.path] = l.value
+	}
+	newValues := make(map[string]string, len(current))
+	for _, l := range current {
+		newValues[l.path] = l.value
+	}
+
+	changes := make([]Change, 0, len(current))
+	i, j := 0, 0
+	for i < len(old) || j < len(current) {
+		_, kept := newValues[pathAt(old, i)]
+		_, existed := oldValues[pathAt(current, j)]
+		switch {
+		// adjacent removed and added lines are in the order of path
+		case i < len(old) && !kept && (j == len(current) || existed || old[i].path < current[j].path):
+			changes = append(changes, Change{Op: "-", Path: old[i].path, Old: old[i].value})
+			i++
+		case j < len(current) && !existed:
+			changes = append(changes, Change{Op: "+", Path: current[j].path, New: current[j].value})
+			j++
+		case j < len(current):
+			l := current[j]
+			oldValue := oldValues[l.path]
+			op := ""
+			if oldValue != l.value || digests[l.path] != previous.Secrets[l.path] {
+				op = "~"
+			}
+			changes = append(changes, Change{Op: op, Path: l.path, Old: oldValue, New: l.value})
+			j++
+			// skip the kept line in old, which is compared already
+			if i < len(old) && old[i].path == l.path {
+				i++
+			}
+		default:
+			// kept lines of old compared in another order
+			i++
+		}
+	}
+	return changes
+}
+
+func pathAt(lines []line, i int) string {
+	if i < len(lines) {
+		return lines[i].path
+	}
+	return ""
+}
+
+// recordPath returns the path of the record of project in channel under dir
+func recordPath(dir, project, channel string) string {
+	return filepath.Join(dir, filepath.Base(project), filepath.Base(channel)+".json")
+}
+
+// LoadRecord returns the record of the last deployment of project in channel under dir, nil if not recorded
+func LoadRecord(dir, project, channel string) (*Record, error) {
+	content, err := ioutil.ReadFile(recordPath(dir, project, channel))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, err
+	}
+	if record.key, err = secretKey(dir, false); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveRecord saves record as the last deployment of its project and channel under dir
+func SaveRecord(dir string, record Record) error {
+	key, err := secretKey(dir, true)
+	if err != nil {
+		return err
+	}
+	record.Secrets = secretDigests(key, record.Plan.secrets)
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := recordPath(dir, record.Plan.Name, record.Plan.Channel)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	// readable by the user only, as the digests of secrets are saved
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// secretKey returns the random key of records under dir to digest secrets, generated once per machine.
+// nil is returned if it's not generated and create is false.
+func secretKey(dir string, create bool) ([]byte, error) {
+	path := filepath.Join(dir, "secret.key")
+	content, err := ioutil.ReadFile(path)
+	if !os.IsNotExist(err) {
+		return content, err
+	}
+	if !create {
+		return nil, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return key, ioutil.WriteFile(path, key, 0600)
+}
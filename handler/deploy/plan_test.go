@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/let-sh/cli/handler/build"
+	"github.com/let-sh/cli/handler/env"
+	"github.com/let-sh/cli/types"
+)
+
+func testContext(token string, static ...string) (*DeployContext, FileList) {
+	c := &DeployContext{LetConfig: types.LetConfig{
+		Name:   "site",
+		Type:   "react",
+		Static: "dist",
+		Env:    map[string]string{"TOKEN": token},
+	}}
+	c.BuildStep = build.Step{Commands: []string{"npm run build"}}
+	return c, FileList{StaticDir: "dist", Static: static, StaticSize: int64(len(static) * 1024)}
+}
+
+func changedLines(changes []Change) []Change {
+	var changed []Change
+	for _, c := range changes {
+		if c.Op != "" {
+			changed = append(changed, c)
+		}
+	}
+	return changed
+}
+
+func TestPlanDiff(t *testing.T) {
+	c, files := testContext("secret", "index.html", "old.js")
+	dir, err := ioutil.TempDir("", "records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := SaveRecord(dir, Record{ID: "d1", DeployedAt: time.Now(), Plan: NewPlan(c, "dev", files)}); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := LoadRecord(dir, "site", "dev")
+	if err != nil || previous == nil || previous.ID != "d1" {
+		t.Fatalf("LoadRecord = %+v, %v", previous, err)
+	}
+	if previous.Plan.Config.Env["TOKEN"] != env.Mask {
+		t.Errorf("recorded env = %v, want masked", previous.Plan.Config.Env)
+	}
+	// secrets are digested with the random key of dir, unlike a plain hash of them
+	sum := sha256.Sum256([]byte("secret"))
+	if digest := previous.Secrets["config.env.TOKEN"]; digest == "" || digest == hex.EncodeToString(sum[:]) {
+		t.Errorf("recorded secret digest = %q", digest)
+	}
+	if missing, err := LoadRecord(dir, "site", "prod"); err != nil || missing != nil {
+		t.Errorf("LoadRecord of another channel = %+v, %v, want nil", missing, err)
+	}
+
+	// the same plan has no changes
+	if changed := changedLines(NewPlan(c, "dev", files).Diff(previous)); changed != nil {
+		t.Errorf("changes of the same plan = %v", changed)
+	}
+
+	c, files = testContext("rotated", "index.html", "new.js")
+	c.Build = &types.BuildConfig{Command: "npm ci && npm run build"}
+	c.BuildStep.Commands = []string{c.Build.Command}
+	got := changedLines(NewPlan(c, "dev", files).Diff(previous))
+	want := []Change{
+		{Op: "~", Path: "build_commands[0]", Old: "npm run build", New: "npm ci && npm run build"},
+		{Op: "+", Path: "config.build.command", New: "npm ci && npm run build"},
+		{Op: "~", Path: "config.env.TOKEN", Old: env.Mask, New: env.Mask},
+		{Op: "+", Path: "files.static/new.js"},
+		{Op: "-", Path: "files.static/old.js"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %+v\nwant %+v", got, want)
+	}
+
+	// everything is added without previous deployment
+	for _, change := range NewPlan(c, "dev", files).Diff(nil) {
+		if change.Op != "+" {
+			t.Errorf("change without previous = %+v, want added", change)
+		}
+	}
+}
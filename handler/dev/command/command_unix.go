@@ -4,7 +4,6 @@ package command
 
 import (
 	"github.com/creack/pty"
-	"github.com/let-sh/cli/log"
 	"github.com/logrusorgru/aurora"
 	"github.com/segmentio/textio"
 	"io"
@@ -12,13 +11,12 @@ import (
 	"os/exec"
 )
 
-func RunCmd(cmd *exec.Cmd) {
+func RunCmd(cmd *exec.Cmd) error {
 
 	// Start the command with a pty.
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
-		log.Error(err)
-		return
+		return err
 	}
 
 	// Make sure to close the pty at the end.
@@ -51,7 +49,7 @@ func RunCmd(cmd *exec.Cmd) {
 	go func() {
 		_, _ = io.Copy(ptmx, os.Stdin)
 	}()
-	copyIndent(os.Stdout, ptmx)
+	return copyIndent(os.Stdout, ptmx)
 }
 
 func copyIndent(w io.Writer, r io.Reader) error {
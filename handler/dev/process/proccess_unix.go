@@ -3,7 +3,7 @@
 package process
 
 import (
-	"github.com/let-sh/cli/log"
+	"github.com/sirupsen/logrus"
 	"github.com/shirou/gopsutil/v3/process"
 	"os/exec"
 	"strconv"
@@ -24,7 +24,7 @@ func GetPortByProcessID(pid int) []int {
 		split := strings.Split(spaces[8], ":")
 		port, err := strconv.Atoi(split[1])
 		if err != nil {
-			log.Error(err)
+			logrus.Debug("parse port: ", err)
 			return ports
 		}
 		ports = append(ports, port)
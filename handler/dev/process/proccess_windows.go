@@ -5,7 +5,7 @@ package process
 import (
 	"bytes"
 	"fmt"
-	"github.com/let-sh/cli/log"
+	"github.com/sirupsen/logrus"
 	"github.com/shirou/gopsutil/v3/process"
 	"io"
 	"os/exec"
@@ -51,7 +51,7 @@ func GetPortByProcessID(pid int) []int {
 		split := strings.Split(spaces[2], ":")
 		port, err := strconv.Atoi(split[1])
 		if err != nil {
-			log.Error(err)
+			logrus.Debug("parse port: ", err)
 			return ports
 		}
 		ports = append(ports, port)
@@ -0,0 +1,217 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// modes are the suffixes of env files by channel, as the common NODE_ENV
+var modes = map[string]string{
+	"prod": "production",
+	"dev":  "development",
+}
+
+// Files returns the names of env files loaded for channel, from the lowest precedence to the highest:
+// .env and .env.<mode>, where mode is production or development. The local ones, .env.local and
+// .env.<mode>.local, follow if local is set. They are for local development only and never deployed,
+// since secrets of dev channel are pulled to .env.local.
+func Files(channel string, local bool) []string {
+	files := []string{".env"}
+	mode, ok := modes[channel]
+	if ok {
+		files = append(files, ".env."+mode)
+	}
+	if local {
+		files = append(files, LocalFile)
+		if ok {
+			files = append(files, ".env."+mode+".local")
+		}
+	}
+	return files
+}
+
+// ChannelSpecific reports whether dir has deployed env files of any channel, so the channel should be resolved first
+func ChannelSpecific(dir string) bool {
+	for _, mode := range modes {
+		if _, err := os.Stat(filepath.Join(dir, ".env."+mode)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the env files of channel under dir which exist, the local ones included if local is set,
+// then extra files which must exist. Later files override the former ones, ${VAR} and $VAR refer
+// the variables loaded before, or the environment of cli if not loaded. The environment of cli is kept as is.
+func Load(dir, channel string, local bool, extra []string) (map[string]string, error) {
+	var files []string
+	for _, name := range Files(channel, local) {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	files = append(files, extra...)
+
+	env := map[string]string{}
+	for _, file := range files {
+		if err := loadFile(file, env); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+func loadFile(file string, env map[string]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := Parse(f, env); err != nil {
+		return fmt.Errorf("%s:%w", file, err)
+	}
+	return nil
+}
+
+// Parse reads the variables of dotenv content into env, the variables already in env could be referred.
+// Values could be single quoted as is, or double quoted with escapes like \n, both could span lines.
+func Parse(r io.Reader, env map[string]string) error {
+	resolve := func(key string) string {
+		if v, ok := env[key]; ok {
+			return v
+		}
+		return os.Getenv(key)
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		start := line
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return fmt.Errorf("%d: expect KEY=VALUE, got %q", start, text)
+		}
+		key := strings.TrimSpace(text[:i])
+		if !ValidKey(key) {
+			return fmt.Errorf("%d: invalid key %q", start, key)
+		}
+		raw := strings.TrimLeft(text[i+1:], " \t")
+
+		var value string
+		switch quote := firstByte(raw); quote {
+		case '"', '\'':
+			// quoted values end at the closing quote, which could be on the following lines
+			body := raw[1:]
+			end := closingQuote(body, quote)
+			for end < 0 && scanner.Scan() {
+				line++
+				body += "\n" + scanner.Text()
+				end = closingQuote(body, quote)
+			}
+			if end < 0 {
+				return fmt.Errorf("%d: unterminated quoted value of %s", start, key)
+			}
+			if rest := strings.TrimSpace(body[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return fmt.Errorf("%d: unexpected %q after quoted value of %s", start, rest, key)
+			}
+			value = body[:end]
+			if quote == '"' {
+				value = expand(value, true, resolve)
+			}
+		default:
+			// inline comments start with # after spaces
+			for j := 1; j < len(raw); j++ {
+				if raw[j] == '#' && (raw[j-1] == ' ' || raw[j-1] == '\t') {
+					raw = raw[:j]
+					break
+				}
+			}
+			value = expand(strings.TrimSpace(raw), false, resolve)
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%d: %w", line, err)
+	}
+	return nil
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+// closingQuote returns the index of the closing quote in s, escaped ones are skipped in double quotes
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// expand replaces ${VAR} and $VAR by resolve, \$ is a literal $.
+// Escapes like \n and \" are unescaped in double quoted values.
+func expand(s string, quoted bool, resolve func(key string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (quoted || s[i+1] == '$'):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 || !ValidKey(s[i+2:i+end]) {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(resolve(s[i+2 : i+end]))
+			i += end
+		case c == '$':
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || isAlnum(s[end])) {
+				end++
+			}
+			if !ValidKey(s[i+1 : end]) {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(resolve(s[i+1 : end]))
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,131 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	os.Setenv("LETS_TEST_HOME", "/home/let")
+	defer os.Unsetenv("LETS_TEST_HOME")
+
+	content := `# comment
+export HOST=example.com
+PORT = 8080 # inline comment
+URL=https://${HOST}:$PORT/path#anchor
+SINGLE='${HOST} \n'
+DOUBLE="say \"hi\"\tto ${HOST}\n"
+ESCAPED=\$HOST costs $5
+MULTILINE="line 1
+line 2"
+HOME_DIR=${LETS_TEST_HOME}/app
+MISSING=${LETS_TEST_MISSING}
+EMPTY=
+`
+	env := map[string]string{"HOST": "localhost"}
+	if err := Parse(strings.NewReader(content), env); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"HOST":      "example.com",
+		"PORT":      "8080",
+		"URL":       "https://example.com:8080/path#anchor",
+		"SINGLE":    `${HOST} \n`,
+		"DOUBLE":    "say \"hi\"\tto example.com\n",
+		"ESCAPED":   "$HOST costs $5",
+		"MULTILINE": "line 1\nline 2",
+		"HOME_DIR":  "/home/let/app",
+		"MISSING":   "",
+		"EMPTY":     "",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("parsed = %q\nwant %q", env, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"A=1\nB\n":             "2: expect KEY=VALUE",
+		"1A=1\n":               `1: invalid key "1A"`,
+		"A=1\nB=\"open\nC=2\n": "2: unterminated quoted value of B",
+		"A='x' y\n":            `1: unexpected "y" after quoted value of A`,
+	} {
+		err := Parse(strings.NewReader(content), map[string]string{})
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("error of %q = %v, want %s", content, err, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		".env":                   "HOST=example.com\nAPI=https://api.${HOST}\nLEVEL=env\n",
+		".env.production":        "HOST=prod.example.com\nLEVEL=production\n",
+		".env.development":       "LEVEL=development\n",
+		".env.local":             "LEVEL=local\nURL=https://${HOST}\n",
+		".env.production.local":  "LEVEL=production.local\n",
+		"custom.env":             "LEVEL=custom\n",
+		".env.development.local": "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range []struct {
+		channel string
+		local   bool
+		extra   []string
+		want    map[string]string
+	}{
+		{"prod", true, nil, map[string]string{"HOST": "prod.example.com", "API": "https://api.example.com",
+			"LEVEL": "production.local", "URL": "https://prod.example.com"}},
+		{"dev", true, nil, map[string]string{"HOST": "example.com", "API": "https://api.example.com",
+			"LEVEL": "local", "URL": "https://example.com"}},
+		{"", true, []string{filepath.Join(dir, "custom.env")}, map[string]string{"HOST": "example.com",
+			"API": "https://api.example.com", "LEVEL": "custom", "URL": "https://example.com"}},
+		// local env files are not loaded for deploy
+		{"prod", false, nil, map[string]string{"HOST": "prod.example.com", "API": "https://api.example.com",
+			"LEVEL": "production"}},
+	} {
+		got, err := Load(dir, c.channel, c.local, c.extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("variables of %q, local %v = %v, want %v", c.channel, c.local, got, c.want)
+		}
+	}
+
+	if _, err := Load(dir, "dev", true, []string{filepath.Join(dir, "missing.env")}); err == nil {
+		t.Error("load missing env file should fail")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".env.local"), []byte("A=1\nbad line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(dir, "dev", true, nil)
+	if want := filepath.Join(dir, ".env.local") + ":2:"; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+	if !ChannelSpecific(dir) {
+		t.Error("dir with .env.production should be channel specific")
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	got := Environ([]string{"PATH=/bin", "TOKEN=old", "EMPTY="}, map[string]string{"TOKEN": "new", "A": "1"})
+	if want := []string{"PATH=/bin", "EMPTY=", "A=1", "TOKEN=new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("environ = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+// Package env resolves, masks and writes environment variables of project,
+// the ones stored by let.sh are scoped to project or one of its channels.
+package env
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+)
+
+// Mask is shown instead of values, the length of values is not revealed either
+const Mask = "********"
+
+// LocalFile is the file pulled for local development
+const LocalFile = ".env.local"
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidKey reports whether key is a valid name of environment variable
+func ValidKey(key string) bool {
+	return validKey.MatchString(key)
+}
+
+// Masked returns a copy of env with values masked
+func Masked(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(env))
+	for k, v := range env {
+		masked[k] = Mask
+		if v == "" {
+			masked[k] = ""
+		}
+	}
+	return masked
+}
+
+// MaskedConfig returns a copy of c with values of env masked, including the ones of build and environments
+func MaskedConfig(c types.LetConfig) types.LetConfig {
+	c.Env = Masked(c.Env)
+	if c.Build != nil {
+		build := *c.Build
+		build.Env = Masked(build.Env)
+		c.Build = &build
+	}
+	if c.Environments != nil {
+		environments := make(map[string]types.LetConfig, len(c.Environments))
+		for channel, e := range c.Environments {
+			environments[channel] = MaskedConfig(e)
+		}
+		c.Environments = environments
+	}
+	return c
+}
+
+// Resolve returns the variables of channel, the ones of channel override the ones of project
+func Resolve(vars []graphql.Env, channel string) map[string]string {
+	env := map[string]string{}
+	for _, v := range vars {
+		if v.Channel == "" {
+			env[v.Key] = v.Value
+		}
+	}
+	for _, v := range vars {
+		if v.Channel != "" && v.Channel == channel {
+			env[v.Key] = v.Value
+		}
+	}
+	return env
+}
+
+// Sort sorts vars by key, the ones of project go before the ones of channels
+func Sort(vars []graphql.Env) {
+	sort.SliceStable(vars, func(i, j int) bool {
+		if vars[i].Key != vars[j].Key {
+			return vars[i].Key < vars[j].Key
+		}
+		return vars[i].Channel < vars[j].Channel
+	})
+}
+
+// WriteFile writes env to file in dotenv format, readable by owner only since values are secrets
+func WriteFile(file, header string, env map[string]string) error {
+	content, err := godotenv.Marshal(env)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		fmt.Fprintf(&b, "# %s\n", line)
+	}
+	b.WriteString(content)
+	if content != "" {
+		b.WriteString("\n")
+	}
+	return ioutil.WriteFile(file, []byte(b.String()), 0600)
+}
+
+// Environ returns base, the environment like os.Environ, with the variables of env set
+func Environ(base []string, env map[string]string) []string {
+	result := make([]string, 0, len(base)+len(env))
+	for _, kv := range base {
+		if _, ok := env[strings.SplitN(kv, "=", 2)[0]]; !ok {
+			result = append(result, kv)
+		}
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result = append(result, k+"="+env[k])
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/let-sh/cli/requests/graphql"
+	"github.com/let-sh/cli/types"
+)
+
+func TestResolve(t *testing.T) {
+	vars := []graphql.Env{
+		{Key: "API", Value: "https://api.example.com", Channel: "prod"},
+		{Key: "API", Value: "https://api.dev.example.com"},
+		{Key: "TOKEN", Value: "secret"},
+		{Key: "DEBUG", Value: "1", Channel: "dev"},
+	}
+	for channel, want := range map[string]map[string]string{
+		"prod": {"API": "https://api.example.com", "TOKEN": "secret"},
+		"dev":  {"API": "https://api.dev.example.com", "TOKEN": "secret", "DEBUG": "1"},
+		"":     {"API": "https://api.dev.example.com", "TOKEN": "secret"},
+	} {
+		if got := Resolve(vars, channel); !reflect.DeepEqual(got, want) {
+			t.Errorf("variables of %q = %v, want %v", channel, got, want)
+		}
+	}
+
+	Sort(vars)
+	var got []string
+	for _, v := range vars {
+		got = append(got, v.Key+"@"+v.Channel)
+	}
+	if want := []string{"API@", "API@prod", "DEBUG@dev", "TOKEN@"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted = %v, want %v", got, want)
+	}
+}
+
+func TestMaskedConfig(t *testing.T) {
+	c := types.LetConfig{
+		Name:  "site",
+		Env:   map[string]string{"TOKEN": "secret", "EMPTY": ""},
+		Build: &types.BuildConfig{Env: map[string]string{"NPM_TOKEN": "npm-secret"}},
+		Environments: map[string]types.LetConfig{
+			"prod": {Env: map[string]string{"TOKEN": "prod-secret"}},
+		},
+	}
+	masked := MaskedConfig(c)
+	if want := map[string]string{"TOKEN": Mask, "EMPTY": ""}; !reflect.DeepEqual(masked.Env, want) {
+		t.Errorf("masked env = %v, want %v", masked.Env, want)
+	}
+	if masked.Environments["prod"].Env["TOKEN"] != Mask || masked.Name != "site" {
+		t.Errorf("masked config = %+v", masked)
+	}
+	if masked.Build.Env["NPM_TOKEN"] != Mask {
+		t.Errorf("masked build env = %v", masked.Build.Env)
+	}
+	if c.Build.Env["NPM_TOKEN"] != "npm-secret" || c.Env["TOKEN"] != "secret" || c.Environments["prod"].Env["TOKEN"] != "prod-secret" {
+		t.Errorf("config modified by masking: %+v", c)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, LocalFile)
+	env := map[string]string{"TOKEN": `se"cr$ET!`, "MULTILINE": "a\nb", "URL": "https://example.com?a=1#b"}
+	if err := WriteFile(file, "pulled\nkeep it secret", env); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "# pulled\n# keep it secret\n") {
+		t.Errorf("content = %q, want header comments", content)
+	}
+	got, err := godotenv.Read(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, env) {
+		t.Errorf("read back %v, want %v", got, env)
+	}
+	if got, err = Load(dir, "dev", true, nil); err != nil || !reflect.DeepEqual(got, env) {
+		t.Errorf("loaded %v, %v, want %v", got, err, env)
+	}
+}
@@ -0,0 +1,158 @@
+// Package routing validates the redirects, rewrites and headers of let.json,
+// and serves static files with them as let.sh does, for local development.
+package routing
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	paramName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	paramReference = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Pattern is a compiled source of redirect or rewrite like /blog/:slug or /docs/*
+type Pattern struct {
+	Source   string
+	segments []segment
+	// rest is the param of the trailing wildcard, "*" if anonymous, empty without wildcard
+	rest string
+}
+
+type segment struct {
+	literal string
+	// param matches a segment if not empty
+	param string
+}
+
+// Compile parses source, a path where :name matches a segment and a trailing * or :name* matches the rest
+func Compile(source string) (*Pattern, error) {
+	if !strings.HasPrefix(source, "/") {
+		return nil, fmt.Errorf("source %q should start with /", source)
+	}
+
+	p := &Pattern{Source: source}
+	params := map[string]bool{}
+	parts := splitPath(source)
+	for i, part := range parts {
+		name := ""
+		switch {
+		case part == "*":
+			name = "*"
+		case strings.HasPrefix(part, ":"):
+			name = strings.TrimPrefix(part, ":")
+		case strings.Contains(part, "*") || strings.Contains(part, ":"):
+			return nil, fmt.Errorf("invalid segment %q, params and wildcards should be whole segments", part)
+		default:
+			p.segments = append(p.segments, segment{literal: part})
+			continue
+		}
+
+		wildcard := strings.HasSuffix(name, "*")
+		if wildcard && i != len(parts)-1 {
+			return nil, fmt.Errorf("wildcard %q should be the last segment", part)
+		}
+		if name != "*" {
+			name = strings.TrimSuffix(name, "*")
+			if !paramName.MatchString(name) {
+				return nil, fmt.Errorf("invalid param name %q", part)
+			}
+			if params[name] {
+				return nil, fmt.Errorf("duplicate param %q", name)
+			}
+			params[name] = true
+		}
+		if wildcard {
+			p.rest = name
+		} else {
+			p.segments = append(p.segments, segment{param: name})
+		}
+	}
+	return p, nil
+}
+
+// key is the source with params unnamed, patterns of the same key match the same paths
+func (p *Pattern) key() string {
+	var b strings.Builder
+	for _, s := range p.segments {
+		if s.param != "" {
+			b.WriteString("/:")
+		} else {
+			b.WriteString("/" + s.literal)
+		}
+	}
+	if p.rest != "" {
+		b.WriteString("/*")
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// Params returns the names of params referable by destination
+func (p *Pattern) Params() []string {
+	var names []string
+	for _, s := range p.segments {
+		if s.param != "" {
+			names = append(names, s.param)
+		}
+	}
+	if p.rest != "" && p.rest != "*" {
+		names = append(names, p.rest)
+	}
+	return names
+}
+
+// Match returns the params if urlPath matches the pattern
+func (p *Pattern) Match(urlPath string) (map[string]string, bool) {
+	parts := splitPath(urlPath)
+	if len(parts) < len(p.segments) || len(parts) > len(p.segments) && p.rest == "" {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, s := range p.segments {
+		switch {
+		case s.param != "":
+			params[s.param] = parts[i]
+		case s.literal != parts[i]:
+			return nil, false
+		}
+	}
+	if p.rest != "" {
+		params[p.rest] = strings.Join(parts[len(p.segments):], "/")
+	}
+	return params, true
+}
+
+// Expand replaces the params referred by :name in destination
+func Expand(destination string, params map[string]string) string {
+	return paramReference.ReplaceAllStringFunc(destination, func(ref string) string {
+		if v, ok := params[ref[1:]]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// references returns the param names referred in destination
+func references(destination string) []string {
+	var names []string
+	for _, m := range paramReference.FindAllStringSubmatch(destination, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// splitPath returns the segments of cleaned path, the root has none
+func splitPath(p string) []string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
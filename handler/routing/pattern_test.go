@@ -0,0 +1,52 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPattern(t *testing.T) {
+	for _, c := range []struct {
+		source, path string
+		params       map[string]string
+	}{
+		{"/", "/", map[string]string{}},
+		{"/about", "/about/", map[string]string{}},
+		{"/about", "/contact", nil},
+		{"/blog/:slug", "/blog/hello", map[string]string{"slug": "hello"}},
+		{"/blog/:slug", "/blog/hello/world", nil},
+		{"/blog/:slug", "/blog", nil},
+		{"/docs/*", "/docs", map[string]string{"*": ""}},
+		{"/docs/:path*", "/docs/a/b", map[string]string{"path": "a/b"}},
+		{"/:lang/docs/*", "/en/docs/a", map[string]string{"lang": "en", "*": "a"}},
+	} {
+		p, err := Compile(c.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, ok := p.Match(c.path)
+		if ok != (c.params != nil) || ok && !reflect.DeepEqual(params, c.params) {
+			t.Errorf("%s match %s = %v, %v, want %v", c.source, c.path, params, ok, c.params)
+		}
+	}
+
+	for _, source := range []string{"blog", "/docs/*/a", "/a*b", "/:1st", "/:id/:id", "/v:version"} {
+		if _, err := Compile(source); err == nil {
+			t.Errorf("compile %q should fail", source)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	params := map[string]string{"slug": "hello", "path": "a/b"}
+	for destination, want := range map[string]string{
+		"/posts/:slug":                   "/posts/hello",
+		"/new/:path?from=:slug":          "/new/a/b?from=hello",
+		"https://example.com:8080/:slug": "https://example.com:8080/hello",
+		"/keep/:unknown":                 "/keep/:unknown",
+	} {
+		if got := Expand(destination, params); got != want {
+			t.Errorf("Expand(%q) = %q, want %q", destination, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/ignore"
+)
+
+// Server serves static files of Dir routed as let.sh does:
+// redirects first, then existing files, then rewrites, headers are set by the served file.
+type Server struct {
+	Dir       string
+	redirects []route
+	rewrites  []route
+	headers   []headerRule
+	files     http.Handler
+}
+
+type route struct {
+	pattern     *Pattern
+	destination string
+	status      int
+}
+
+type headerRule struct {
+	re      *regexp.Regexp
+	headers map[string]string
+}
+
+// NewServer returns the server of dir, the config should be valid, see Validate
+func NewServer(dir string, c types.LetConfig) (*Server, error) {
+	s := &Server{Dir: dir, files: http.FileServer(http.Dir(dir))}
+	for i, r := range c.Redirect {
+		p, err := Compile(r.Source)
+		if err != nil {
+			return nil, fmt.Errorf("redirects[%d]: %w", i, err)
+		}
+		status := r.Type
+		if status == 0 {
+			status = DefaultRedirectType
+		}
+		s.redirects = append(s.redirects, route{pattern: p, destination: r.Destination, status: status})
+	}
+	for i, r := range c.Rewrite {
+		p, err := Compile(r.Source)
+		if err != nil {
+			return nil, fmt.Errorf("rewrites[%d]: %w", i, err)
+		}
+		s.rewrites = append(s.rewrites, route{pattern: p, destination: r.Destination})
+	}
+	for i, r := range c.Headers {
+		re, err := ignore.Glob(r.Source)
+		if err != nil {
+			return nil, fmt.Errorf("headers[%d]: %w", i, err)
+		}
+		s.headers = append(s.headers, headerRule{re: re, headers: r.Headers})
+	}
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Path
+	if rt, params, ok := match(s.redirects, urlPath); ok {
+		destination := Expand(rt.destination, params)
+		if r.URL.RawQuery != "" && !strings.Contains(destination, "?") {
+			destination += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, destination, rt.status)
+		return
+	}
+
+	rewritten := false
+	if !s.exists(urlPath) {
+		if rt, params, ok := match(s.rewrites, urlPath); ok {
+			urlPath = pathOf(Expand(rt.destination, params))
+			rewritten = true
+		}
+	}
+
+	key := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if fi, err := os.Stat(filepath.Join(s.Dir, filepath.FromSlash(key))); err == nil && fi.IsDir() {
+		key = path.Join(key, "index.html")
+	}
+	for _, rule := range s.headers {
+		if !rule.re.MatchString(key) {
+			continue
+		}
+		for k, v := range rule.headers {
+			if v == "" {
+				w.Header().Del(k)
+			} else {
+				w.Header().Set(k, v)
+			}
+		}
+	}
+
+	if rewritten {
+		// file server redirects requests of index.html, the url should be kept
+		s.serveFile(w, r, key)
+		return
+	}
+	s.files.ServeHTTP(w, r)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, key string) {
+	f, err := os.Open(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+}
+
+// exists reports whether urlPath is a file or a dir with index.html
+func (s *Server) exists(urlPath string) bool {
+	name := filepath.Join(s.Dir, filepath.FromSlash(path.Clean("/"+urlPath)))
+	fi, err := os.Stat(name)
+	if err == nil && fi.IsDir() {
+		fi, err = os.Stat(filepath.Join(name, "index.html"))
+	}
+	return err == nil && !fi.IsDir()
+}
+
+// match returns the first route matching urlPath
+func match(routes []route, urlPath string) (route, map[string]string, bool) {
+	for _, rt := range routes {
+		if params, ok := rt.pattern.Match(urlPath); ok {
+			return rt, params, true
+		}
+	}
+	return route{}, nil, false
+}
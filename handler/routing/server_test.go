@@ -0,0 +1,63 @@
+package routing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/let-sh/cli/types"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":       "home",
+		"about/index.html": "about",
+		"app.js":           "app",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := NewServer(dir, types.LetConfig{
+		Redirect: []types.RedirectRule{
+			{Source: "/blog/:slug", Destination: "/posts/:slug", Type: 302},
+			{Source: "/app.js", Destination: "/moved.js"},
+		},
+		Rewrite: []types.RewriteRule{{Source: "/*", Destination: "/index.html"}},
+		Headers: []types.HeaderRule{
+			{Source: "*.html", Headers: map[string]string{"X-Frame-Options": "DENY"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		path     string
+		status   int
+		location string
+		body     string
+		frame    string
+	}{
+		{"/blog/hello?ref=home", http.StatusFound, "/posts/hello?ref=home", "", ""},
+		// redirects go before existing files
+		{"/app.js", http.StatusMovedPermanently, "/moved.js", "", ""},
+		{"/about/", http.StatusOK, "", "about", "DENY"},
+		// missing files are rewritten
+		{"/dashboard/settings", http.StatusOK, "", "home", "DENY"},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+		if w.Code != c.status || w.Header().Get("Location") != c.location ||
+			c.body != "" && w.Body.String() != c.body || w.Header().Get("X-Frame-Options") != c.frame {
+			t.Errorf("GET %s = %d %v %q", c.path, w.Code, w.Header(), w.Body.String())
+		}
+	}
+}
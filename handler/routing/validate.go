@@ -0,0 +1,199 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/ignore"
+)
+
+// DefaultRedirectType is the status code of redirects without type
+const DefaultRedirectType = http.StatusMovedPermanently
+
+var redirectTypes = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+// Problem is a semantic problem of config, Path is the json path of the field, e.g. redirects[0].source
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// Validate checks the patterns, destinations, status codes and headers of redirects, rewrites and headers,
+// duplicate sources and redirect loops are reported too.
+func Validate(c types.LetConfig) []Problem {
+	var problems []Problem
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// sources are routed by the first matched rule, the later duplicates never match
+	sources := map[string]string{}
+	checkSource := func(field, source string) *Pattern {
+		p, err := Compile(source)
+		if err != nil {
+			report(field+".source", "%s", err)
+			return nil
+		}
+		key := p.key()
+		if previous, ok := sources[key]; ok {
+			report(field+".source", "duplicate source %q, already routed by %s", source, previous)
+		} else {
+			sources[key] = field
+		}
+		return p
+	}
+	checkDestination := func(field string, p *Pattern, destination string, external bool) {
+		if destination == "" {
+			report(field+".destination", "destination is required")
+			return
+		}
+		if !strings.HasPrefix(destination, "/") {
+			u, err := url.Parse(destination)
+			if !external || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				if external {
+					report(field+".destination", "destination %q should be a path or an http(s) url", destination)
+				} else {
+					report(field+".destination", "destination %q should be a path starting with /", destination)
+				}
+				return
+			}
+		}
+		if p == nil {
+			return
+		}
+		params := map[string]bool{}
+		for _, name := range p.Params() {
+			params[name] = true
+		}
+		for _, name := range references(destination) {
+			if !params[name] {
+				report(field+".destination", "param %q is not in source %q", name, p.Source)
+			}
+		}
+	}
+
+	var redirects []*Pattern
+	for i, r := range c.Redirect {
+		field := fmt.Sprintf("redirects[%d]", i)
+		p := checkSource(field, r.Source)
+		redirects = append(redirects, p)
+		checkDestination(field, p, r.Destination, true)
+		if r.Type != 0 && !redirectTypes[r.Type] {
+			report(field+".type", "invalid status code %d, expect one of 301, 302, 303, 307 and 308", r.Type)
+		}
+	}
+	for i, r := range c.Rewrite {
+		field := fmt.Sprintf("rewrites[%d]", i)
+		checkDestination(field, checkSource(field, r.Source), r.Destination, false)
+	}
+	for i, r := range c.Headers {
+		field := fmt.Sprintf("headers[%d]", i)
+		if _, err := ignore.Glob(r.Source); err != nil || r.Source == "" {
+			report(field+".source", "invalid glob %q", r.Source)
+		}
+		if len(r.Headers) == 0 {
+			report(field+".headers", "headers are required")
+		}
+		var names []string
+		for name := range r.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !validHeaderName(name) {
+				report(field+".headers", "invalid header name %q", name)
+			} else if strings.ContainsAny(r.Headers[name], "\r\n") {
+				report(field+".headers."+name, "header value should be a single line")
+			}
+		}
+	}
+
+	for i, loop := range redirectLoops(c.Redirect, redirects) {
+		if loop != "" {
+			report(fmt.Sprintf("redirects[%d]", i), "redirect loop: %s", loop)
+		}
+	}
+	return problems
+}
+
+// redirectLoops follows the redirects from the destination of each redirect,
+// returns the visited paths of the ones redirected by themselves again, indexed as redirects.
+func redirectLoops(rules []types.RedirectRule, patterns []*Pattern) []string {
+	loops := make([]string, len(rules))
+	for i, r := range rules {
+		if patterns[i] == nil || !strings.HasPrefix(r.Destination, "/") {
+			continue
+		}
+		// params are sampled by their names
+		sample := map[string]string{"*": "x"}
+		for _, name := range patterns[i].Params() {
+			sample[name] = name
+		}
+		current := pathOf(Expand(r.Destination, sample))
+		visited := []string{r.Source, current}
+		for step := 0; step < len(rules); step++ {
+			j, next := redirect(rules, patterns, current)
+			if j == i {
+				loops[i] = strings.Join(visited, " -> ")
+				break
+			}
+			if j < 0 || !strings.HasPrefix(next, "/") {
+				break
+			}
+			current = pathOf(next)
+			visited = append(visited, current)
+		}
+	}
+	return loops
+}
+
+// redirect returns the index and destination of the first redirect matching urlPath, -1 if none
+func redirect(rules []types.RedirectRule, patterns []*Pattern, urlPath string) (int, string) {
+	for i, p := range patterns {
+		if p == nil {
+			continue
+		}
+		if params, ok := p.Match(urlPath); ok {
+			return i, Expand(rules[i].Destination, params)
+		}
+	}
+	return -1, ""
+}
+
+// pathOf strips the query and fragment of destination
+func pathOf(destination string) string {
+	if i := strings.IndexAny(destination, "?#"); i >= 0 {
+		return destination[:i]
+	}
+	return destination
+}
+
+// validHeaderName reports whether name is a token of RFC 7230
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/let-sh/cli/types"
+)
+
+func TestValidate(t *testing.T) {
+	c := types.LetConfig{
+		Redirect: []types.RedirectRule{
+			{Source: "/old/:slug", Destination: "/new/:slug", Type: 308},
+			{Source: "/a", Destination: "/b"},
+			{Source: "/b", Destination: "/a"},
+			{Source: "/self/*", Destination: "/self/next"},
+			{Source: "/old/:id", Destination: "/x"},
+			{Source: "docs", Destination: "/docs/"},
+			{Source: "/external", Destination: "ftp://example.com", Type: 200},
+			{Source: "/missing/:id", Destination: "https://example.com/:slug"},
+		},
+		Rewrite: []types.RewriteRule{
+			{Source: "/*", Destination: "/index.html"},
+			{Source: "/api/*", Destination: "https://api.example.com"},
+		},
+		Headers: []types.HeaderRule{
+			{Source: "*.html", Headers: map[string]string{"X-Frame-Options": "DENY"}},
+			{Source: "[z-a]", Headers: map[string]string{"Bad Name": "1", "X-Multi": "a\nb"}},
+		},
+	}
+
+	var got []string
+	for _, p := range Validate(c) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`redirects[4].source: duplicate source "/old/:id", already routed by redirects[0]`,
+		`redirects[5].source: source "docs" should start with /`,
+		`redirects[6].destination: destination "ftp://example.com" should be a path or an http(s) url`,
+		`redirects[6].type: invalid status code 200, expect one of 301, 302, 303, 307 and 308`,
+		`redirects[7].destination: param "slug" is not in source "/missing/:id"`,
+		`rewrites[1].destination: destination "https://api.example.com" should be a path starting with /`,
+		`headers[1].source: invalid glob "[z-a]"`,
+		`headers[1].headers: invalid header name "Bad Name"`,
+		`headers[1].headers.X-Multi: header value should be a single line`,
+		`redirects[1]: redirect loop: /a -> /b -> /a`,
+		`redirects[2]: redirect loop: /b -> /a -> /b`,
+		`redirects[3]: redirect loop: /self/* -> /self/next`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems =\n%v\nwant\n%v", got, want)
+	}
+
+	if problems := Validate(types.LetConfig{Rewrite: c.Rewrite[:1]}); len(problems) != 0 {
+		t.Errorf("problems of valid config: %v", problems)
+	}
+}
@@ -0,0 +1,90 @@
+package workspace
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/let-sh/cli/log/errs"
+)
+
+// ChangedFiles returns the files changed since git ref base, including uncommitted and untracked ones,
+// paths are slash separated and relative to workspace root.
+func (w *Workspace) ChangedFiles(base string) ([]string, error) {
+	diff, err := w.git("diff", "--name-only", "--relative", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := w.git("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// Changed returns the packages changed since git ref base.
+// A package is changed if its own files changed, or any workspace package it depends on changed.
+// Changing a shared file, e.g. the lockfile, changes all packages.
+func (w *Workspace) Changed(base string) ([]Package, error) {
+	files, err := w.ChangedFiles(base)
+	if err != nil {
+		return nil, err
+	}
+	return w.changedBy(files), nil
+}
+
+func (w *Workspace) changedBy(files []string) []Package {
+	changed := map[string]bool{}
+	for _, f := range files {
+		for _, shared := range w.SharedFiles {
+			if f == shared {
+				return w.Packages
+			}
+		}
+		// files of nested packages belong to the innermost one, e.g. the root module of go.work has use .
+		owner := -1
+		for i, p := range w.Packages {
+			if Contains(p.Path, f) && (owner < 0 || len(p.Path) > len(w.Packages[owner].Path)) {
+				owner = i
+			}
+		}
+		if owner >= 0 {
+			changed[w.Packages[owner].Name] = true
+		}
+	}
+
+	var packages []Package
+	for _, p := range w.Packages {
+		if changed[p.Name] {
+			packages = append(packages, p)
+			continue
+		}
+		for _, dep := range w.Dependencies(p) {
+			if changed[dep.Name] {
+				packages = append(packages, p)
+				break
+			}
+		}
+	}
+	return packages
+}
+
+func (w *Workspace) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = w.Root
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", errs.Newf(errs.Validation, "git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
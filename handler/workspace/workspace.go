@@ -0,0 +1,432 @@
+package workspace
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/let-sh/cli/log/errs"
+	"github.com/rogpeppe/go-internal/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// kinds of workspace
+const (
+	KindPnpm = "pnpm"
+	KindYarn = "yarn"
+	KindNpm  = "npm"
+	KindGo   = "go"
+)
+
+// sharedFiles are the files under workspace root shared by all packages,
+// uploaded with every package so that dependencies resolve the same as local.
+var sharedFiles = []string{
+	"package.json", "pnpm-workspace.yaml", "pnpm-lock.yaml", "yarn.lock", ".yarnrc.yml",
+	"package-lock.json", "npm-shrinkwrap.json", ".npmrc", "bun.lockb",
+	"go.work", "go.work.sum",
+}
+
+// Package is a deployable package of workspace
+type Package struct {
+	// Name is the name in package.json, or the module path in go.mod
+	Name string `json:"name"`
+	// Dir is the absolute dir of package
+	Dir string `json:"dir"`
+	// Path is the slash separated path relative to workspace root
+	Path string `json:"path"`
+	// Requires are the names of workspace packages it depends on
+	Requires []string `json:"requires,omitempty"`
+}
+
+// Workspace is a monorepo of pnpm, yarn, npm or go workspace
+type Workspace struct {
+	Kind     string    `json:"kind"`
+	Root     string    `json:"root"`
+	Packages []Package `json:"packages"`
+	// SharedFiles are the existing shared files under root, e.g. lockfiles
+	SharedFiles []string `json:"sharedFiles"`
+}
+
+// Find returns the workspace contains dir, nil if dir is not under any workspace
+func Find(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		w, err := Load(dir)
+		if err != nil || w != nil {
+			return w, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load returns the workspace of root, nil if root has no workspace manifest
+func Load(root string) (*Workspace, error) {
+	w := &Workspace{Root: root}
+
+	var err error
+	switch {
+	case exists(root, "pnpm-workspace.yaml"):
+		w.Kind = KindPnpm
+		err = w.loadPnpm()
+	case exists(root, "package.json") && hasWorkspaces(root):
+		w.Kind = KindNpm
+		if exists(root, "yarn.lock") {
+			w.Kind = KindYarn
+		}
+		err = w.loadPackageJSON()
+	case exists(root, "go.work"):
+		w.Kind = KindGo
+		err = w.loadGoWork()
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errs.Wrap(errs.Validation, err)
+	}
+
+	for _, f := range sharedFiles {
+		if exists(root, f) {
+			w.SharedFiles = append(w.SharedFiles, f)
+		}
+	}
+	sort.Slice(w.Packages, func(i, j int) bool {
+		return w.Packages[i].Path < w.Packages[j].Path
+	})
+	return w, nil
+}
+
+func (w *Workspace) loadPnpm() error {
+	content, err := ioutil.ReadFile(filepath.Join(w.Root, "pnpm-workspace.yaml"))
+	if err != nil {
+		return err
+	}
+	var manifest struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return err
+	}
+	return w.loadNodePackages(manifest.Packages)
+}
+
+func (w *Workspace) loadPackageJSON() error {
+	patterns, err := readWorkspaces(w.Root)
+	if err != nil {
+		return err
+	}
+	return w.loadNodePackages(patterns)
+}
+
+func hasWorkspaces(root string) bool {
+	patterns, _ := readWorkspaces(root)
+	return len(patterns) > 0
+}
+
+// readWorkspaces reads the workspaces of package.json,
+// either an array or an object with packages like yarn
+func readWorkspaces(root string) ([]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(manifest.Workspaces, &patterns); err == nil {
+		return patterns, nil
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(manifest.Workspaces, &object); err != nil {
+		return nil, err
+	}
+	return object.Packages, nil
+}
+
+// loadNodePackages finds the dirs with package.json matches patterns,
+// patterns starts with "!" excludes dirs.
+func (w *Workspace) loadNodePackages(patterns []string) error {
+	var includes, excludes []string
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "./"), "/")
+		if strings.HasPrefix(p, "!") {
+			excludes = append(excludes, strings.TrimPrefix(strings.TrimPrefix(p, "!"), "./"))
+			continue
+		}
+		includes = append(includes, p)
+	}
+
+	err := filepath.Walk(w.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if name := info.Name(); name == "node_modules" || (strings.HasPrefix(name, ".") && p != w.Root) {
+			return filepath.SkipDir
+		}
+
+		rel, _ := filepath.Rel(w.Root, p)
+		rel = filepath.ToSlash(rel)
+		if rel == "." || !matchAny(includes, rel) || matchAny(excludes, rel) || !exists(p, "package.json") {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(p, "package.json"))
+		if err != nil {
+			return err
+		}
+		var manifest struct {
+			Name            string            `json:"name"`
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return err
+		}
+		pkg := Package{Name: manifest.Name, Dir: p, Path: rel}
+		if pkg.Name == "" {
+			pkg.Name = rel
+		}
+		for _, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+			for name := range deps {
+				pkg.Requires = append(pkg.Requires, name)
+			}
+		}
+		w.Packages = append(w.Packages, pkg)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.keepWorkspaceRequires()
+	return nil
+}
+
+func (w *Workspace) loadGoWork() error {
+	content, err := ioutil.ReadFile(filepath.Join(w.Root, "go.work"))
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range parseGoWorkUse(string(content)) {
+		p := filepath.Join(w.Root, filepath.FromSlash(dir))
+		mod, err := ioutil.ReadFile(filepath.Join(p, "go.mod"))
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(w.Root, p)
+		pkg := Package{Name: modfile.ModulePath(mod), Dir: p, Path: filepath.ToSlash(rel)}
+		if f, err := modfile.Parse("go.mod", mod, nil); err == nil {
+			for _, r := range f.Require {
+				pkg.Requires = append(pkg.Requires, r.Mod.Path)
+			}
+		}
+		w.Packages = append(w.Packages, pkg)
+	}
+	w.keepWorkspaceRequires()
+	return nil
+}
+
+// parseGoWorkUse returns the dirs of use directives in go.work
+func parseGoWorkUse(content string) (dirs []string) {
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			dirs = append(dirs, strings.Trim(fields[0], `"`))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "use" && len(fields) > 1:
+			dirs = append(dirs, strings.Trim(fields[1], `"`))
+		}
+	}
+	return dirs
+}
+
+// keepWorkspaceRequires drops the requires outside of workspace
+func (w *Workspace) keepWorkspaceRequires() {
+	names := map[string]bool{}
+	for _, p := range w.Packages {
+		names[p.Name] = true
+	}
+	for i := range w.Packages {
+		var requires []string
+		for _, r := range w.Packages[i].Requires {
+			if names[r] && r != w.Packages[i].Name {
+				requires = append(requires, r)
+			}
+		}
+		sort.Strings(requires)
+		w.Packages[i].Requires = requires
+	}
+}
+
+// Package returns the package named name
+func (w *Workspace) Package(name string) (Package, bool) {
+	for _, p := range w.Packages {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Package{}, false
+}
+
+// PackageOf returns the package contains dir
+func (w *Workspace) PackageOf(dir string) (Package, bool) {
+	dir, _ = filepath.Abs(dir)
+	var found Package
+	for _, p := range w.Packages {
+		if dir == p.Dir || strings.HasPrefix(dir, p.Dir+string(filepath.Separator)) {
+			// the innermost package wins
+			if len(p.Dir) > len(found.Dir) {
+				found = p
+			}
+		}
+	}
+	return found, found.Dir != ""
+}
+
+// Filter returns the packages match any pattern by name or path, e.g. web, @org/*, apps/*
+func (w *Workspace) Filter(patterns []string) ([]Package, error) {
+	var packages []Package
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/")
+		matched := false
+		for _, p := range w.Packages {
+			if matchPackage(pattern, p) {
+				matched = true
+				packages = appendPackage(packages, p)
+			}
+		}
+		if !matched {
+			return nil, errs.Newf(errs.Validation, "no package matches %s in workspace %s", pattern, w.Root)
+		}
+	}
+	return packages, nil
+}
+
+func matchPackage(pattern string, p Package) bool {
+	if pattern == p.Name || pattern == p.Path {
+		return true
+	}
+	if ok, _ := path.Match(pattern, p.Name); ok {
+		return true
+	}
+	return matchGlob(pattern, p.Path)
+}
+
+// Dependencies returns the workspace packages p depends on, transitively
+func (w *Workspace) Dependencies(p Package) []Package {
+	var deps []Package
+	visited := map[string]bool{p.Name: true}
+	queue := append([]string{}, p.Requires...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		if dep, ok := w.Package(name); ok {
+			deps = append(deps, dep)
+			queue = append(queue, dep.Requires...)
+		}
+	}
+	return deps
+}
+
+// UploadPaths returns the paths relative to root should be uploaded to deploy p:
+// the shared files, p itself and the workspace packages it depends on.
+func (w *Workspace) UploadPaths(p Package) []string {
+	paths := append([]string{}, w.SharedFiles...)
+	paths = append(paths, p.Path)
+	for _, dep := range w.Dependencies(p) {
+		paths = append(paths, dep.Path)
+	}
+	return paths
+}
+
+// Contains reports whether the slash separated path rel is dir or under it, both relative to workspace root.
+// Dir . is the root, which contains all paths.
+func Contains(dir, rel string) bool {
+	dir = path.Clean(dir)
+	return dir == "." || rel == dir || strings.HasPrefix(rel, dir+"/")
+}
+
+func appendPackage(packages []Package, p Package) []Package {
+	for _, existing := range packages {
+		if existing.Dir == p.Dir {
+			return packages
+		}
+	}
+	return append(packages, p)
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func matchAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches slash separated path p with pattern, "**" matches any number of dirs
+func matchGlob(pattern, p string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
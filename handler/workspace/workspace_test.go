@@ -0,0 +1,217 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func names(packages []Package) (names []string) {
+	for _, p := range packages {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+var pnpmFiles = map[string]string{
+	"package.json":                          `{"private":true}`,
+	"pnpm-workspace.yaml":                   "packages:\n  - 'apps/*'\n  - 'packages/**'\n  - '!**/test/**'\n",
+	"pnpm-lock.yaml":                        "lockfileVersion: 5.4\n",
+	"apps/web/package.json":                 `{"name":"@org/web","dependencies":{"next":"12","@org/ui":"workspace:*"}}`,
+	"apps/api/package.json":                 `{"name":"@org/api","dependencies":{"express":"4"}}`,
+	"packages/ui/package.json":              `{"name":"@org/ui","dependencies":{"@org/utils":"workspace:*"}}`,
+	"packages/shared/utils/package.json":    `{"name":"@org/utils"}`,
+	"packages/ui/test/fixture/package.json": `{"name":"fixture"}`,
+	"apps/web/node_modules/x/package.json":  `{"name":"x"}`,
+}
+
+func TestFindPnpm(t *testing.T) {
+	root := writeFiles(t, pnpmFiles)
+
+	w, err := Find(filepath.Join(root, "apps", "web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w == nil || w.Kind != KindPnpm {
+		t.Fatalf("workspace = %+v, want pnpm", w)
+	}
+
+	want := []string{"@org/api", "@org/web", "@org/utils", "@org/ui"}
+	if got := names(w.Packages); !reflect.DeepEqual(got, want) {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+
+	web, ok := w.PackageOf(filepath.Join(root, "apps", "web", "pages"))
+	if !ok || web.Name != "@org/web" {
+		t.Fatalf("package of apps/web/pages = %+v", web)
+	}
+	if got := names(w.Dependencies(web)); !reflect.DeepEqual(got, []string{"@org/ui", "@org/utils"}) {
+		t.Errorf("dependencies of web = %v", got)
+	}
+
+	wantPaths := []string{"package.json", "pnpm-workspace.yaml", "pnpm-lock.yaml", "apps/web", "packages/ui",
+		"packages/shared/utils"}
+	if got := w.UploadPaths(web); !reflect.DeepEqual(got, wantPaths) {
+		t.Errorf("upload paths = %v, want %v", got, wantPaths)
+	}
+}
+
+func TestFindNone(t *testing.T) {
+	root := writeFiles(t, map[string]string{"package.json": `{"name":"app"}`})
+	w, err := Find(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != nil {
+		t.Errorf("workspace = %+v, want nil", w)
+	}
+}
+
+func TestLoadYarnWorkspacesObject(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"package.json":            `{"private":true,"workspaces":{"packages":["packages/*"]}}`,
+		"yarn.lock":               "",
+		"packages/a/package.json": `{"name":"a"}`,
+	})
+	w, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Kind != KindYarn || !reflect.DeepEqual(names(w.Packages), []string{"a"}) {
+		t.Errorf("workspace = %+v", w)
+	}
+}
+
+func TestLoadGoWork(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"go.work":      "go 1.18\n\nuse (\n\t./api // service\n\t./lib\n)\nuse ./tools\n",
+		"api/go.mod":   "module example.com/api\n\nrequire example.com/lib v0.0.0\n",
+		"lib/go.mod":   "module example.com/lib\n",
+		"tools/go.mod": "module example.com/tools\n",
+	})
+	w, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Kind != KindGo {
+		t.Fatalf("kind = %s, want go", w.Kind)
+	}
+	api, ok := w.Package("example.com/api")
+	if !ok || !reflect.DeepEqual(api.Requires, []string{"example.com/lib"}) {
+		t.Errorf("api = %+v", api)
+	}
+	if len(w.Packages) != 3 {
+		t.Errorf("packages = %v", names(w.Packages))
+	}
+}
+
+func TestLoadGoWorkRoot(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"go.work":    "go 1.18\n\nuse (\n\t.\n\t./lib\n)\n",
+		"go.mod":     "module example.com/app\n\nrequire example.com/lib v0.0.0\n",
+		"main.go":    "package main\n",
+		"lib/go.mod": "module example.com/lib\n",
+	})
+	w, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, ok := w.Package("example.com/app")
+	if !ok || app.Path != "." {
+		t.Fatalf("root module = %+v", app)
+	}
+
+	// the root module contains all files, except the ones of nested modules when telling the changed
+	for _, rel := range []string{"main.go", "cmd/app/main.go", "lib/lib.go"} {
+		if !Contains(app.Path, rel) {
+			t.Errorf("root module should contain %s", rel)
+		}
+	}
+	if got := names(w.changedBy([]string{"main.go"})); !reflect.DeepEqual(got, []string{"example.com/app"}) {
+		t.Errorf("changed by main.go = %v", got)
+	}
+	got := names(w.changedBy([]string{"lib/lib.go"}))
+	if !reflect.DeepEqual(got, []string{"example.com/app", "example.com/lib"}) {
+		t.Errorf("changed by lib = %v", got)
+	}
+}
+
+func TestContains(t *testing.T) {
+	for _, c := range []struct {
+		dir, rel string
+		want     bool
+	}{
+		{"apps/web", "apps/web", true},
+		{"apps/web", "apps/web/index.js", true},
+		{"apps/web", "apps/web-legacy/index.js", false},
+		{"./apps/web/", "apps/web/index.js", true},
+		{".", "main.go", true},
+		{"", "main.go", true},
+	} {
+		if got := Contains(c.dir, c.rel); got != c.want {
+			t.Errorf("Contains(%q, %q) = %v, want %v", c.dir, c.rel, got, c.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	w, err := Load(writeFiles(t, pnpmFiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := w.Filter([]string{"@org/web", "apps/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(got), []string{"@org/web", "@org/api"}) {
+		t.Errorf("filtered = %v", names(got))
+	}
+
+	if _, err := w.Filter([]string{"missing"}); err == nil {
+		t.Error("filter missing package should fail")
+	}
+}
+
+func TestChangedBy(t *testing.T) {
+	w, err := Load(writeFiles(t, pnpmFiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changing a dependency changes its dependents
+	got := names(w.changedBy([]string{"packages/shared/utils/index.js"}))
+	if !reflect.DeepEqual(got, []string{"@org/web", "@org/utils", "@org/ui"}) {
+		t.Errorf("changed by utils = %v", got)
+	}
+
+	got = names(w.changedBy([]string{"apps/api/index.js", "README.md"}))
+	if !reflect.DeepEqual(got, []string{"@org/api"}) {
+		t.Errorf("changed by api = %v", got)
+	}
+
+	// shared lockfile changes all packages
+	if got := w.changedBy([]string{"pnpm-lock.yaml"}); len(got) != len(w.Packages) {
+		t.Errorf("changed by lockfile = %v", names(got))
+	}
+}
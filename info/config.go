@@ -20,7 +20,7 @@ func (c *credentials) LoadToken() string {
 		credentialsFile, _ := ioutil.ReadFile(home + "/.let/credentials.json")
 		err := json.Unmarshal(credentialsFile, &Credentials)
 		if err != nil {
-			logrus.Debugln("load token error: %s", err.Error())
+			logrus.Debugf("load token error: %s", err.Error())
 		}
 	}
 	return Credentials.Token
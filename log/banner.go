@@ -28,6 +28,10 @@ func init() {
 
 // deprecated
 func BStart(message string) {
+	if JSON() {
+		Event("progress", map[string]interface{}{"message": message})
+		return
+	}
 	cfg := yacspin.Config{
 		Frequency: 50 * time.Millisecond,
 		CharSet:   yacspin.CharSets[14],
@@ -45,6 +49,10 @@ func BStart(message string) {
 
 // deprecated
 func BUpdate(message string) {
+	if JSON() {
+		Event("progress", map[string]interface{}{"message": message})
+		return
+	}
 	S.Message(" " + message)
 }
 
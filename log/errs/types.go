@@ -1 +1,109 @@
 package errs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Kind classifies errors of cli, each kind exits with a distinct exit code,
+// so that scripts could tell a build failure apart from an expired token.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	Validation
+	Auth
+	Network
+	BuildFailed
+	Quota
+	Canceled
+)
+
+var kindNames = map[Kind]string{
+	Unknown:     "unknown",
+	Validation:  "validation",
+	Auth:        "auth",
+	Network:     "network",
+	BuildFailed: "build_failed",
+	Quota:       "quota",
+	Canceled:    "canceled",
+}
+
+var exitCodes = map[Kind]int{
+	Unknown:     1,
+	Validation:  2,
+	Auth:        3,
+	Network:     4,
+	BuildFailed: 5,
+	Quota:       6,
+	Canceled:    130,
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// ExitCode returns the process exit code of kind
+func (k Kind) ExitCode() int {
+	return exitCodes[k]
+}
+
+// Error is an error with kind
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error of kind with message
+func New(kind Kind, message string) error {
+	return &Error{Kind: kind, Err: errors.New(message)}
+}
+
+// Newf returns an error of kind with formatted message
+func Newf(kind Kind, format string, a ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, a...)}
+}
+
+// Wrap marks err as kind, returns nil if err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of err,
+// errors without kind are classified as network errors if they are net.Error.
+func KindOf(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Network
+	}
+	return Unknown
+}
+
+// ExitCode returns the process exit code of err, 0 if err is nil
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return KindOf(err).ExitCode()
+}
@@ -0,0 +1,35 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{errors.New("unknown"), 1},
+		{New(Validation, "invalid let.json"), 2},
+		{New(Auth, "token expired"), 3},
+		{fmt.Errorf("query project: %w", New(BuildFailed, "build error")), 5},
+		{&net.OpError{Op: "dial", Err: errors.New("no such host")}, 4},
+		{Wrap(Quota, errors.New("too big")), 6},
+	}
+
+	for _, c := range cases {
+		if code := ExitCode(c.err); code != c.code {
+			t.Errorf("ExitCode(%v) = %d, want %d", c.err, code, c.code)
+		}
+	}
+}
+
+func TestWrapNil(t *testing.T) {
+	if Wrap(Auth, nil) != nil {
+		t.Error("wrap nil error should be nil")
+	}
+}
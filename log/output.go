@@ -0,0 +1,84 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/theckman/yacspin"
+)
+
+// output formats of cli
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+var output = OutputText
+
+// SetOutput switches the output format of cli.
+// in json mode, spinners and colors are suppressed,
+// and everything printed to stdout is a json document per line.
+func SetOutput(format string) error {
+	switch format {
+	case OutputText:
+	case OutputJSON:
+		color.NoColor = true
+		S, _ = yacspin.New(yacspin.Config{
+			Frequency: 50 * time.Millisecond,
+			CharSet:   yacspin.CharSets[14],
+			Writer:    ioutil.Discard,
+		})
+	default:
+		return fmt.Errorf("unsupported output format: %s, available: %s, %s", format, OutputText, OutputJSON)
+	}
+	output = format
+	return nil
+}
+
+// JSON returns whether cli is in json output mode
+func JSON() bool {
+	return output == OutputJSON
+}
+
+// ProgressWriter returns where progress bars should be rendered
+func ProgressWriter() io.Writer {
+	if JSON() {
+		return ioutil.Discard
+	}
+	return os.Stdout
+}
+
+// Result prints the result of a command.
+// in json mode v is printed as a json document, otherwise print is called for human readable text.
+func Result(v interface{}, print func()) {
+	if JSON() {
+		writeJSON(v)
+		return
+	}
+	print()
+}
+
+// Event prints a progress event as a line of ndjson, it's a no-op in text mode
+func Event(event string, fields map[string]interface{}) {
+	if !JSON() {
+		return
+	}
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["event"] = event
+	writeJSON(fields)
+}
+
+func writeJSON(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{"error": map[string]string{"message": err.Error()}})
+	}
+	fmt.Fprintln(os.Stdout, string(b))
+}
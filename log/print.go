@@ -1,38 +1,58 @@
 package log
 
 import (
-	"errors"
 	"fmt"
+
 	"github.com/fatih/color"
 	"github.com/getsentry/sentry-go"
-	"os"
+	"github.com/let-sh/cli/log/errs"
 )
 
-func Errorf(template string, formatString ...string) {
-
-	err := errors.New(fmt.Sprintf(template, formatString))
-
-	S.StopFail()
-	sentry.CaptureException(err)
-	red := color.New(color.BgRed, color.FgBlack).SprintFunc()
-	fmt.Printf("%s %s.\n", red(" error "), err.Error())
-	os.Exit(-1)
+// Errorf prints the formatted error, use Error instead if err is returned up
+func Errorf(template string, a ...interface{}) {
+	Error(fmt.Errorf(template, a...))
 }
 
+// Error stops the spinner and prints err,
+// it never exits, errors should be returned to the command to decide the exit code.
 func Error(err error) {
 	S.StopFail()
-	sentry.CaptureException(err)
+	if kind := errs.KindOf(err); kind != errs.Canceled && kind != errs.Validation {
+		sentry.CaptureException(err)
+	}
+	PrintError(err)
+}
+
+// PrintError prints err, as a json document in json mode
+func PrintError(err error) {
+	if JSON() {
+		writeJSON(map[string]interface{}{
+			"error": map[string]interface{}{
+				"kind":     errs.KindOf(err).String(),
+				"exitCode": errs.ExitCode(err),
+				"message":  err.Error(),
+			},
+		})
+		return
+	}
 	red := color.New(color.BgRed, color.FgBlack).SprintFunc()
 	fmt.Printf("%s %s.\n", red(" error "), err.Error())
-	os.Exit(-1)
 }
 
 func Success(msg string) {
+	if JSON() {
+		Event("success", map[string]interface{}{"message": msg})
+		return
+	}
 	green := color.New(color.BgGreen, color.FgBlack).SprintFunc()
 	fmt.Printf("%s %s\n", green(" success "), msg)
 }
 
 func Warning(msg string) {
+	if JSON() {
+		Event("warning", map[string]interface{}{"message": msg})
+		return
+	}
 	yellow := color.New(color.BgHiYellow, color.FgBlack).SprintFunc()
 	fmt.Printf("%s %s.\n", yellow(" warn "), msg)
 }
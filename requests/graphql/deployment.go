@@ -1,9 +1,11 @@
 package graphql
 
-import "context"
+import (
+	"github.com/shurcooL/graphql"
+)
 
 func Deploy(projectType, projectName, config, channel string, cn bool) (m MutationDeploy, err error) {
-	err = NewClient().Mutate(context.Background(), &m, map[string]interface{}{
+	err = mutate(&m, map[string]interface{}{
 		"type":    projectType,
 		"name":    projectName,
 		"config":  config,
@@ -15,7 +17,7 @@ func Deploy(projectType, projectName, config, channel string, cn bool) (m Mutati
 
 func DeployWithCheckRunID(projectType, projectName, config, channel string, cn bool,
 	checkRunID int64) (m MutationDeployWithCheckRunID, err error) {
-	NewClient().Mutate(context.Background(), &m, map[string]interface{}{
+	err = mutate(&m, map[string]interface{}{
 		"type":       projectType,
 		"name":       projectName,
 		"config":     config,
@@ -25,3 +27,29 @@ func DeployWithCheckRunID(projectType, projectName, config, channel string, cn b
 	})
 	return m, err
 }
+
+// GetDeployments lists the latest deployments of project, the newest created first
+func GetDeployments(projectName string, first int) (q QueryDeployments, err error) {
+	err = query(&q, map[string]interface{}{
+		"projectName": graphql.String(projectName),
+		"first":       graphql.Int(first),
+	})
+	return q, err
+}
+
+// GetDeployment returns the deployment of id, with the id of its project
+func GetDeployment(id string) (q QueryDeploymentNode, err error) {
+	err = query(&q, map[string]interface{}{
+		"id": UUID(id),
+	})
+	return q, err
+}
+
+// PromoteDeployment assigns the deployment to channel, e.g. rollback prod to an earlier deployment
+func PromoteDeployment(deploymentID, channel string) (m MutationPromoteDeployment, err error) {
+	err = mutate(&m, map[string]interface{}{
+		"deploymentID": UUID(deploymentID),
+		"channel":      graphql.String(channel),
+	})
+	return m, err
+}
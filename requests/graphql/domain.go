@@ -1,19 +1,38 @@
 package graphql
 
-import "context"
+import (
+	"github.com/shurcooL/graphql"
+)
 
 func Link(projectID string, hostname string) (m MutationLink, err error) {
-	err = NewClient().Mutate(context.Background(), &m, map[string]interface{}{
-		"projectID": projectID,
-		"hostname":  hostname,
+	err = mutate(&m, map[string]interface{}{
+		"projectID": UUID(projectID),
+		"hostname":  graphql.String(hostname),
 	})
 	return m, err
 }
 
 func Unlink(projectID string, hostname string) (m MutationUnlink, err error) {
-	err = NewClient().Mutate(context.Background(), &m, map[string]interface{}{
-		"projectID": projectID,
-		"hostname":  hostname,
+	err = mutate(&m, map[string]interface{}{
+		"projectID": UUID(projectID),
+		"hostname":  graphql.String(hostname),
+	})
+	return m, err
+}
+
+// GetDomains lists all domains linked to the project
+func GetDomains(projectName string) (q QueryDomains, err error) {
+	err = query(&q, map[string]interface{}{
+		"projectName": graphql.String(projectName),
+	})
+	return q, err
+}
+
+// VerifyDomain triggers a dns check of the linked domain and returns its latest status
+func VerifyDomain(projectID string, hostname string) (m MutationVerifyDomain, err error) {
+	err = mutate(&m, map[string]interface{}{
+		"projectID": UUID(projectID),
+		"hostname":  graphql.String(hostname),
 	})
 	return m, err
 }
@@ -0,0 +1,34 @@
+package graphql
+
+import (
+	"github.com/shurcooL/graphql"
+)
+
+// GetEnvs lists the environment variables of project in all channels
+func GetEnvs(projectName string) (q QueryEnvs, err error) {
+	err = query(&q, map[string]interface{}{
+		"projectName": graphql.String(projectName),
+	})
+	return q, err
+}
+
+// SetEnv creates or updates the environment variable of project, channel is empty for all channels
+func SetEnv(projectID, channel, key, value string) (m MutationSetEnv, err error) {
+	err = mutate(&m, map[string]interface{}{
+		"projectID": UUID(projectID),
+		"channel":   graphql.String(channel),
+		"key":       graphql.String(key),
+		"value":     graphql.String(value),
+	})
+	return m, err
+}
+
+// RemoveEnv removes the environment variable of project in channel, channel is empty for all channels
+func RemoveEnv(projectID, channel, key string) (m MutationRemoveEnv, err error) {
+	err = mutate(&m, map[string]interface{}{
+		"projectID": UUID(projectID),
+		"channel":   graphql.String(channel),
+		"key":       graphql.String(key),
+	})
+	return m, err
+}
@@ -1,5 +1,14 @@
 package graphql
 
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/let-sh/cli/log/errs"
+	"github.com/shurcooL/graphql"
+)
+
 type Error interface {
 	error
 	Network() bool // Is the error a network error?
@@ -16,3 +25,48 @@ type GraphqlError struct {
 		Code string `json:"code"`
 	} `json:"extensions"`
 }
+
+// Classify marks err of graphql request with kind of errs,
+// so that cli could exit with the matching exit code.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var graphqlError *graphql.GraphQLError
+	if errors.As(err, &graphqlError) {
+		for _, e := range graphqlError.GraphqlErrors {
+			switch e.Extensions["code"] {
+			case "UNAUTHENTICATED", "FORBIDDEN":
+				return errs.Wrap(errs.Auth, err)
+			case "QUOTA_EXCEEDED", "RATE_LIMITED":
+				return errs.Wrap(errs.Quota, err)
+			case "BAD_USER_INPUT", "GRAPHQL_VALIDATION_FAILED":
+				return errs.Wrap(errs.Validation, err)
+			}
+		}
+		return err
+	}
+
+	var requestError *graphql.RequestError
+	if errors.As(err, &requestError) {
+		if requestError.NetworkError != nil {
+			switch requestError.NetworkError.StatusCode {
+			case http.StatusUnauthorized, http.StatusForbidden:
+				return errs.Wrap(errs.Auth, err)
+			case http.StatusTooManyRequests:
+				return errs.Wrap(errs.Quota, err)
+			}
+		}
+		return errs.Wrap(errs.Network, err)
+	}
+	return err
+}
+
+func query(q interface{}, variables map[string]interface{}) error {
+	return Classify(NewClient().Query(context.Background(), q, variables))
+}
+
+func mutate(m interface{}, variables map[string]interface{}) error {
+	return Classify(NewClient().Mutate(context.Background(), m, variables))
+}
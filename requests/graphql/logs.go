@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"github.com/shurcooL/graphql"
+)
+
+// GetLogs returns the latest `last` lines of the given log type ("build" or "runtime").
+// if after is not empty, only lines after that cursor are returned.
+func GetLogs(deploymentID, logType string, last int, after string) (q QueryLogs, err error) {
+	var cursor *graphql.String
+	if after != "" {
+		cursor = graphql.NewString(graphql.String(after))
+	}
+
+	err = query(&q, map[string]interface{}{
+		"deploymentID": UUID(deploymentID),
+		"type":         graphql.String(logType),
+		"last":         graphql.Int(last),
+		"after":        cursor,
+	})
+	return q, err
+}
+
+// GetLogsPage returns the first `first` lines of the given log type after cursor,
+// from the beginning if after is empty.
+func GetLogsPage(deploymentID, logType string, first int, after string) (q QueryLogsPage, err error) {
+	var cursor *graphql.String
+	if after != "" {
+		cursor = graphql.NewString(graphql.String(after))
+	}
+
+	err = query(&q, map[string]interface{}{
+		"deploymentID": UUID(deploymentID),
+		"type":         graphql.String(logType),
+		"first":        graphql.Int(first),
+		"after":        cursor,
+	})
+	return q, err
+}
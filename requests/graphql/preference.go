@@ -1,11 +1,7 @@
 package graphql
 
-import (
-	"context"
-)
-
 func SetPreference(name, value string) (m MutationSetPreference, err error) {
-	err = NewClient().Mutate(context.Background(), &m, map[string]interface{}{
+	err = mutate(&m, map[string]interface{}{
 		"name":  name,
 		"value": value,
 	})
@@ -13,12 +9,12 @@ func SetPreference(name, value string) (m MutationSetPreference, err error) {
 }
 
 func GetAllPreference() (q QueryAllPreference, err error) {
-	err = NewClient().Query(context.Background(), &q, nil)
+	err = query(&q, nil)
 	return q, err
 }
 
 func GetPreference(name string) (q QueryPreference, err error) {
-	err = NewClient().Query(context.Background(), &q, map[string]interface{}{
+	err = query(&q, map[string]interface{}{
 		"name": name,
 	})
 	return q, err
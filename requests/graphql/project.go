@@ -0,0 +1,12 @@
+package graphql
+
+import (
+	"github.com/shurcooL/graphql"
+)
+
+func GetProject(projectName string) (q QueryProject, err error) {
+	err = query(&q, map[string]interface{}{
+		"projectName": graphql.String(projectName),
+	})
+	return q, err
+}
@@ -0,0 +1,5 @@
+package graphql
+
+// UUID is the server side UUID scalar,
+// variables of this type are sent as `UUID!`.
+type UUID string
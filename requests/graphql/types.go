@@ -20,6 +20,12 @@ type QueryCheckDeployCapability struct {
 		Exists bool   `graphql:"exists"`
 	} `graphql:"checkDeployCapability(projectName:$projectName,cn:$cn)"`
 }
+type QuerySourceSizeLimit struct {
+	SourceSizeLimit struct {
+		Confirm int64 `graphql:"confirm"`
+		Max     int64 `graphql:"max"`
+	} `graphql:"sourceSizeLimit(projectName:$projectName)"`
+}
 type QueryStsToken struct {
 	StsToken struct {
 		Host            string `graphql:"host"`
@@ -81,14 +87,38 @@ type MutationCancelDeployment struct {
 	CancelDeployment bool `graphql:"cancel(deploymentID:$deploymentID)""`
 }
 
+type DeploymentNode struct {
+	ID         string `graphql:"id" json:"id"`
+	Channel    string `graphql:"channel" json:"channel"`
+	Status     string `graphql:"status" json:"status"`
+	Done       bool   `graphql:"done" json:"done"`
+	TargetFQDN string `graphql:"targetFQDN" json:"targetFQDN"`
+	CreatedAt  string `graphql:"createdAt" json:"createdAt"`
+}
+
+type QueryDeploymentNode struct {
+	Deployment struct {
+		DeploymentNode
+		Project struct {
+			ID string `graphql:"id" json:"id"`
+		} `graphql:"project" json:"project"`
+	} `graphql:"deployment(id:$id)"`
+}
+
 type QueryDeployments struct {
 	Deployments struct {
 		Edges []struct {
-			Node struct {
-				ID string `graphql:"id"`
-			} `graphql:"node"`
+			Node DeploymentNode `graphql:"node"`
 		} `graphql:"edges"`
-	} `graphql:"deployments(first:$first,projectName:$projectName,orderBy:{direction:DESC,field:UPDATED_AT})"`
+	} `graphql:"deployments(first:$first,projectName:$projectName,orderBy:{direction:DESC,field:CREATED_AT})"`
+}
+
+type MutationPromoteDeployment struct {
+	PromoteDeployment struct {
+		ID         string `graphql:"id" json:"id"`
+		Channel    string `graphql:"channel" json:"channel"`
+		TargetFQDN string `graphql:"targetFQDN" json:"targetFQDN"`
+	} `graphql:"promoteDeployment(deploymentID:$deploymentID,channel:$channel)"`
 }
 
 type MutationStartDevelopment struct {
@@ -115,3 +145,59 @@ type MutationLink struct {
 type MutationUnlink struct {
 	Unlink bool `graphql:"unlink(projectID:$projectID,hostname:$hostname)"`
 }
+
+type Domain struct {
+	Hostname  string `graphql:"hostname" json:"hostname"`
+	DNSStatus string `graphql:"dnsStatus" json:"dnsStatus"`
+	TLSStatus string `graphql:"tlsStatus" json:"tlsStatus"`
+	Records   []struct {
+		Type  string `graphql:"type" json:"type"`
+		Name  string `graphql:"name" json:"name"`
+		Value string `graphql:"value" json:"value"`
+	} `graphql:"records" json:"records"`
+}
+
+type QueryDomains struct {
+	Project struct {
+		ID      string   `graphql:"id"`
+		Domains []Domain `graphql:"domains"`
+	} `graphql:"project(name:$projectName)"`
+}
+
+type MutationVerifyDomain struct {
+	VerifyDomain Domain `graphql:"verifyDomain(projectID:$projectID,hostname:$hostname)"`
+}
+
+type LogLine struct {
+	Cursor    string `graphql:"cursor" json:"cursor"`
+	Timestamp string `graphql:"timestamp" json:"timestamp"`
+	Message   string `graphql:"message" json:"message"`
+}
+type QueryLogs struct {
+	Logs []LogLine `graphql:"logs(deploymentID:$deploymentID,type:$type,last:$last,after:$after)"`
+}
+type QueryLogsPage struct {
+	Logs []LogLine `graphql:"logs(deploymentID:$deploymentID,type:$type,first:$first,after:$after)"`
+}
+
+type Env struct {
+	Key   string `graphql:"key" json:"key"`
+	Value string `graphql:"value" json:"value"`
+	// Channel is empty for all channels of project
+	Channel string `graphql:"channel" json:"channel"`
+}
+
+type QueryEnvs struct {
+	Project struct {
+		ID   string `graphql:"id"`
+		Envs []Env  `graphql:"envs"`
+	} `graphql:"project(name:$projectName)"`
+}
+
+type MutationSetEnv struct {
+	SetEnv bool `graphql:"setEnv(projectID:$projectID,channel:$channel,key:$key,value:$value)"`
+}
+
+type MutationRemoveEnv struct {
+	RemoveEnv bool `graphql:"removeEnv(projectID:$projectID,channel:$channel,key:$key)"`
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -47,7 +46,7 @@ func GetJsonWithPath(url string, path string) (data gjson.Result, err error) {
 	defer r.Body.Close()
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		log.Fatalln(err)
+		return data, err
 	}
 	//Convert the body to type string
 	data = gjson.Get(string(body), path)
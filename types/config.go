@@ -20,6 +20,37 @@ type Project struct {
 	ServeCommand string `json:"serve_command"`
 }
 
+// Promotion records the deployment promoted to prod of project by lets rollback
+type Promotion struct {
+	DeploymentID string    `json:"deployment_id"`
+	PromotedAt   time.Time `json:"promoted_at"`
+}
+
+// StaticManifest records the static files of the last uploaded bundle of project
+type StaticManifest struct {
+	BundleID string `json:"bundle_id"`
+	// Files maps slash separated path relative to static dir to sha256 of content
+	Files map[string]string `json:"files"`
+	// Headers is the digest of header rules, files are uploaded again once the rules change
+	Headers string `json:"headers,omitempty"`
+}
+
+// UploadCheckpoint records the uploaded parts of an unfinished multipart upload, to resume it
+type UploadCheckpoint struct {
+	Bucket   string         `json:"bucket"`
+	Key      string         `json:"key"`
+	UploadID string         `json:"upload_id"`
+	PartSize int64          `json:"part_size"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+type UploadedPart struct {
+	Number int    `json:"number"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	ETag   string `json:"etag"`
+}
+
 type Extra struct {
 	NotifyUpgradeTime time.Time `json:"notify"`
 }
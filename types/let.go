@@ -1,27 +1,72 @@
 package types
 
+import "github.com/c2h5oh/datasize"
+
 type LetConfig struct {
 	Name string            `json:"name,omitempty"`
 	Type string            `json:"type,omitempty"`
 	Env  map[string]string `json:"env,omitempty"`
-	//Build struct {
-	//	Env struct {
-	//		K string `json:"K,omitempty"`
-	//	} `json:"env,omitempty"`
-	//} `json:"build,omitempty"`
+
+	// Build overrides the local build step of project type
+	Build *BuildConfig `json:"build,omitempty"`
 
 	// static dir
-	Static   string `json:"static,omitempty"`
-	Redirect []struct {
-		Source      string `json:"source,omitempty"`
-		Destination string `json:"destination,omitempty"`
-		Type        int    `json:"type,omitempty"`
-	} `json:"redirects,omitempty"`
-	Rewrite []struct {
-		Source      string `json:"source,omitempty"`
-		Destination string `json:"destination,omitempty"`
-	} `json:"rewrites,omitempty"`
-	Link []string `json:"link,omitempty"`
-	CN   *bool    `json:"cn,omitempty"`
-	Web3 *bool    `json:"web3,omitempty"`
+	Static   string         `json:"static,omitempty"`
+	Redirect []RedirectRule `json:"redirects,omitempty"`
+	Rewrite  []RewriteRule  `json:"rewrites,omitempty"`
+	Link     []string       `json:"link,omitempty"`
+	CN       *bool          `json:"cn,omitempty"`
+	Web3     *bool          `json:"web3,omitempty"`
+
+	// Headers overrides the response headers of static files, later rules win
+	Headers []HeaderRule `json:"headers,omitempty"`
+
+	// SourceLimit lowers the size limits of source code, the limits of plan could not be exceeded
+	SourceLimit *SizeLimit `json:"sourceLimit,omitempty"`
+
+	// Environments override the config by channel, e.g. {"prod": {"env": {"API": "https://api.let.sh"}}},
+	// objects are merged by key, other values are replaced
+	Environments map[string]LetConfig `json:"environments,omitempty"`
+}
+
+// BuildConfig overrides the local build step, e.g. {"command": "npm ci && npm run build", "outputDir": "build"}
+type BuildConfig struct {
+	// Command runs by the shell of system in project dir, instead of the compile commands of project type
+	Command string `json:"command,omitempty"`
+	// OutputDir is the dir of built static files to upload, overrides static
+	OutputDir string `json:"outputDir,omitempty"`
+	// Env are the variables only set for the build command, over env
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// SizeLimit limits the size of uploads, e.g. {"confirm": "20MB", "max": "40MB"}
+type SizeLimit struct {
+	// Confirm asks user before uploading larger ones
+	Confirm datasize.ByteSize `json:"confirm,omitempty"`
+	// Max aborts larger ones
+	Max datasize.ByteSize `json:"max,omitempty"`
+}
+
+// RedirectRule redirects requests of path matching Source to Destination.
+// Source is a path like /blog/:slug or /docs/*, where :name matches a segment and a trailing * or :name*
+// matches the rest. Destination is a path or an absolute url, referring params of Source by :name.
+type RedirectRule struct {
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	// Type is the status code, one of 301, 302, 303, 307 and 308, 301 if omitted
+	Type int `json:"type,omitempty"`
+}
+
+// RewriteRule serves Destination for requests of path matching Source without redirecting,
+// requests of existing files are not rewritten. The patterns are the same as RedirectRule.
+type RewriteRule struct {
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// HeaderRule sets headers of the static files matched by Source, a gitignore style glob relative to static dir,
+// e.g. {"source": "fonts/**", "headers": {"Cache-Control": "max-age=86400"}}
+type HeaderRule struct {
+	Source  string            `json:"source"`
+	Headers map[string]string `json:"headers"`
 }
@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/let-sh/cli/utils"
-	"github.com/sirupsen/logrus"
-	"os"
 )
 
 type InputAreaConfig struct {
@@ -16,12 +16,18 @@ type InputAreaConfig struct {
 	PlaceHolders       []string `json:"placeholders,omitempty"`
 }
 
+// InputArea asks user to input a line, returns errs.Canceled if user pressed Ctrl+C or Esc
 func InputArea(config ...InputAreaConfig) (string, error) {
 	conf := InputAreaConfig{}
 	if len(config) > 0 {
 		conf = config[0]
 	}
 
+	// no prompts in json output mode, take the default answer
+	if log.JSON() {
+		return conf.DefaultPlaceholder, nil
+	}
+
 	ti := textinput.NewModel()
 	ti.Placeholder = conf.DefaultPlaceholder
 	ti.Prompt = ""
@@ -36,10 +42,13 @@ func InputArea(config ...InputAreaConfig) (string, error) {
 
 	p := tea.NewProgram(m)
 	if err := p.Start(); err != nil {
-		logrus.Fatal(err)
 		return "", err
 	}
 
+	if m.canceled {
+		return "", errs.New(errs.Canceled, "canceled")
+	}
+
 	return m.Value(), nil
 }
 
@@ -47,6 +56,7 @@ type inputAreaModal struct {
 	textInput    textinput.Model
 	layoutConfig InputAreaConfig
 	err          error
+	canceled     bool
 }
 
 type errMsg error
@@ -71,11 +81,8 @@ func (m *inputAreaModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
-		case tea.KeyCtrlC:
-			os.Exit(0)
-			return m, tea.Quit
-		case tea.KeyEsc:
-			os.Exit(0)
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.canceled = true
 			return m, tea.Quit
 		case tea.KeyEnter:
 			return m, tea.Quit
@@ -3,9 +3,9 @@ package ui
 import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/log/errs"
 	"github.com/sirupsen/logrus"
-	"log"
-	"os"
 	"strings"
 )
 
@@ -21,14 +21,21 @@ type radioModel struct {
 	textInput textinput.Model
 	config    RadioConfig
 	err       error
+	canceled  bool
 }
 
-func Radio(configs ...RadioConfig) bool {
+// Radio asks user a yes/no question, returns errs.Canceled if user pressed Ctrl+C or Esc
+func Radio(configs ...RadioConfig) (bool, error) {
 	conf := RadioConfig{}
 	if len(configs) > 0 {
 		conf = configs[0]
 	}
 
+	// no prompts in json output mode, take the default answer
+	if log.JSON() {
+		return conf.Default, nil
+	}
+
 	ti := textinput.NewModel()
 	ti.Placeholder = conf.Placeholder
 	ti.Focus()
@@ -42,25 +49,25 @@ func Radio(configs ...RadioConfig) bool {
 	p := tea.NewProgram(m1)
 
 	if err := p.Start(); err != nil {
-		log.Fatal(err)
+		return false, err
+	}
+
+	if m1.canceled {
+		return false, errs.New(errs.Canceled, "canceled")
 	}
 
 	// y
 	if strings.Contains(strings.ToLower(m1.Value()), "y") {
-		return true
+		return true, nil
 	}
 
 	// n
 	if strings.Contains(strings.ToLower(m1.Value()), "n") {
-		return false
+		return false, nil
 	}
 
 	// default
-	if conf.Default {
-		return true
-	} else {
-		return false
-	}
+	return conf.Default, nil
 }
 
 func (m *radioModel) Init() tea.Cmd {
@@ -87,11 +94,8 @@ func (m *radioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.Type {
-		case tea.KeyCtrlC:
-			os.Exit(0)
-			return m, tea.Quit
-		case tea.KeyEsc:
-			os.Exit(0)
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.canceled = true
 			return m, tea.Quit
 		case tea.KeyEnter:
 			return m, tea.Quit
@@ -14,6 +14,11 @@ type spinner struct {
 var Spinner = spinner{}
 
 func (s *spinner) Start(message string) {
+	if log.JSON() {
+		log.Event("progress", map[string]interface{}{"message": message})
+		return
+	}
+
 	if s.Spinner == nil {
 		cfg := yacspin.Config{
 			Frequency: 50 * time.Millisecond,
@@ -33,19 +38,26 @@ func (s *spinner) Start(message string) {
 }
 
 func (s *spinner) Update(message string) {
+	if log.JSON() {
+		log.Event("progress", map[string]interface{}{"message": message})
+		return
+	}
 	s.Spinner.Message(" " + message)
 }
 
 func (s *spinner) Stop() {
+	if s.Spinner == nil {
+		return
+	}
 	s.Spinner.StopFail()
 }
 
 func (s *spinner) Success(message string) {
-	s.Spinner.StopFail()
+	s.Stop()
 	log.Success(message)
 }
 
 func (s *spinner) Failed(message string) {
-	s.Spinner.StopFail()
+	s.Stop()
 	log.Error(errors.New(message))
 }
@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// BuildCacheDir returns the dir of local build cache, outputs are cached under ~/.let/cache/<project>/
+func BuildCacheDir() string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".let", "cache")
+}
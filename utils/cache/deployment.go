@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/let-sh/cli/types"
+	"github.com/mitchellh/go-homedir"
+)
+
+// DeploymentsDir returns the dir of deployment records, the last deployments of projects are recorded
+// under ~/.let/deployments/<project>/
+func DeploymentsDir() string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".let", "deployments")
+}
+
+// promotionPath returns the path of the last promotion of project, stored under ~/.let/promotions/
+func promotionPath(projectName string) string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".let", "promotions", filepath.Base(projectName)+".json")
+}
+
+// GetPromotion returns the last deployment promoted to prod of project from this machine, nil if none
+func GetPromotion(projectName string) (*types.Promotion, error) {
+	content, err := ioutil.ReadFile(promotionPath(projectName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var promotion types.Promotion
+	if err := json.Unmarshal(content, &promotion); err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// SavePromotion saves promotion as the last one of project
+func SavePromotion(projectName string, promotion types.Promotion) error {
+	content, err := json.Marshal(promotion)
+	if err != nil {
+		return err
+	}
+	path := promotionPath(projectName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
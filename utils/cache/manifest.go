@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/let-sh/cli/types"
+	"github.com/mitchellh/go-homedir"
+)
+
+// manifestPath returns the path of static manifest of project in bucket,
+// manifests are stored under ~/.let/manifests/<bucket>/, objects are only copied within a bucket.
+func manifestPath(bucket, projectName string) string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".let", "manifests", bucket, projectName+".json")
+}
+
+// GetStaticManifest returns the static manifest of last deployment to bucket,
+// an empty manifest is returned if project has never been deployed to bucket from this machine.
+func GetStaticManifest(bucket, projectName string) (manifest types.StaticManifest, err error) {
+	content, err := ioutil.ReadFile(manifestPath(bucket, projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, nil
+		}
+		return manifest, err
+	}
+	err = json.Unmarshal(content, &manifest)
+	return manifest, err
+}
+
+// SaveStaticManifest saves the static manifest after all files of bundle uploaded
+func SaveStaticManifest(bucket, projectName string, manifest types.StaticManifest) error {
+	byteValue, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	path := manifestPath(bucket, projectName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, byteValue, 0644)
+}
@@ -42,14 +42,12 @@ func SaveProjectInfo(projectInfo types.Project) error {
 	// Convert golang object back to byte
 	byteValue, err := json.Marshal(ProjectsInfo)
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 
 	// Write back to file
 	home, _ := homedir.Dir()
-	err = ioutil.WriteFile(home+"/.let/projects.json", byteValue, 0644)
-	return nil
+	return ioutil.WriteFile(home+"/.let/projects.json", byteValue, 0644)
 }
 
 func GetProjectInfo(dir string) (project types.Project, err error) {
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/let-sh/cli/types"
+	"github.com/mitchellh/go-homedir"
+)
+
+// checkpointPath returns the path of upload checkpoint of project, checkpoints are stored under ~/.let/uploads/
+func checkpointPath(projectName string) string {
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".let", "uploads", projectName+".json")
+}
+
+// GetUploadCheckpoint returns the checkpoint of unfinished source upload, nil if not exists
+func GetUploadCheckpoint(projectName string) (*types.UploadCheckpoint, error) {
+	content, err := ioutil.ReadFile(checkpointPath(projectName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var checkpoint types.UploadCheckpoint
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// SaveUploadCheckpoint saves the checkpoint after each part uploaded
+func SaveUploadCheckpoint(projectName string, checkpoint types.UploadCheckpoint) error {
+	byteValue, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	path := checkpointPath(projectName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, byteValue, 0644)
+}
+
+// RemoveUploadCheckpoint removes the checkpoint once upload completed
+func RemoveUploadCheckpoint(projectName string) error {
+	err := os.Remove(checkpointPath(projectName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -59,8 +59,9 @@ func init() {
 		repo, err := info.GitHub.GetRepositoryNameWithOwner()
 		if err != nil {
 			log.Error(err)
+		} else {
+			info.Credentials.SetToken("GITHUB:" + repo + ":" + info.GitHub.GetToken())
 		}
-		info.Credentials.SetToken("GITHUB:" + repo + ":" + info.GitHub.GetToken())
 	}
 
 	_, err = os.Stat(home + "/.let/preference.json")
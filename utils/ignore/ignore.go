@@ -0,0 +1,245 @@
+// Package ignore decides which files of a project are shipped on deploy.
+//
+// It follows the gitignore semantics: patterns of .gitignore and .letignore files are applied to the dir
+// the file lives in and its sub dirs, later patterns overwrite earlier ones, and "!" negates a pattern.
+// The precedence from low to high is: Defaults, then for each dir from root to the deepest one,
+// its .gitignore and .letignore.
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Files are the ignore files read in each dir, in the order of precedence
+var Files = []string{".gitignore", ".letignore"}
+
+// Defaults are ignored unless negated by ignore files
+var Defaults = []string{
+	"node_modules",
+	".git",
+	".env*",
+}
+
+type pattern struct {
+	// base is the slash separated dir of ignore file relative to root, empty for root
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher matches paths relative to Root against the loaded patterns
+type Matcher struct {
+	Root     string
+	patterns []pattern
+}
+
+// New returns the matcher of root with Defaults and ignore files of root loaded,
+// ignore files of sub dirs are loaded by Walk.
+func New(root string) (*Matcher, error) {
+	m := &Matcher{Root: root}
+	m.AddPatterns("", Defaults...)
+	if err := m.load(""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AddPatterns adds gitignore patterns applied to dir base
+func (m *Matcher) AddPatterns(base string, lines ...string) {
+	for _, line := range lines {
+		if p, ok := compile(base, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// load adds the patterns of ignore files in dir base
+func (m *Matcher) load(base string) error {
+	for _, name := range Files {
+		f, err := os.Open(filepath.Join(m.Root, filepath.FromSlash(base), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var lines []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		m.AddPatterns(base, lines...)
+	}
+	return nil
+}
+
+// Match tells whether the slash separated path relative to root is ignored,
+// a path under an ignored dir is ignored too.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+func (m *Matcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if p.base != "" {
+			if !strings.HasPrefix(rel, p.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, p.base+"/")
+		}
+		if p.re.MatchString(target) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Walk calls fn for each file under root not ignored, with the slash separated path relative to root.
+// Ignored dirs are skipped, ignore files of sub dirs are loaded while walking.
+func (m *Matcher) Walk(fn func(rel string, info os.FileInfo) error) error {
+	return filepath.Walk(m.Root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.Root, file)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if m.Match(rel, info.IsDir()) {
+			logrus.Debug("ignore ", rel)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return m.load(rel)
+		}
+		return fn(rel, info)
+	})
+}
+
+// List returns the sorted slash separated paths relative to root of files not ignored
+func List(root string) ([]string, error) {
+	m, err := New(root)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = m.Walk(func(rel string, info os.FileInfo) error {
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// compile parses a line of ignore file, returns false for blank lines and comments
+func compile(base, line string) (pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// escaped leading "!" or "#"
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	re, err := Glob(line)
+	if err != nil {
+		logrus.Debugf("invalid ignore pattern %q: %s", line, err)
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// Glob compiles a gitignore pattern to the regexp of slash separated relative paths.
+// A pattern with a slash is relative to the root, otherwise it matches the name at any level.
+func Glob(glob string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	expr := globToRegexp(glob)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				// zero or more dirs
+				b.WriteString("(.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		".gitignore":               "*.log\n/build/\n!keep.log\n# comment\n",
+		".letignore":               "docs/**/*.md\n",
+		".env":                     "SECRET=1",
+		".env.local":               "SECRET=2",
+		"index.js":                 "",
+		"debug.log":                "",
+		"keep.log":                 "",
+		"build/out.js":             "",
+		"src/build/out.js":         "",
+		"node_modules/x/index.js":  "",
+		"docs/guide/intro.md":      "",
+		"docs/guide/intro.html":    "",
+		"packages/a/.gitignore":    "dist\n!important.log\n",
+		"packages/a/dist/a.js":     "",
+		"packages/a/important.log": "",
+		"packages/a/other.log":     "",
+		"packages/b/dist/b.js":     "",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".gitignore",
+		".letignore",
+		"docs/guide/intro.html",
+		"index.js",
+		"keep.log",
+		"packages/a/.gitignore",
+		"packages/a/important.log",
+		"packages/b/dist/b.js",
+		"src/build/out.js",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := &Matcher{}
+	m.AddPatterns("", "node_modules", "*.tmp", "/only-root.txt", "logs/", "a/**/z", "[!x]y.txt")
+	m.AddPatterns("sub", "local.txt", "!keep.tmp")
+
+	for _, c := range []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules/react/index.js", false, true},
+		{"a.tmp", false, true},
+		{"sub/keep.tmp", false, false},
+		{"other/keep.tmp", false, true},
+		{"only-root.txt", false, true},
+		{"sub/only-root.txt", false, false},
+		{"logs", false, false},
+		{"logs", true, true},
+		{"logs/today", false, true},
+		{"a/z", false, true},
+		{"a/b/c/z", false, true},
+		{"ay.txt", false, true},
+		{"xy.txt", false, false},
+		{"local.txt", false, false},
+		{"sub/deep/local.txt", false, true},
+	} {
+		if got := m.Match(c.path, c.isDir); got != c.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", c.path, c.isDir, got, c.want)
+		}
+	}
+}
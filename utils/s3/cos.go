@@ -0,0 +1,103 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// cosUploader uploads to Tencent COS, the host is like https://<bucket>-<appid>.cos.ap-guangzhou.myqcloud.com
+type cosUploader struct {
+	bucket string
+	host   string
+	client *cos.Client
+}
+
+func newCosUploader(token StsToken) (*cosUploader, error) {
+	u, err := url.Parse(token.Host)
+	if err != nil {
+		return nil, err
+	}
+	labels := strings.SplitN(u.Host, ".", 2)
+	if len(labels) != 2 {
+		return nil, fmt.Errorf("invalid cos host %q", token.Host)
+	}
+
+	client := cos.NewClient(&cos.BaseURL{BucketURL: &url.URL{Scheme: u.Scheme, Host: u.Host}}, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:     token.AccessKeyID,
+			SecretKey:    token.AccessKeySecret,
+			SessionToken: token.SecurityToken,
+		},
+	})
+	return &cosUploader{bucket: labels[0], host: u.Host, client: client}, nil
+}
+
+func (u *cosUploader) Bucket() string {
+	return u.bucket
+}
+
+func (u *cosUploader) PutObject(key string, r io.Reader, size int64, header http.Header) error {
+	_, err := u.client.Object.Put(context.Background(), key, r, &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{ContentLength: size, XOptionHeader: &header},
+	})
+	return err
+}
+
+func (u *cosUploader) CopyObject(src, dst string) error {
+	_, _, err := u.client.Object.Copy(context.Background(), dst, u.host+"/"+src, nil)
+	return err
+}
+
+func (u *cosUploader) DeleteObject(key string) error {
+	_, err := u.client.Object.Delete(context.Background(), key)
+	return err
+}
+
+func (u *cosUploader) InitiateMultipartUpload(key string) (string, error) {
+	result, _, err := u.client.Object.InitiateMultipartUpload(context.Background(), key, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+func (u *cosUploader) UploadPart(key, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	resp, err := u.client.Object.UploadPart(context.Background(), key, uploadID, number, r,
+		&cos.ObjectUploadPartOptions{ContentLength: size})
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (u *cosUploader) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	opt := &cos.CompleteMultipartUploadOptions{}
+	for _, p := range parts {
+		opt.Parts = append(opt.Parts, cos.Object{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, _, err := u.client.Object.CompleteMultipartUpload(context.Background(), key, uploadID, opt)
+	return err
+}
+
+func (u *cosUploader) AbortMultipartUpload(key, uploadID string) error {
+	_, err := u.client.Object.AbortMultipartUpload(context.Background(), key, uploadID)
+	return err
+}
+
+func (u *cosUploader) ListParts(key, uploadID string) ([]Part, error) {
+	result, _, err := u.client.Object.ListParts(context.Background(), key, uploadID, nil)
+	if err != nil {
+		return nil, err
+	}
+	var parts []Part
+	for _, p := range result.Parts {
+		parts = append(parts, Part{Number: p.PartNumber, ETag: p.ETag, Size: p.Size})
+	}
+	return parts, nil
+}
@@ -0,0 +1,276 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/ignore"
+)
+
+// headersVersion is bumped once the default headers change, so files are uploaded again instead of copied
+const headersVersion = "1"
+
+const (
+	// immutableCacheControl is set on fingerprinted files, their names change with content
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// htmlCacheControl makes pages revalidated, so they always refer to the latest fingerprinted files
+	htmlCacheControl = "no-cache"
+)
+
+// minCompressSize is the size of smallest file worth precompressing
+const minCompressSize = 1024
+
+// encoding is a precompressed variant uploaded beside the file with the key suffix
+type encoding struct {
+	suffix   string
+	name     string
+	compress func(w io.Writer) io.WriteCloser
+}
+
+// encodings are in the order of preference
+var encodings = []encoding{
+	{".br", "br", func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, brotli.BestCompression) }},
+	{".gz", "gzip", func(w io.Writer) io.WriteCloser {
+		gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+		return gw
+	}},
+}
+
+// contentTypes complements the types of web assets missing in mime package or system
+var contentTypes = map[string]string{
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".txt":         "text/plain; charset=utf-8",
+	".md":          "text/markdown; charset=utf-8",
+	".ico":         "image/x-icon",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".ttf":         "font/ttf",
+	".otf":         "font/otf",
+	".eot":         "application/vnd.ms-fontobject",
+}
+
+// compressibleTypes are compressed besides text/*, +json and +xml types
+var compressibleTypes = map[string]bool{
+	"application/javascript":        true,
+	"application/json":              true,
+	"application/xml":               true,
+	"application/wasm":              true,
+	"application/vnd.ms-fontobject": true,
+	"image/svg+xml":                 true,
+	"image/x-icon":                  true,
+	"font/ttf":                      true,
+	"font/otf":                      true,
+}
+
+// staticHeaders decides the headers of static files by their keys relative to static dir
+type staticHeaders struct {
+	rules []headerRule
+	// keys are all the files, variants built by user are uploaded as is
+	keys map[string]bool
+	// digest changes with the rules
+	digest string
+}
+
+type headerRule struct {
+	re      *regexp.Regexp
+	headers map[string]string
+}
+
+func newStaticHeaders(rules []types.HeaderRule, keys []string) (*staticHeaders, error) {
+	h := &staticHeaders{keys: map[string]bool{}}
+	for _, key := range keys {
+		h.keys[key] = true
+	}
+	for _, rule := range rules {
+		re, err := ignore.Glob(rule.Source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header source %q: %w", rule.Source, err)
+		}
+		h.rules = append(h.rules, headerRule{re: re, headers: rule.Headers})
+	}
+
+	content, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(append([]byte(headersVersion), content...))
+	h.digest = hex.EncodeToString(sum[:8])
+	return h, nil
+}
+
+// header returns the headers of key, the file is sniffed if its type is unknown by extension.
+// Defaults are Content-Type, Content-Encoding of prebuilt variants and Cache-Control,
+// then overridden by matched rules, an empty value removes the header.
+func (h *staticHeaders) header(key, filePath string) (http.Header, error) {
+	header := http.Header{}
+	name := key
+	for _, e := range encodings {
+		if base := strings.TrimSuffix(key, e.suffix); base != key && h.keys[base] {
+			name = base
+			header.Set("Content-Encoding", e.name)
+			break
+		}
+	}
+
+	contentType := typeByExtension(path.Ext(name))
+	if contentType == "" && name == key {
+		sniffed, err := sniff(filePath)
+		if err != nil {
+			return nil, err
+		}
+		contentType = sniffed
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	switch {
+	case mediaType(contentType) == "text/html":
+		header.Set("Cache-Control", htmlCacheControl)
+	case fingerprinted(name):
+		header.Set("Cache-Control", immutableCacheControl)
+	}
+
+	// rules of the file apply to its variants too
+	for _, rule := range h.rules {
+		if !rule.re.MatchString(name) {
+			continue
+		}
+		for k, v := range rule.headers {
+			if v == "" {
+				header.Del(k)
+			} else {
+				header.Set(k, v)
+			}
+		}
+	}
+	return header, nil
+}
+
+// variants returns the encodings to precompress the file of key,
+// prebuilt variants and small or incompressible files are skipped.
+func (h *staticHeaders) variants(key string, header http.Header, size int64) []encoding {
+	if header.Get("Content-Encoding") != "" || size < minCompressSize {
+		return nil
+	}
+	if !compressible(header.Get("Content-Type")) {
+		return nil
+	}
+	var variants []encoding
+	for _, e := range encodings {
+		if !h.keys[key+e.suffix] {
+			variants = append(variants, e)
+		}
+	}
+	return variants
+}
+
+// compressFile returns the content of file compressed by e
+func (e encoding) compressFile(filePath string) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	w := e.compress(&buf)
+	if _, err := io.Copy(w, f); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func typeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// sniff detects the content type by the first 512 bytes of file
+func sniff(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+func compressible(contentType string) bool {
+	t := mediaType(contentType)
+	return strings.HasPrefix(t, "text/") || strings.HasSuffix(t, "+json") || strings.HasSuffix(t, "+xml") ||
+		compressibleTypes[t]
+}
+
+// fingerprinted reports whether the name carries a content hash built by bundlers,
+// e.g. app.3f2a1c.js of webpack or index-BxK3a9Zq.js of vite.
+// The hash is a segment between the name and extension, of 6+ hex digits or 8+ word characters,
+// mixing letters and digits.
+func fingerprinted(name string) bool {
+	segments := strings.FieldsFunc(path.Base(name), func(r rune) bool { return r == '.' || r == '-' })
+	if len(segments) < 3 {
+		return false
+	}
+	for _, s := range segments[1 : len(segments)-1] {
+		if isHash(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHash(s string) bool {
+	letter, digit, isHex := false, false, true
+	for _, r := range s {
+		switch {
+		case '0' <= r && r <= '9':
+			digit = true
+		case 'a' <= r && r <= 'f':
+			letter = true
+		case 'A' <= r && r <= 'Z', 'g' <= r && r <= 'z', r == '_':
+			letter, isHex = true, false
+		default:
+			return false
+		}
+	}
+	if !letter || !digit {
+		return false
+	}
+	return isHex && len(s) >= 6 || len(s) >= 8
+}
@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/let-sh/cli/types"
+)
+
+func TestFingerprinted(t *testing.T) {
+	for name, want := range map[string]bool{
+		"app.3f2a1c.js":              true,
+		"static/js/main.8e6fa1b2.js": true,
+		"assets/index-BxK3a9Zq.js":   true,
+		"main.abcdef12.chunk.css":    true,
+		"app.js":                     false,
+		"3f2a1c.js":                  false,
+		"polyfill-es2015.js":         false,
+		"chunk-vendors.js":           false,
+		"report-20210304.pdf":        false,
+		"jquery-3.6.0.min.js":        false,
+	} {
+		if got := fingerprinted(name); got != want {
+			t.Errorf("fingerprinted(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestStaticHeaders(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	if err := ioutil.WriteFile(data, []byte("plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := newStaticHeaders([]types.HeaderRule{
+		{Source: "docs/**", Headers: map[string]string{"Cache-Control": "max-age=60", "Content-Language": "en"}},
+		{Source: "docs/legacy.html", Headers: map[string]string{"Cache-Control": ""}},
+	}, []string{"docs/index.html", "docs/legacy.html", "app.js", "app.js.gz", "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		key  string
+		want http.Header
+	}{
+		{"docs/index.html", http.Header{"Content-Type": {"text/html; charset=utf-8"},
+			"Cache-Control": {"max-age=60"}, "Content-Language": {"en"}}},
+		{"docs/legacy.html", http.Header{"Content-Type": {"text/html; charset=utf-8"}, "Content-Language": {"en"}}},
+		// prebuilt variant
+		{"app.js.gz", http.Header{"Content-Type": {"text/javascript; charset=utf-8"}, "Content-Encoding": {"gzip"}}},
+		{"data", http.Header{"Content-Type": {"text/plain; charset=utf-8"}}},
+	} {
+		got, err := h.header(c.key, filepath.Join(dir, filepath.FromSlash(c.key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("header(%q) = %v, want %v", c.key, got, c.want)
+		}
+	}
+
+	js := http.Header{"Content-Type": {"text/javascript; charset=utf-8"}}
+	if got := h.variants("app.js", js, 4096); len(got) != 1 || got[0].name != "br" {
+		t.Errorf("variants of app.js with prebuilt gzip = %v", got)
+	}
+	if got := h.variants("docs/main.js", js, 100); got != nil {
+		t.Errorf("variants of small file = %v", got)
+	}
+	if got := h.variants("logo.png", http.Header{"Content-Type": {"image/png"}}, 4096); got != nil {
+		t.Errorf("variants of png = %v", got)
+	}
+
+	if _, err := newStaticHeaders([]types.HeaderRule{{Source: "[z-a]"}}, nil); err == nil {
+		t.Error("invalid source should fail")
+	}
+}
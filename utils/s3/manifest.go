@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/let-sh/cli/types"
+)
+
+// relativeKey returns the slash separated path of file relative to dirPath, used as object key under bundle
+func relativeKey(dirPath, file string) (string, error) {
+	rel, err := filepath.Rel(dirPath, file)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// HashFiles returns the manifest of files under dirPath
+func HashFiles(dirPath, bundleID string, names []string) (types.StaticManifest, error) {
+	manifest := types.StaticManifest{BundleID: bundleID, Files: make(map[string]string, len(names))}
+	for _, name := range names {
+		key, err := relativeKey(dirPath, name)
+		if err != nil {
+			return manifest, err
+		}
+		hash, err := hashFile(name)
+		if err != nil {
+			return manifest, err
+		}
+		manifest.Files[key] = hash
+	}
+	return manifest, nil
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UnchangedFiles returns the files of current manifest with the same content in previous bundle,
+// they could be copied from previous bundle server side instead of uploading.
+func UnchangedFiles(previous, current types.StaticManifest) map[string]bool {
+	unchanged := map[string]bool{}
+	// objects are copied with their headers
+	if previous.BundleID == "" || previous.BundleID == current.BundleID || previous.Headers != current.Headers {
+		return unchanged
+	}
+	for key, hash := range current.Files {
+		if previous.Files[key] == hash {
+			unchanged[key] = true
+		}
+	}
+	return unchanged
+}
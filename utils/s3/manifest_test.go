@@ -0,0 +1,57 @@
+package s3
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/let-sh/cli/types"
+)
+
+func TestUnchangedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) string {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	names := []string{write("index.html", "index"), write("docs/a.html", "a"), write("docs/b.html", "b")}
+
+	previous, err := HashFiles(dir, "site-1", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous.Files) != 3 || previous.Files["docs/a.html"] == "" {
+		t.Fatalf("manifest = %+v", previous)
+	}
+
+	write("docs/b.html", "b changed")
+	names = append(names, write("docs/c.html", "c"))
+	current, err := HashFiles(dir, "site-2", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"index.html": true, "docs/a.html": true}
+	if got := UnchangedFiles(previous, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("unchanged = %v, want %v", got, want)
+	}
+
+	// nothing to copy without previous deployment, or from the same bundle
+	if got := UnchangedFiles(previous, previous); len(got) != 0 {
+		t.Errorf("unchanged of same bundle = %v", got)
+	}
+	if got := UnchangedFiles(types.StaticManifest{}, current); len(got) != 0 {
+		t.Errorf("unchanged without previous deployment = %v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/cache"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// uploadPartSize is the size of multipart upload parts, streams smaller than it are put directly
+	uploadPartSize int64 = 5 * 1024 * 1024
+	// retryAttempts is the max attempts of each request
+	retryAttempts = 4
+	// retryBackoff is the max wait before the first retry, doubled after each retry
+	retryBackoff = time.Second
+)
+
+// checkpointStore persists the checkpoint of upload, Get returns nil if not exists
+type checkpointStore struct {
+	Get    func() (*types.UploadCheckpoint, error)
+	Save   func(types.UploadCheckpoint) error
+	Remove func() error
+}
+
+// projectCheckpoint stores the checkpoint of project source upload under ~/.let
+func projectCheckpoint(projectName string) checkpointStore {
+	return checkpointStore{
+		Get: func() (*types.UploadCheckpoint, error) {
+			return cache.GetUploadCheckpoint(projectName)
+		},
+		Save: func(checkpoint types.UploadCheckpoint) error {
+			return cache.SaveUploadCheckpoint(projectName, checkpoint)
+		},
+		Remove: func() error {
+			return cache.RemoveUploadCheckpoint(projectName)
+		},
+	}
+}
+
+// resumableUpload uploads r to key in parts, the uploaded parts are saved in checkpoint.
+// If previous upload of project failed, the parts with the same content are skipped,
+// the object is uploaded to the key of previous upload then copied to key.
+// Once ctx is done, it stops after the part in flight is uploaded and saved, so it could be resumed.
+func resumableUpload(ctx context.Context, uploader Uploader, key string, r io.Reader, store checkpointStore) error {
+	buf := make([]byte, uploadPartSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// small enough to put directly
+		return retry(ctx, "put object", func() error {
+			return uploader.PutObject(key, bytes.NewReader(buf[:n]), int64(n), nil)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	checkpoint := resumeCheckpoint(uploader, store)
+	if checkpoint == nil {
+		var uploadID string
+		err := retry(ctx, "initiate multipart upload", func() (err error) {
+			uploadID, err = uploader.InitiateMultipartUpload(key)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		checkpoint = &types.UploadCheckpoint{Bucket: uploader.Bucket(), Key: key, UploadID: uploadID,
+			PartSize: uploadPartSize}
+	}
+
+	var parts []Part
+	for number := 1; ; number++ {
+		part, err := uploadPart(ctx, uploader, checkpoint, number, buf[:n])
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+		if err := store.Save(*checkpoint); err != nil {
+			logrus.WithError(err).Debugln("save upload checkpoint")
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+
+	err = retry(ctx, "complete multipart upload", func() error {
+		return uploader.CompleteMultipartUpload(checkpoint.Key, checkpoint.UploadID, parts)
+	})
+	if err != nil {
+		return err
+	}
+	if err := store.Remove(); err != nil {
+		logrus.WithError(err).Debugln("remove upload checkpoint")
+	}
+
+	// resumed the upload of another bundle
+	if checkpoint.Key != key {
+		err := retry(ctx, "copy object", func() error {
+			return uploader.CopyObject(checkpoint.Key, key)
+		})
+		if err != nil {
+			return err
+		}
+		if err := uploader.DeleteObject(checkpoint.Key); err != nil {
+			logrus.WithError(err).Debugln("delete resumed object")
+		}
+	}
+	return nil
+}
+
+// resumeCheckpoint returns the checkpoint of previous upload if still resumable,
+// parts not found on server are dropped, an unusable upload is aborted.
+func resumeCheckpoint(uploader Uploader, store checkpointStore) *types.UploadCheckpoint {
+	checkpoint, err := store.Get()
+	if err != nil {
+		logrus.WithError(err).Debugln("load upload checkpoint")
+		return nil
+	}
+	if checkpoint == nil || checkpoint.Bucket != uploader.Bucket() {
+		return nil
+	}
+	if checkpoint.PartSize != uploadPartSize {
+		uploader.AbortMultipartUpload(checkpoint.Key, checkpoint.UploadID)
+		return nil
+	}
+
+	uploaded, err := uploader.ListParts(checkpoint.Key, checkpoint.UploadID)
+	if err != nil {
+		// the upload is completed, aborted or expired
+		logrus.WithError(err).Debugln("list uploaded parts")
+		return nil
+	}
+	etags := map[int]string{}
+	for _, p := range uploaded {
+		etags[p.Number] = p.ETag
+	}
+	var parts []types.UploadedPart
+	for _, p := range checkpoint.Parts {
+		if etags[p.Number] == p.ETag {
+			parts = append(parts, p)
+		}
+	}
+	checkpoint.Parts = parts
+
+	logrus.WithFields(logrus.Fields{
+		"key":   checkpoint.Key,
+		"parts": len(parts),
+	}).Debug("resume multipart upload")
+	return checkpoint
+}
+
+// uploadPart uploads the part unless uploaded with the same content, the checkpoint is updated
+func uploadPart(ctx context.Context, uploader Uploader, checkpoint *types.UploadCheckpoint, number int,
+	content []byte) (Part, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	for _, p := range checkpoint.Parts {
+		if p.Number == number && p.SHA256 == hash {
+			logrus.Debugf("skip uploaded part %d", number)
+			return Part{Number: number, ETag: p.ETag, Size: p.Size}, nil
+		}
+	}
+
+	part := Part{Number: number, Size: int64(len(content))}
+	err := retry(ctx, "upload part", func() (err error) {
+		part.ETag, err = uploader.UploadPart(checkpoint.Key, checkpoint.UploadID, number, bytes.NewReader(content),
+			part.Size)
+		return err
+	})
+	if err != nil {
+		return part, err
+	}
+
+	uploaded := types.UploadedPart{Number: number, Size: part.Size, SHA256: hash, ETag: part.ETag}
+	for i, p := range checkpoint.Parts {
+		if p.Number == number {
+			checkpoint.Parts[i] = uploaded
+			return part, nil
+		}
+	}
+	checkpoint.Parts = append(checkpoint.Parts, uploaded)
+	return part, nil
+}
+
+// retry calls fn until it succeeds, fails with an unretryable error, runs out of attempts or ctx is done.
+// The backoff between attempts is doubled each time, and jittered so that workers don't retry together.
+func retry(ctx context.Context, name string, fn func() error) error {
+	backoff := retryBackoff
+	var err error
+	for attempt := 1; attempt <= retryAttempts; attempt++ {
+		if err = fn(); err == nil || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		logrus.WithError(err).Debugf("%s failed, attempt %d", name, attempt)
+		if attempt == retryAttempts {
+			break
+		}
+
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// retryable tells whether err is temporary, client errors of storage are not except timeout and throttling
+func retryable(err error) bool {
+	code, ok := statusCode(err)
+	if !ok {
+		return true
+	}
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return code >= 500
+}
@@ -0,0 +1,296 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/let-sh/cli/types"
+	"github.com/minio/minio-go/v7"
+)
+
+// fakeUploader keeps objects and multipart uploads in memory
+type fakeUploader struct {
+	objects map[string]string
+	// parts by upload id and part number
+	uploads map[string]map[int]string
+	// failures are returned by the next calls of UploadPart
+	failures []error
+	uploaded []int
+	copied   []string
+}
+
+func newFakeUploader() *fakeUploader {
+	return &fakeUploader{objects: map[string]string{}, uploads: map[string]map[int]string{}}
+}
+
+func (u *fakeUploader) Bucket() string {
+	return "bucket"
+}
+
+func (u *fakeUploader) PutObject(key string, r io.Reader, size int64, header http.Header) error {
+	content, err := ioutil.ReadAll(r)
+	u.objects[key] = string(content)
+	return err
+}
+
+func (u *fakeUploader) CopyObject(src, dst string) error {
+	u.objects[dst] = u.objects[src]
+	u.copied = append(u.copied, src+" -> "+dst)
+	return nil
+}
+
+func (u *fakeUploader) DeleteObject(key string) error {
+	delete(u.objects, key)
+	return nil
+}
+
+func (u *fakeUploader) InitiateMultipartUpload(key string) (string, error) {
+	id := fmt.Sprintf("upload-%d", len(u.uploads)+1)
+	u.uploads[id] = map[int]string{}
+	return id, nil
+}
+
+func (u *fakeUploader) UploadPart(key, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	if len(u.failures) > 0 {
+		err := u.failures[0]
+		u.failures = u.failures[1:]
+		if err != nil {
+			return "", err
+		}
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	u.uploads[uploadID][number] = string(content)
+	u.uploaded = append(u.uploaded, number)
+	return "etag-" + string(content), nil
+}
+
+func (u *fakeUploader) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	var content strings.Builder
+	for _, p := range parts {
+		content.WriteString(u.uploads[uploadID][p.Number])
+	}
+	u.objects[key] = content.String()
+	delete(u.uploads, uploadID)
+	return nil
+}
+
+func (u *fakeUploader) AbortMultipartUpload(key, uploadID string) error {
+	delete(u.uploads, uploadID)
+	return nil
+}
+
+func (u *fakeUploader) ListParts(key, uploadID string) ([]Part, error) {
+	uploaded, ok := u.uploads[uploadID]
+	if !ok {
+		return nil, minio.ErrorResponse{Code: "NoSuchUpload", StatusCode: http.StatusNotFound}
+	}
+	var parts []Part
+	for number, content := range uploaded {
+		parts = append(parts, Part{Number: number, ETag: "etag-" + content, Size: int64(len(content))})
+	}
+	return parts, nil
+}
+
+// memoryCheckpoint is a checkpointStore kept in memory
+type memoryCheckpoint struct {
+	checkpoint *types.UploadCheckpoint
+}
+
+func (m *memoryCheckpoint) store() checkpointStore {
+	return checkpointStore{
+		Get: func() (*types.UploadCheckpoint, error) { return m.checkpoint, nil },
+		Save: func(checkpoint types.UploadCheckpoint) error {
+			m.checkpoint = &checkpoint
+			return nil
+		},
+		Remove: func() error {
+			m.checkpoint = nil
+			return nil
+		},
+	}
+}
+
+// failingReader returns err after n bytes, as the tarball stream broken by network
+type failingReader struct {
+	r   io.Reader
+	n   int
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.n <= 0 {
+		return 0, f.err
+	}
+	if len(p) > f.n {
+		p = p[:f.n]
+	}
+	n, err := f.r.Read(p)
+	f.n -= n
+	return n, err
+}
+
+func setPartSize(t *testing.T, size int64) {
+	partSize, backoff := uploadPartSize, retryBackoff
+	uploadPartSize, retryBackoff = size, 0
+	t.Cleanup(func() { uploadPartSize, retryBackoff = partSize, backoff })
+}
+
+func TestResumableUploadSmall(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+
+	if err := resumableUpload(ctx, uploader, "a.tar.gz", strings.NewReader("abc"), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	if uploader.objects["a.tar.gz"] != "abc" || len(uploader.uploaded) != 0 {
+		t.Errorf("objects = %v, parts = %v", uploader.objects, uploader.uploaded)
+	}
+}
+
+func TestResumableUploadResume(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+	content := "aaaabbbbccccdd"
+
+	// broken after two parts
+	broken := errors.New("connection reset")
+	r := &failingReader{r: strings.NewReader(content), n: 9, err: broken}
+	if err := resumableUpload(ctx, uploader, "1.tar.gz", r, cp.store()); err != broken {
+		t.Fatalf("err = %v, want %v", err, broken)
+	}
+	if cp.checkpoint == nil || len(cp.checkpoint.Parts) != 2 {
+		t.Fatalf("checkpoint = %+v", cp.checkpoint)
+	}
+
+	// re-run with a new bundle, the same parts are skipped and the object is copied
+	uploader.uploaded = nil
+	if err := resumableUpload(ctx, uploader, "2.tar.gz", strings.NewReader(content), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(uploader.uploaded)
+	if fmt.Sprint(uploader.uploaded) != "[3 4]" {
+		t.Errorf("uploaded parts = %v, want [3 4]", uploader.uploaded)
+	}
+	if uploader.objects["2.tar.gz"] != content {
+		t.Errorf("object = %q, want %q", uploader.objects["2.tar.gz"], content)
+	}
+	if _, ok := uploader.objects["1.tar.gz"]; ok || len(uploader.copied) != 1 {
+		t.Errorf("objects = %v, copied = %v", uploader.objects, uploader.copied)
+	}
+	if cp.checkpoint != nil {
+		t.Errorf("checkpoint = %+v, want removed", cp.checkpoint)
+	}
+}
+
+// cancelReader cancels the upload after n bytes read, as Ctrl+C does
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if c.n -= n; c.n <= 0 {
+		c.cancel()
+	}
+	return n, err
+}
+
+func TestResumableUploadCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+	content := "aaaabbbbcccc"
+
+	// canceled while reading the second part, which is still uploaded and saved
+	r := &cancelReader{r: strings.NewReader(content), n: 6, cancel: cancel}
+	if err := resumableUpload(ctx, uploader, "a.tar.gz", r, cp.store()); err != context.Canceled {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if fmt.Sprint(uploader.uploaded) != "[1 2]" || cp.checkpoint == nil || len(cp.checkpoint.Parts) != 2 {
+		t.Fatalf("uploaded parts = %v, checkpoint = %+v", uploader.uploaded, cp.checkpoint)
+	}
+
+	uploader.uploaded = nil
+	if err := resumableUpload(context.Background(), uploader, "a.tar.gz", strings.NewReader(content),
+		cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(uploader.uploaded) != "[3]" || uploader.objects["a.tar.gz"] != content {
+		t.Errorf("uploaded parts = %v, object = %q", uploader.uploaded, uploader.objects["a.tar.gz"])
+	}
+}
+
+func TestResumableUploadChangedParts(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+
+	r := &failingReader{r: strings.NewReader("aaaabbbbcccc"), n: 9, err: errors.New("timeout")}
+	resumableUpload(ctx, uploader, "a.tar.gz", r, cp.store())
+
+	uploader.uploaded = nil
+	if err := resumableUpload(ctx, uploader, "a.tar.gz", strings.NewReader("aaaaxxxxcccc"), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(uploader.uploaded)
+	if fmt.Sprint(uploader.uploaded) != "[2 3]" {
+		t.Errorf("uploaded parts = %v, want [2 3]", uploader.uploaded)
+	}
+	if uploader.objects["a.tar.gz"] != "aaaaxxxxcccc" {
+		t.Errorf("object = %q", uploader.objects["a.tar.gz"])
+	}
+}
+
+func TestResumableUploadExpired(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+	cp.checkpoint = &types.UploadCheckpoint{Bucket: "bucket", Key: "old.tar.gz", UploadID: "expired", PartSize: 4,
+		Parts: []types.UploadedPart{{Number: 1, ETag: "etag-aaaa"}}}
+
+	if err := resumableUpload(ctx, uploader, "a.tar.gz", strings.NewReader("aaaabbbb"), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	if uploader.objects["a.tar.gz"] != "aaaabbbb" || len(uploader.copied) != 0 {
+		t.Errorf("objects = %v, copied = %v", uploader.objects, uploader.copied)
+	}
+}
+
+func TestResumableUploadRetry(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	uploader, cp := newFakeUploader(), &memoryCheckpoint{}
+	uploader.failures = []error{
+		errors.New("connection reset"),
+		minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError},
+	}
+	if err := resumableUpload(ctx, uploader, "a.tar.gz", strings.NewReader("aaaabbbb"), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	if uploader.objects["a.tar.gz"] != "aaaabbbb" {
+		t.Errorf("object = %q", uploader.objects["a.tar.gz"])
+	}
+
+	// client errors are not retried
+	denied := minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}
+	uploader.failures = []error{denied, nil}
+	err := resumableUpload(ctx, uploader, "b.tar.gz", strings.NewReader("aaaabbbb"), cp.store())
+	if code, _ := statusCode(err); code != http.StatusForbidden || len(uploader.failures) != 1 {
+		t.Errorf("err = %v, remaining failures = %d", err, len(uploader.failures))
+	}
+}
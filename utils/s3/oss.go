@@ -1,384 +1,101 @@
 package s3
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/aliyun/aliyun-oss-go-sdk/oss"
-	"github.com/let-sh/cli/log"
-	"github.com/let-sh/cli/requests"
-	ignore "github.com/sabhiram/go-gitignore"
-	"github.com/sirupsen/logrus"
-	"github.com/vbauerster/mpb/v7"
-	"github.com/vbauerster/mpb/v7/decor"
-	"os"
-	"path/filepath"
-	"runtime"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
-	"sync"
-	"time"
-)
 
-var bar *mpb.Bar
-var uploadStatus = make(map[string]fileUplaodStatus)
-var mutex = &sync.Mutex{}
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
 
-type fileUplaodStatus struct {
-	FilePath     string
-	ConsumedSize int64
-	TotalSize    int64
+// ossUploader uploads to Aliyun OSS, the host is like https://<bucket>.oss-cn-hangzhou.aliyuncs.com
+type ossUploader struct {
+	bucket *oss.Bucket
 }
 
-func UploadFileToCodeSource(filedir, filename, projectName string, cn bool) {
-	// create and start new bar
-	fi, _ := os.Stat(filedir)
-
-	file, _ := os.Open(filedir)
-	r := bufio.NewReader(file)
-
-	p := mpb.New(
-		mpb.WithWidth(64),
-		mpb.WithRefreshRate(200*time.Millisecond),
-	)
-
-	bar = p.AddBar(fi.Size(),
-		mpb.PrependDecorators(
-			decor.Name("uploading file: "),
-			//decor.Counters(decor.UnitKiB, "% .1f / % .1f"),
-		),
-
-		//mpb.NewBarFiller(mpb.BarStyle("[=>-|")),
-		mpb.PrependDecorators(
-			decor.CountersKiloByte("% .2f / % .2f"),
-		),
-		mpb.AppendDecorators(
-			decor.Percentage(),
-			decor.Name(" ] "),
-			//decor.EwmaETA(decor.ET_STYLE_GO, 90),
-			decor.EwmaSpeed(decor.UnitKB, "% .2f", 1024),
-		),
-		mpb.BarRemoveOnComplete(),
-	)
-	bar.SetTotal(fi.Size(), false)
-
-	stsToken, err := requests.GetStsToken("buildBundle", projectName, cn)
+func newOssUploader(token StsToken) (*ossUploader, error) {
+	u, err := url.Parse(token.Host)
 	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
+		return nil, err
 	}
-
-	// 创建OSSClient实例
-	endpoint := strings.Join(strings.Split(stsToken.Host, ".")[1:], ".")
-	client, err := oss.New(endpoint, stsToken.AccessKeyID, stsToken.AccessKeySecret, oss.SecurityToken(stsToken.SecurityToken))
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
+	labels := strings.SplitN(u.Host, ".", 2)
+	if len(labels) != 2 {
+		return nil, fmt.Errorf("invalid oss host %q", token.Host)
 	}
 
-	bucketName := strings.Replace(strings.Split(stsToken.Host, ".")[0], "https://", "", 1)
-
-	// 获取存储空间。
-	bucket, err := client.Bucket(bucketName)
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
-	}
-
-	// create proxy reader
-	proxyReader := bar.ProxyReader(r)
-	defer proxyReader.Close()
-
-	logrus.WithFields(logrus.Fields{
-		"objKey": filename,
-	}).Debug("put object from file")
-
-	err = bucket.PutObject(filename, proxyReader, oss.Progress(&OssProgressListener{}))
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
-	}
-	bar.Completed()
-}
-
-func UploadDirToStaticSource(dirPath, projectName, bundleID string, cn bool) error {
-	log.BPause()
-	uploadStatus = make(map[string]fileUplaodStatus)
-	stsToken, err := requests.GetStsToken("static", projectName, cn)
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
-	}
 	// 创建OSSClient实例
-	endpoint := strings.Join(strings.Split(stsToken.Host, ".")[1:], ".")
-	client, err := oss.New(endpoint, stsToken.AccessKeyID, stsToken.AccessKeySecret, oss.SecurityToken(stsToken.SecurityToken))
-
+	client, err := oss.New(u.Scheme+"://"+labels[1], token.AccessKeyID, token.AccessKeySecret,
+		oss.SecurityToken(token.SecurityToken))
 	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
+		return nil, err
 	}
-	bucketName := strings.Replace(strings.Split(stsToken.Host, ".")[0], "https://", "", 1)
 
 	// 获取存储空间。
-	bucket, err := client.Bucket(bucketName)
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(-1)
-	}
-
-	// Read directory files
-	var names []string
-	err = filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			names = append(names, path)
-		}
-		return nil
-	})
+	bucket, err := client.Bucket(labels[0])
 	if err != nil {
-		return err
-	}
-
-	// respect .gitignore and .letignore
-	if _, err := os.Stat(filepath.Join(dirPath, ".gitignore")); err == nil {
-		// match a file against a particular .gitignore
-		i, _ := ignore.CompileIgnoreFile(filepath.Join(dirPath, ".gitignore"))
-
-		tmp := []string{}
-		for _, v := range names {
-			if !i.MatchesPath(v) {
-				tmp = append(tmp, v)
-			}
-		}
-
-		names = tmp
-	}
-
-	// .letignore
-	if _, err := os.Stat(filepath.Join(dirPath + ".letignore")); err == nil {
-		// match a file against a particular .gitignore
-		i, _ := ignore.CompileIgnoreFile(filepath.Join(dirPath + ".letignore"))
-
-		tmp := []string{}
-		for _, v := range names {
-			if !i.MatchesPath(v) {
-				fi, _ := os.Stat(v)
-				mutex.Lock()
-				// register upload status
-				uploadStatus[v] = struct {
-					FilePath     string
-					ConsumedSize int64
-					TotalSize    int64
-				}{FilePath: fi.Name(), ConsumedSize: 0, TotalSize: fi.Size()}
-				mutex.Unlock()
-
-				tmp = append(tmp, v)
-			}
-		}
-
-		names = tmp
-	}
-
-	// fill in files info
-	var totalFilesSize int64
-	for _, v := range names {
-		fi, _ := os.Stat(v)
-		mutex.Lock()
-		// register upload status
-		uploadStatus[v] = struct {
-			FilePath     string
-			ConsumedSize int64
-			TotalSize    int64
-		}{FilePath: fi.Name(), ConsumedSize: 0, TotalSize: fi.Size()}
-
-		totalFilesSize += fi.Size()
-		mutex.Unlock()
-	}
-	status := uploadStatus
-	logrus.Debug(status)
-
-	p := mpb.New(
-		mpb.WithWidth(64),
-		mpb.WithRefreshRate(200*time.Millisecond),
-	)
-
-	// init progress bar
-	{
-		bar = p.AddBar(totalFilesSize,
-			mpb.PrependDecorators(
-				decor.Name("uploading files: "),
-				//decor.Counters(decor.UnitKiB, "% .1f / % .1f"),
-			),
-
-			//mpb.NewBarFiller(mpb.BarStyle("[=>-|")),
-			mpb.PrependDecorators(
-				decor.CountersKiloByte("% .2f / % .2f"),
-			),
-			mpb.AppendDecorators(
-				decor.Percentage(),
-				decor.Name(" ] "),
-				//decor.EwmaETA(decor.ET_STYLE_GO, 90),
-				//decor.EwmaSpeed(decor.UnitKB, "% .2f", 1024),
-			),
-			mpb.BarRemoveOnComplete(),
-		)
-		bar.SetTotal(totalFilesSize, false)
-	}
-
-	// Copy names to a channel for workers to consume. Close the
-	// channel so that workers stop when all work is complete.
-	namesChan := make(chan string, len(names))
-	for _, name := range names {
-		namesChan <- name
+		return nil, err
 	}
-	close(namesChan)
+	return &ossUploader{bucket: bucket}, nil
+}
 
-	// Create a maximum of 8 workers
+func (u *ossUploader) Bucket() string {
+	return u.bucket.BucketName
+}
 
-	workers := 8
-	if len(names) < workers {
-		workers = len(names)
+func (u *ossUploader) PutObject(key string, r io.Reader, size int64, header http.Header) error {
+	options := []oss.Option{oss.ContentLength(size)}
+	for k := range header {
+		options = append(options, oss.SetHeader(k, header.Get(k)))
 	}
+	return u.bucket.PutObject(key, r, options...)
+}
 
-	errChan := make(chan error, 1)
-	resChan := make(chan *error, len(names))
-
-	// Run workers
-
-	for i := 0; i < workers; i++ {
-		go func() {
-			// Consume work from namesChan. Loop will end when no more work.
-			for name := range namesChan {
-				if err != nil {
-					select {
-					case errChan <- err:
-						// will break parent goroutine out of loop
-					default:
-						// don't care, first error wins
-					}
-					return
-				}
-
-				objKey := filepath.Join(bundleID, strings.Replace(name, dirPath, "", 1))
-				filePath := name
-
-				// skip dir
-				fi, err := os.Stat(filePath)
+func (u *ossUploader) CopyObject(src, dst string) error {
+	_, err := u.bucket.CopyObject(src, dst)
+	return err
+}
 
-				if err != nil {
-					fmt.Println(err)
-					resChan <- &err
-					return
-				}
-				if fi.IsDir() {
-					resChan <- &err
-					return
-				}
+func (u *ossUploader) DeleteObject(key string) error {
+	return u.bucket.DeleteObject(key)
+}
 
-				logrus.WithFields(logrus.Fields{
-					"objKey":   objKey,
-					"filePath": filePath,
-				}).Debug("put object from file")
+func (u *ossUploader) InitiateMultipartUpload(key string) (string, error) {
+	imur, err := u.bucket.InitiateMultipartUpload(key)
+	return imur.UploadID, err
+}
 
-				// TODO:
-				// * check file exists in previous deployment
-				// * if matched etag, copy file
-				// * else upload file
-				err = bucket.PutObjectFromFile(func() string {
-					if runtime.GOOS == "windows" {
-						return filepath.ToSlash(objKey)
-					}
-					return objKey
-				}(), filePath, oss.Progress(&OssProgressListener{filepath: filePath, totalFilesSize: totalFilesSize, currentTime: time.Now()}))
-				if err != nil {
-					select {
-					case errChan <- err:
-						log.Error(err)
-						resChan <- &err
-						return
-						// will break parent goroutine out of loop
-					default:
-						// don't care, first error wins
-					}
-					return
-				}
-				resChan <- &err
-			}
-		}()
-	}
+func (u *ossUploader) UploadPart(key, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	part, err := u.bucket.UploadPart(u.upload(key, uploadID), r, size, number)
+	return part.ETag, err
+}
 
-	// Collect results from workers
-	for i := 0; i < len(names); i++ {
-		select {
-		case res := <-resChan:
-			// collect result
-			_ = res
-		case err := <-errChan:
-			return err
-		}
+func (u *ossUploader) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	var uploaded []oss.UploadPart
+	for _, p := range parts {
+		uploaded = append(uploaded, oss.UploadPart{PartNumber: p.Number, ETag: p.ETag})
 	}
-
-	bar.Completed()
-	bar.Abort(true)
-	log.BUnpause()
-	log.S.Suffix(" deploying ")
-	return nil
+	_, err := u.bucket.CompleteMultipartUpload(u.upload(key, uploadID), uploaded)
+	return err
 }
 
-type OssProgressListener struct {
-	filepath       string
-	totalFilesSize int64
-	currentTime    time.Time
+func (u *ossUploader) AbortMultipartUpload(key, uploadID string) error {
+	return u.bucket.AbortMultipartUpload(u.upload(key, uploadID))
 }
 
-func (listener *OssProgressListener) ProgressChanged(event *oss.ProgressEvent) {
-
-	switch event.EventType {
-
-	case oss.TransferStartedEvent:
-		//fmt.Printf("Transfer Started, ConsumedBytes: %d, TotalBytes %d.\n",
-		//	event.ConsumedBytes, event.TotalBytes)
-
-	case oss.TransferDataEvent:
-		UpdateUploadBar(listener.totalFilesSize)
-
-		mutex.Lock()
-		//todo: add uploading bar
-
-		bar.IncrBy(int(event.ConsumedBytes - uploadStatus[listener.filepath].ConsumedSize))
-
-		// add debounce
-
-		bar.DecoratorEwmaUpdate(time.Since(listener.currentTime))
-		listener.currentTime = time.Now()
-
-		uploadStatus[listener.filepath] = struct {
-			FilePath     string
-			ConsumedSize int64
-			TotalSize    int64
-		}{FilePath: listener.filepath, ConsumedSize: event.ConsumedBytes, TotalSize: event.TotalBytes}
-		mutex.Unlock()
-
-		//fmt.Printf("\rTransfer Data, ConsumedBytes: %d, TotalBytes %d, %d%%.",
-		//	event.ConsumedBytes, event.TotalBytes, event.ConsumedBytes*100/event.TotalBytes)
-
-	case oss.TransferCompletedEvent:
-		//fmt.Printf("\nTransfer Completed, ConsumedBytes: %d, TotalBytes %d.\n",
-		//	event.ConsumedBytes, event.TotalBytes)
-	case oss.TransferFailedEvent:
-		//fmt.Printf("\nTransfer Failed, ConsumedBytes: %d, TotalBytes %d.\n",
-		//	event.ConsumedBytes, event.TotalBytes)
-	default:
+func (u *ossUploader) ListParts(key, uploadID string) ([]Part, error) {
+	result, err := u.bucket.ListUploadedParts(u.upload(key, uploadID))
+	if err != nil {
+		return nil, err
 	}
-}
-
-func UpdateUploadBar(totalFilesSize int64) (totalConsumedSize int64) {
-	mutex.Lock()
-	for _, v := range uploadStatus {
-		totalConsumedSize += v.ConsumedSize
+	var parts []Part
+	for _, p := range result.UploadedParts {
+		parts = append(parts, Part{Number: p.PartNumber, ETag: p.ETag, Size: int64(p.Size)})
 	}
-	mutex.Unlock()
+	return parts, nil
+}
 
-	bar.SetCurrent(totalConsumedSize)
-	return totalConsumedSize
+func (u *ossUploader) upload(key, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{Bucket: u.bucket.BucketName, Key: key, UploadID: uploadID}
 }
@@ -0,0 +1,138 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+// defaultRegion is used when the region is not in host, e.g. MinIO
+const defaultRegion = "us-east-1"
+
+// s3Transport is the transport of S3 requests, nil for the default of minio
+var s3Transport http.RoundTripper
+
+func init() {
+	// requests are retried by the uploads, see retryAttempts
+	minio.MaxRetry = 1
+}
+
+// s3Uploader uploads to S3 compatible storage by minio.
+// The host is virtual hosted style like https://<bucket>.s3.us-west-2.amazonaws.com,
+// or path style like http://localhost:9000/<bucket> for MinIO.
+type s3Uploader struct {
+	bucket string
+	region string
+	client *minio.Core
+}
+
+func newS3Uploader(token StsToken) (*s3Uploader, error) {
+	u, err := url.Parse(token.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// the region is set, or minio requests the bucket location first
+	endpoint, bucket, lookup := u.Host, strings.Trim(u.Path, "/"), minio.BucketLookupPath
+	region := s3utils.GetRegionFromURL(url.URL{Host: u.Hostname()})
+	if bucket == "" {
+		labels := strings.SplitN(u.Host, ".", 2)
+		if len(labels) != 2 {
+			return nil, fmt.Errorf("invalid s3 host %q", token.Host)
+		}
+		bucket, endpoint, lookup = labels[0], labels[1], minio.BucketLookupDNS
+		region = s3utils.GetRegionFromURL(url.URL{Host: strings.SplitN(u.Hostname(), ".", 2)[1]})
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+
+	client, err := minio.NewCore(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(token.AccessKeyID, token.AccessKeySecret, token.SecurityToken),
+		Secure:       u.Scheme == "https",
+		Transport:    s3Transport,
+		Region:       region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Uploader{bucket: bucket, region: region, client: client}, nil
+}
+
+func (u *s3Uploader) Bucket() string {
+	return u.bucket
+}
+
+func (u *s3Uploader) PutObject(key string, r io.Reader, size int64, header http.Header) error {
+	// minio sends standard headers like Cache-Control as they are, others as x-amz-meta-*
+	metadata := map[string]string{}
+	for k := range header {
+		metadata[k] = header.Get(k)
+	}
+	_, err := u.client.PutObject(context.Background(), u.bucket, key, r, size, "", "", minio.PutObjectOptions{
+		ContentType:  header.Get("Content-Type"),
+		UserMetadata: metadata,
+	})
+	return err
+}
+
+func (u *s3Uploader) CopyObject(src, dst string) error {
+	_, err := u.client.CopyObject(context.Background(), u.bucket, src, u.bucket, dst, nil,
+		minio.CopySrcOptions{}, minio.PutObjectOptions{})
+	return err
+}
+
+func (u *s3Uploader) DeleteObject(key string) error {
+	return u.client.RemoveObject(context.Background(), u.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (u *s3Uploader) InitiateMultipartUpload(key string) (string, error) {
+	return u.client.NewMultipartUpload(context.Background(), u.bucket, key, minio.PutObjectOptions{})
+}
+
+func (u *s3Uploader) UploadPart(key, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	part, err := u.client.PutObjectPart(context.Background(), u.bucket, key, uploadID, number, r, size,
+		minio.PutObjectPartOptions{})
+	return part.ETag, err
+}
+
+func (u *s3Uploader) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	var completeParts []minio.CompletePart
+	for _, p := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	_, err := u.client.CompleteMultipartUpload(context.Background(), u.bucket, key, uploadID, completeParts,
+		minio.PutObjectOptions{})
+	return err
+}
+
+func (u *s3Uploader) AbortMultipartUpload(key, uploadID string) error {
+	return u.client.AbortMultipartUpload(context.Background(), u.bucket, key, uploadID)
+}
+
+func (u *s3Uploader) ListParts(key, uploadID string) ([]Part, error) {
+	var parts []Part
+	marker := 0
+	for {
+		result, err := u.client.ListObjectParts(context.Background(), u.bucket, key, uploadID, marker, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result.ObjectParts {
+			// listed etags are quoted, unlike the ones returned by UploadPart
+			parts = append(parts, Part{Number: p.PartNumber, ETag: strings.Trim(p.ETag, `"`), Size: p.Size})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
@@ -0,0 +1,252 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is an in-process S3 compatible server of path style, the credential is checked
+type fakeS3 struct {
+	t      *testing.T
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]string
+	// headers of objects stored with them
+	headers map[string]http.Header
+	// parts by upload id and part number
+	uploads  map[string]map[int]string
+	requests []string
+	copied   []string
+	// failures are the status responded to requests of keys
+	failures map[string]int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, StsToken) {
+	f := &fakeS3{t: t, bucket: "bucket", objects: map[string]string{},
+		headers: map[string]http.Header{}, uploads: map[string]map[int]string{}}
+	// minio signs the payload in chunks over http, the body is sent as it is over https
+	server := httptest.NewTLSServer(f)
+	t.Cleanup(server.Close)
+	transport := s3Transport
+	s3Transport = server.Client().Transport
+	t.Cleanup(func() { s3Transport = transport })
+	return f, StsToken{Host: server.URL + "/bucket", AccessKeyID: "id", AccessKeySecret: "secret",
+		SecurityToken: "token"}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.Contains(r.Header.Get("Authorization"), "Credential=id/") ||
+		r.Header.Get("X-Amz-Security-Token") != "token" {
+		f.fail(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")
+	query := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	f.requests = append(f.requests, r.Method+" "+key)
+	if status, ok := f.failures[key]; ok {
+		f.fail(w, status, http.StatusText(status))
+		return
+	}
+
+	switch uploadID := query.Get("uploadId"); {
+	case r.Method == http.MethodPut && query.Get("partNumber") != "":
+		parts, ok := f.uploads[uploadID]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[number] = string(body)
+		w.Header().Set("ETag", etag(string(body)))
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		src, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		src = strings.TrimPrefix(strings.TrimPrefix(src, "/"), f.bucket+"/")
+		content, ok := f.objects[src]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		f.objects[key] = content
+		f.headers[key] = f.headers[src]
+		f.copied = append(f.copied, key)
+		fmt.Fprint(w, "<CopyObjectResult></CopyObjectResult>")
+	case r.Method == http.MethodPut:
+		f.objects[key] = string(body)
+		f.headers[key] = http.Header{}
+		for _, name := range []string{"Content-Type", "Content-Encoding", "Cache-Control"} {
+			if v := r.Header.Get(name); v != "" {
+				f.headers[key].Set(name, v)
+			}
+		}
+		w.Header().Set("ETag", etag(string(body)))
+	case r.Method == http.MethodDelete && uploadID != "":
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := fmt.Sprintf("upload-%d", len(f.requests))
+		f.uploads[uploadID] = map[int]string{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>",
+			uploadID)
+	case r.Method == http.MethodPost && uploadID != "":
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		xml.Unmarshal(body, &complete)
+		var content strings.Builder
+		for _, p := range complete.Parts {
+			part := f.uploads[uploadID][p.PartNumber]
+			if strings.Trim(etag(part), `"`) != strings.Trim(p.ETag, `"`) {
+				f.fail(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			content.WriteString(part)
+		}
+		f.objects[key] = content.String()
+		delete(f.uploads, uploadID)
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key></CompleteMultipartUploadResult>",
+			f.bucket, key)
+	case r.Method == http.MethodGet && uploadID != "":
+		parts, ok := f.uploads[uploadID]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		fmt.Fprint(w, "<ListPartsResult>")
+		for number, content := range parts {
+			fmt.Fprintf(w, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag><Size>%d</Size></Part>",
+				number, etag(content), len(content))
+		}
+		fmt.Fprint(w, "</ListPartsResult>")
+	default:
+		f.fail(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, http.StatusText(status))
+}
+
+// etag is the quoted md5 as S3 returns
+func etag(content string) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum([]byte(content)))
+}
+
+func TestNewS3Uploader(t *testing.T) {
+	tests := []struct {
+		host, endpoint, bucket, region string
+	}{
+		{"https://site.s3.us-west-2.amazonaws.com", "https://s3.us-west-2.amazonaws.com", "site", "us-west-2"},
+		{"https://site.s3.amazonaws.com", "https://s3.amazonaws.com", "site", "us-east-1"},
+		{"http://localhost:9000/site", "http://localhost:9000", "site", "us-east-1"},
+	}
+	for _, tt := range tests {
+		u, err := newS3Uploader(StsToken{Host: tt.host})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.client.EndpointURL().String() != tt.endpoint || u.bucket != tt.bucket || u.region != tt.region {
+			t.Errorf("uploader of %s = %s %+v", tt.host, u.client.EndpointURL(), u)
+		}
+	}
+}
+
+func TestS3Uploader(t *testing.T) {
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "bundle/static/a b+c.html"
+	if err := uploader.PutObject(key, strings.NewReader("hello"), 5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploader.CopyObject(key, "next/a.html"); err != nil {
+		t.Fatal(err)
+	}
+	if f.objects[key] != "hello" || f.objects["next/a.html"] != "hello" {
+		t.Errorf("objects = %v", f.objects)
+	}
+	if err := uploader.CopyObject("missing", "next/b.html"); err == nil {
+		t.Error("copy missing object should fail")
+	}
+
+	uploadID, err := uploader.InitiateMultipartUpload("source.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []Part
+	for i, content := range []string{"aaaa", "bb"} {
+		etag, err := uploader.UploadPart("source.tar.gz", uploadID, i+1, strings.NewReader(content),
+			int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, Part{Number: i + 1, ETag: etag})
+	}
+	listed, err := uploader.ListParts("source.tar.gz", uploadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Number < listed[j].Number })
+	if len(listed) != 2 || listed[0].ETag != parts[0].ETag || listed[1].Size != 2 {
+		t.Errorf("listed parts = %+v", listed)
+	}
+	if err := uploader.CompleteMultipartUpload("source.tar.gz", uploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if f.objects["source.tar.gz"] != "aaaabb" {
+		t.Errorf("object = %q", f.objects["source.tar.gz"])
+	}
+
+	// the completed upload is gone
+	_, err = uploader.ListParts("source.tar.gz", uploadID)
+	if code, ok := statusCode(err); !ok || code != http.StatusNotFound {
+		t.Errorf("list parts of completed upload: %v", err)
+	}
+	if retryable(err) {
+		t.Errorf("%v should not be retried", err)
+	}
+
+	if err := uploader.DeleteObject(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.objects[key]; ok {
+		t.Errorf("object %s is not deleted", key)
+	}
+}
+
+func TestS3UploaderAccessDenied(t *testing.T) {
+	_, token := newFakeS3(t)
+	token.AccessKeyID = "wrong"
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uploader.PutObject("a.html", strings.NewReader("a"), 1, nil)
+	if code, ok := statusCode(err); !ok || code != http.StatusForbidden {
+		t.Errorf("err = %v, want forbidden", err)
+	}
+}
@@ -0,0 +1,47 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBurst is the max bytes read at once under bandwidth limit
+const maxBurst = 32 * 1024
+
+// newLimiter returns the limiter of bandwidth in bytes per second, nil if unlimited
+func newLimiter(bandwidth int64) *rate.Limiter {
+	if bandwidth <= 0 {
+		return nil
+	}
+	burst := maxBurst
+	if bandwidth < int64(burst) {
+		burst = int(bandwidth)
+	}
+	return rate.NewLimiter(rate.Limit(bandwidth), burst)
+}
+
+// throttledReader stops reading once ctx is done, and waits for limiter before returning read bytes.
+// The limiter is shared by all readers of an upload, nil if unlimited.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if err := t.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if t.limiter != nil && len(p) > t.limiter.Burst() {
+		p = p[:t.limiter.Burst()]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 && t.limiter != nil {
+		if err := t.limiter.WaitN(t.ctx, n); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
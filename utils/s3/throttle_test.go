@@ -0,0 +1,34 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestThrottledReader(t *testing.T) {
+	content := make([]byte, 2*maxBurst)
+	r := &throttledReader{ctx: context.Background(), r: bytes.NewReader(content), limiter: newLimiter(4 * maxBurst)}
+
+	// the first burst is read at once, the rest waits for 1/4 second
+	start := time.Now()
+	read, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(content) {
+		t.Errorf("read %d bytes, want %d", len(read), len(content))
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("read in %s, want limited", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = &throttledReader{ctx: ctx, r: bytes.NewReader(content)}
+	if _, err := r.Read(make([]byte, 1)); err != context.Canceled {
+		t.Errorf("read after canceled: %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/let-sh/cli/log"
+	"github.com/let-sh/cli/types"
+	"github.com/let-sh/cli/utils/cache"
+	"github.com/let-sh/cli/utils/ignore"
+	"github.com/sirupsen/logrus"
+	"github.com/vbauerster/mpb/v7"
+	"github.com/vbauerster/mpb/v7/decor"
+	"golang.org/x/time/rate"
+)
+
+// DefaultConcurrency is the number of static files uploaded at the same time by default
+const DefaultConcurrency = 8
+
+// UploadOptions tunes the uploads
+type UploadOptions struct {
+	// Concurrency is the number of static files uploaded at the same time, default to DefaultConcurrency
+	Concurrency int
+	// MaxBandwidth limits the upload speed in bytes per second, unlimited if zero
+	MaxBandwidth int64
+	// Headers overrides the headers of static files
+	Headers []types.HeaderRule
+}
+
+func (o UploadOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
+
+// UploadError lists the static files failed to upload after retries
+type UploadError struct {
+	Failed []FailedFile
+}
+
+// FailedFile is a static file failed to upload, Path is relative to the static dir
+type FailedFile struct {
+	Path string
+	Err  error
+}
+
+func (e *UploadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to upload %d files:", len(e.Failed))
+	for _, f := range e.Failed {
+		fmt.Fprintf(&b, "\n  %s: %s", f.Path, f.Err)
+	}
+	return b.String()
+}
+
+// UploadFileToCodeSource uploads the source tarball read from r,
+// size is the estimated size for progress bar, the bar completes when r is drained.
+func UploadFileToCodeSource(ctx context.Context, r io.Reader, size int64, filename, projectName string, cn bool,
+	opts UploadOptions) error {
+	p := mpb.New(
+		mpb.WithOutput(log.ProgressWriter()),
+		mpb.WithWidth(64),
+		mpb.WithRefreshRate(200*time.Millisecond),
+	)
+
+	bar := p.AddBar(size,
+		mpb.PrependDecorators(
+			decor.Name("uploading file: "),
+			//decor.Counters(decor.UnitKiB, "% .1f / % .1f"),
+		),
+
+		//mpb.NewBarFiller(mpb.BarStyle("[=>-|")),
+		mpb.PrependDecorators(
+			decor.CountersKiloByte("% .2f / % .2f"),
+		),
+		mpb.AppendDecorators(
+			decor.Percentage(),
+			decor.Name(" ] "),
+			//decor.EwmaETA(decor.ET_STYLE_GO, 90),
+			decor.EwmaSpeed(decor.UnitKB, "% .2f", 1024),
+		),
+		mpb.BarRemoveOnComplete(),
+	)
+
+	uploader, err := NewUploader("buildBundle", projectName, cn)
+	if err != nil {
+		return err
+	}
+
+	// create proxy reader
+	proxyReader := bar.ProxyReader(&throttledReader{ctx: ctx, r: r, limiter: newLimiter(opts.MaxBandwidth)})
+	defer proxyReader.Close()
+
+	logrus.WithFields(logrus.Fields{
+		"objKey": filename,
+	}).Debug("put object from reader")
+
+	err = resumableUpload(ctx, uploader, filename, proxyReader, projectCheckpoint(projectName))
+	if err != nil {
+		return err
+	}
+	// compressed size is less than estimated
+	bar.SetTotal(-1, true)
+	return nil
+}
+
+func UploadDirToStaticSource(ctx context.Context, dirPath, projectName, bundleID string, cn bool,
+	opts UploadOptions) error {
+	log.BPause()
+	uploader, err := NewUploader("static", projectName, cn)
+	if err != nil {
+		return err
+	}
+	return uploadDir(ctx, uploader, dirPath, projectName, bundleID, opts)
+}
+
+// uploadDir uploads files of dirPath under bundleID, unchanged files are copied from previous bundle.
+// A file failed after retries doesn't stop others, they are reported in *UploadError at the end.
+func uploadDir(ctx context.Context, uploader Uploader, dirPath, projectName, bundleID string,
+	opts UploadOptions) error {
+	// respect .gitignore and .letignore
+	files, err := ignore.List(dirPath)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Join(dirPath, filepath.FromSlash(f)))
+	}
+
+	headers, err := newStaticHeaders(opts.Headers, files)
+	if err != nil {
+		return err
+	}
+
+	// files unchanged since previous deployment are copied server side
+	manifest, err := HashFiles(dirPath, bundleID, names)
+	if err != nil {
+		return err
+	}
+	manifest.Headers = headers.digest
+	previous, err := cache.GetStaticManifest(uploader.Bucket(), projectName)
+	if err != nil {
+		logrus.Debug("load static manifest: ", err)
+	}
+	unchanged := UnchangedFiles(previous, manifest)
+	logrus.WithFields(logrus.Fields{
+		"previous":  previous.BundleID,
+		"files":     len(names),
+		"unchanged": len(unchanged),
+	}).Debug("diff static files")
+	log.Event("static_diff", map[string]interface{}{
+		"files":     len(names),
+		"unchanged": len(unchanged),
+	})
+
+	var totalFilesSize int64
+	for _, v := range names {
+		fi, err := os.Stat(v)
+		if err != nil {
+			return err
+		}
+		if key, _ := relativeKey(dirPath, v); !unchanged[key] {
+			totalFilesSize += fi.Size()
+		}
+	}
+
+	p := mpb.New(
+		mpb.WithOutput(log.ProgressWriter()),
+		mpb.WithWidth(64),
+		mpb.WithRefreshRate(200*time.Millisecond),
+	)
+
+	// init progress bar
+	bar := p.AddBar(totalFilesSize,
+		mpb.PrependDecorators(
+			decor.Name("uploading files: "),
+			//decor.Counters(decor.UnitKiB, "% .1f / % .1f"),
+		),
+
+		//mpb.NewBarFiller(mpb.BarStyle("[=>-|")),
+		mpb.PrependDecorators(
+			decor.CountersKiloByte("% .2f / % .2f"),
+		),
+		mpb.AppendDecorators(
+			decor.Percentage(),
+			decor.Name(" ] "),
+			//decor.EwmaETA(decor.ET_STYLE_GO, 90),
+			//decor.EwmaSpeed(decor.UnitKB, "% .2f", 1024),
+		),
+		mpb.BarRemoveOnComplete(),
+	)
+	bar.SetTotal(totalFilesSize, false)
+
+	u := dirUpload{
+		uploader:  uploader,
+		limiter:   newLimiter(opts.MaxBandwidth),
+		bar:       bar,
+		headers:   headers,
+		dirPath:   dirPath,
+		bundleID:  bundleID,
+		previous:  previous.BundleID,
+		unchanged: unchanged,
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		failed []FailedFile
+	)
+	namesChan := make(chan string)
+	workers := opts.concurrency()
+	if len(names) < workers {
+		workers = len(names)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Consume work from namesChan. Loop will end when no more work.
+			for name := range namesChan {
+				key, err := u.upload(ctx, name)
+				if err != nil && ctx.Err() == nil {
+					mutex.Lock()
+					failed = append(failed, FailedFile{Path: key, Err: err})
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, name := range names {
+		select {
+		case namesChan <- name:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(namesChan)
+	wg.Wait()
+
+	bar.Abort(true)
+	p.Wait()
+	log.BUnpause()
+	log.S.Suffix(" deploying ")
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		sort.Slice(failed, func(i, j int) bool { return failed[i].Path < failed[j].Path })
+		log.Event("upload_failed", map[string]interface{}{
+			"files": len(failed),
+		})
+		return &UploadError{Failed: failed}
+	}
+
+	if err := cache.SaveStaticManifest(uploader.Bucket(), projectName, manifest); err != nil {
+		logrus.Debug("save static manifest: ", err)
+	}
+	return nil
+}
+
+// dirUpload uploads a file of static dir
+type dirUpload struct {
+	uploader Uploader
+	limiter  *rate.Limiter
+	// bar is the progress of this upload, shared by workers
+	bar      *mpb.Bar
+	headers  *staticHeaders
+	dirPath  string
+	bundleID string
+	// previous is the bundle id of previous deployment, unchanged files are copied from it
+	previous  string
+	unchanged map[string]bool
+}
+
+// upload uploads the file with retries, returns the key relative to static dir
+func (u dirUpload) upload(ctx context.Context, name string) (string, error) {
+	key, err := relativeKey(u.dirPath, name)
+	if err != nil {
+		return name, err
+	}
+	objKey := path.Join(u.bundleID, key)
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return key, err
+	}
+	header, err := u.headers.header(key, name)
+	if err != nil {
+		return key, err
+	}
+	variants := u.headers.variants(key, header, fi.Size())
+
+	if u.unchanged[key] {
+		err := retry(ctx, "copy object", func() error {
+			return u.copy(key, variants)
+		})
+		if err == nil {
+			return key, nil
+		}
+		// previous bundle may be expired, fallback to upload
+		logrus.WithFields(logrus.Fields{
+			"objKey": objKey,
+			"error":  err,
+		}).Debug("copy object from previous bundle")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"objKey":   objKey,
+		"filePath": name,
+	}).Debug("put object from file")
+	err = retry(ctx, "put object", func() error {
+		return u.putFile(ctx, objKey, name, header)
+	})
+	if err != nil {
+		return key, err
+	}
+
+	for _, e := range variants {
+		content, err := e.compressFile(name)
+		if err != nil {
+			return key, err
+		}
+		variantHeader := header.Clone()
+		variantHeader.Set("Content-Encoding", e.name)
+		err = retry(ctx, "put object", func() error {
+			r := &throttledReader{ctx: ctx, r: bytes.NewReader(content), limiter: u.limiter}
+			return u.uploader.PutObject(objKey+e.suffix, r, int64(len(content)), variantHeader)
+		})
+		if err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
+
+// copy copies the file and its precompressed variants from previous bundle
+func (u dirUpload) copy(key string, variants []encoding) error {
+	suffixes := []string{""}
+	for _, e := range variants {
+		suffixes = append(suffixes, e.suffix)
+	}
+	for _, suffix := range suffixes {
+		err := u.uploader.CopyObject(path.Join(u.previous, key)+suffix, path.Join(u.bundleID, key)+suffix)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putFile uploads the file to key, the progress is added to bar and rolled back if failed
+func (u dirUpload) putFile(ctx context.Context, key, filePath string, header http.Header) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	r := &progressReader{r: &throttledReader{ctx: ctx, r: f, limiter: u.limiter}, bar: u.bar}
+	err = u.uploader.PutObject(key, r, fi.Size(), header)
+	if err != nil {
+		u.bar.IncrInt64(-r.read)
+	}
+	return err
+}
+
+// progressReader adds read bytes to bar
+type progressReader struct {
+	r    io.Reader
+	bar  *mpb.Bar
+	read int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	p.bar.IncrBy(n)
+	return n, err
+}
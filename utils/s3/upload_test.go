@@ -0,0 +1,313 @@
+package s3
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/let-sh/cli/types"
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestNewUploader(t *testing.T) {
+	tests := []struct {
+		host string
+		want Uploader
+	}{
+		{"https://site.oss-cn-hangzhou.aliyuncs.com", &ossUploader{}},
+		{"https://site-1250000000.cos.ap-guangzhou.myqcloud.com", &cosUploader{}},
+		{"http://localhost:9000/site", &s3Uploader{}},
+	}
+	for _, tt := range tests {
+		u, err := newUploader(StsToken{Host: tt.host, AccessKeyID: "id", AccessKeySecret: "secret"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.TypeOf(u) != reflect.TypeOf(tt.want) {
+			t.Errorf("uploader of %s = %T, want %T", tt.host, u, tt.want)
+		}
+		if !strings.HasPrefix(u.Bucket(), "site") {
+			t.Errorf("bucket of %s = %s", tt.host, u.Bucket())
+		}
+	}
+
+	if _, err := newUploader(StsToken{Host: "site.oss-cn-hangzhou.aliyuncs.com"}); err == nil {
+		t.Error("host without scheme should fail")
+	}
+}
+
+// setHome points ~/.let of cache to a temp dir
+func setHome(t *testing.T) {
+	disableCache := homedir.DisableCache
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = disableCache })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+}
+
+func TestUploadDir(t *testing.T) {
+	ctx := context.Background()
+	setHome(t)
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", "<html></html>")
+	write("assets/app.js", "app")
+	write(".env", "SECRET=1")
+
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-1", UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"bundle-1/index.html": "<html></html>", "bundle-1/assets/app.js": "app"}
+	if !reflect.DeepEqual(f.objects, want) {
+		t.Errorf("objects = %v, want %v", f.objects, want)
+	}
+
+	// unchanged files are copied from previous bundle
+	write("assets/app.js", "app changed")
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-2", UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if f.objects["bundle-2/assets/app.js"] != "app changed" || f.objects["bundle-2/index.html"] != "<html></html>" {
+		t.Errorf("objects = %v", f.objects)
+	}
+	if !reflect.DeepEqual(f.copied, []string{"bundle-2/index.html"}) {
+		t.Errorf("copied = %v", f.copied)
+	}
+}
+
+func TestUploadDirOtherBucket(t *testing.T) {
+	ctx := context.Background()
+	setHome(t)
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-1", UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous bundle is in the bucket of another region, it can't be copied from
+	other, otherToken := newFakeS3(t)
+	other.bucket = "other"
+	otherToken.Host = strings.TrimSuffix(otherToken.Host, "/bucket") + "/other"
+	otherUploader, err := newS3Uploader(otherToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadDir(ctx, otherUploader, dir, "site", "bundle-2", UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(other.copied) != 0 || other.objects["bundle-2/index.html"] != "index" {
+		t.Errorf("copied = %v, objects = %v", other.copied, other.objects)
+	}
+
+	// the manifest of each bucket is kept
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-3", UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.copied, []string{"bundle-3/index.html"}) {
+		t.Errorf("copied = %v", f.copied)
+	}
+}
+
+func TestUploadDirPrecompressed(t *testing.T) {
+	ctx := context.Background()
+	setHome(t)
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	script := strings.Repeat("console.log('let.sh');\n", 100)
+	for name, content := range map[string]string{
+		"index.html":           "<html></html>",
+		"assets/app.3f2a1c.js": script,
+		"logo.png":             "\x89PNG\r\n\x1a\n",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := UploadOptions{Headers: []types.HeaderRule{
+		{Source: "*.png", Headers: map[string]string{"Cache-Control": "max-age=60"}},
+	}}
+
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-1", opts); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]http.Header{
+		"bundle-1/index.html": {"Content-Type": {"text/html; charset=utf-8"}, "Cache-Control": {"no-cache"}},
+		"bundle-1/assets/app.3f2a1c.js": {"Content-Type": {"text/javascript; charset=utf-8"},
+			"Cache-Control": {immutableCacheControl}},
+		"bundle-1/assets/app.3f2a1c.js.br": {"Content-Type": {"text/javascript; charset=utf-8"},
+			"Cache-Control": {immutableCacheControl}, "Content-Encoding": {"br"}},
+		"bundle-1/assets/app.3f2a1c.js.gz": {"Content-Type": {"text/javascript; charset=utf-8"},
+			"Cache-Control": {immutableCacheControl}, "Content-Encoding": {"gzip"}},
+		"bundle-1/logo.png": {"Content-Type": {"image/png"}, "Cache-Control": {"max-age=60"}},
+	} {
+		if got := f.headers[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("headers of %s = %v, want %v", key, got, want)
+		}
+	}
+	if len(f.objects) != 5 {
+		t.Errorf("objects = %v", f.objects)
+	}
+	gz, err := gzip.NewReader(strings.NewReader(f.objects["bundle-1/assets/app.3f2a1c.js.gz"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadAll(gz)
+	if string(content) != script {
+		t.Errorf("decompressed gzip variant = %q", content)
+	}
+	content, _ = ioutil.ReadAll(brotli.NewReader(strings.NewReader(f.objects["bundle-1/assets/app.3f2a1c.js.br"])))
+	if string(content) != script {
+		t.Errorf("decompressed brotli variant = %q", content)
+	}
+
+	// variants are copied with the file
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-2", opts); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(f.copied)
+	want := []string{"bundle-2/assets/app.3f2a1c.js", "bundle-2/assets/app.3f2a1c.js.br",
+		"bundle-2/assets/app.3f2a1c.js.gz", "bundle-2/index.html", "bundle-2/logo.png"}
+	if !reflect.DeepEqual(f.copied, want) {
+		t.Errorf("copied = %v, want %v", f.copied, want)
+	}
+
+	// files are uploaded again with changed rules
+	f.copied = nil
+	opts.Headers[0].Headers["Cache-Control"] = "max-age=120"
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle-3", opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.copied) != 0 || f.headers["bundle-3/logo.png"].Get("Cache-Control") != "max-age=120" {
+		t.Errorf("copied = %v, headers = %v", f.copied, f.headers["bundle-3/logo.png"])
+	}
+}
+
+func TestUploadDirFailures(t *testing.T) {
+	setHome(t)
+	setPartSize(t, 4)
+	ctx := context.Background()
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.failures = map[string]int{
+		"bundle/a.html": http.StatusInternalServerError,
+		"bundle/b.html": http.StatusForbidden,
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.html", "b.html", "c.html"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = uploadDir(ctx, uploader, dir, "site", "bundle", UploadOptions{Concurrency: 2})
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) {
+		t.Fatalf("err = %v, want *UploadError", err)
+	}
+	var failed []string
+	for _, file := range uploadErr.Failed {
+		failed = append(failed, file.Path)
+	}
+	if !reflect.DeepEqual(failed, []string{"a.html", "b.html"}) {
+		t.Errorf("failed = %v", failed)
+	}
+	if f.objects["bundle/c.html"] != "c.html" {
+		t.Errorf("objects = %v", f.objects)
+	}
+
+	// server errors are retried, client errors are not
+	attempts := map[string]int{}
+	for _, r := range f.requests {
+		attempts[r]++
+	}
+	if attempts["PUT bundle/a.html"] != retryAttempts || attempts["PUT bundle/b.html"] != 1 {
+		t.Errorf("attempts = %v", attempts)
+	}
+}
+
+func TestUploadDirCanceled(t *testing.T) {
+	setHome(t)
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := uploadDir(ctx, uploader, dir, "site", "bundle", UploadOptions{}); err != context.Canceled {
+		t.Errorf("err = %v, want canceled", err)
+	}
+	if len(f.objects) != 0 {
+		t.Errorf("objects = %v", f.objects)
+	}
+}
+
+func TestResumableUploadS3(t *testing.T) {
+	ctx := context.Background()
+	setPartSize(t, 4)
+	f, token := newFakeS3(t)
+	uploader, err := newS3Uploader(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := &memoryCheckpoint{}
+
+	content := "aaaabbbbcc"
+	r := &failingReader{r: strings.NewReader(content), n: 5, err: os.ErrDeadlineExceeded}
+	if err := resumableUpload(ctx, uploader, "1.tar.gz", r, cp.store()); err == nil {
+		t.Fatal("upload of broken stream should fail")
+	}
+	if err := resumableUpload(ctx, uploader, "2.tar.gz", strings.NewReader(content), cp.store()); err != nil {
+		t.Fatal(err)
+	}
+	if f.objects["2.tar.gz"] != content || len(f.uploads) != 0 {
+		t.Errorf("objects = %v, uploads = %v", f.objects, f.uploads)
+	}
+	if _, ok := f.objects["1.tar.gz"]; ok {
+		t.Error("object of resumed upload is not deleted")
+	}
+}
@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/let-sh/cli/requests"
+	"github.com/minio/minio-go/v7"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// Uploader puts objects to a bucket of let.sh storage,
+// the implementation is chosen by the host of sts token, see NewUploader.
+type Uploader interface {
+	// Bucket returns the bucket name, checkpoints of other buckets are not resumed
+	Bucket() string
+	// PutObject uploads size bytes read from r to key, header is stored with the object and served with it,
+	// e.g. Content-Type and Cache-Control
+	PutObject(key string, r io.Reader, size int64, header http.Header) error
+	// CopyObject copies src to dst in the same bucket
+	CopyObject(src, dst string) error
+	DeleteObject(key string) error
+
+	InitiateMultipartUpload(key string) (uploadID string, err error)
+	// UploadPart uploads size bytes read from r as part number of the upload, returns its etag
+	UploadPart(key, uploadID string, number int, r io.Reader, size int64) (etag string, err error)
+	CompleteMultipartUpload(key, uploadID string, parts []Part) error
+	AbortMultipartUpload(key, uploadID string) error
+	// ListParts returns the uploaded parts, fails if the upload is completed, aborted or expired
+	ListParts(key, uploadID string) ([]Part, error)
+}
+
+// Part is an uploaded part of multipart upload
+type Part struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// StsToken is the temporary credential of bucket, Host is the url of bucket
+type StsToken struct {
+	Host            string `json:"host"`
+	AccessKeyID     string `json:"accessKeyID"`
+	AccessKeySecret string `json:"accessKeySecret"`
+	SecurityToken   string `json:"securityToken"`
+}
+
+// NewUploader requests the sts token of uploadType, e.g. static or buildBundle, returns the uploader of its bucket
+func NewUploader(uploadType, projectName string, cn bool) (Uploader, error) {
+	stsToken, err := requests.GetStsToken(uploadType, projectName, cn)
+	if err != nil {
+		return nil, err
+	}
+	return newUploader(stsToken)
+}
+
+// newUploader returns the uploader by host of token:
+// *.aliyuncs.com for Aliyun OSS, *.myqcloud.com for Tencent COS, others are S3 compatible.
+func newUploader(token StsToken) (Uploader, error) {
+	u, err := url.Parse(token.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid storage host %q", token.Host)
+	}
+
+	hostname := u.Hostname()
+	switch {
+	case strings.HasSuffix(hostname, ".aliyuncs.com"):
+		return newOssUploader(token)
+	case strings.HasSuffix(hostname, ".myqcloud.com"):
+		return newCosUploader(token)
+	default:
+		return newS3Uploader(token)
+	}
+}
+
+// statusCode returns the http status of a request failed by storage service, false if not responded
+func statusCode(err error) (int, bool) {
+	var ossErr oss.ServiceError
+	if errors.As(err, &ossErr) {
+		return ossErr.StatusCode, true
+	}
+	var cosErr *cos.ErrorResponse
+	if errors.As(err, &cosErr) && cosErr.Response != nil {
+		return cosErr.Response.StatusCode, true
+	}
+	var s3Err minio.ErrorResponse
+	if errors.As(err, &s3Err) && s3Err.StatusCode != 0 {
+		return s3Err.StatusCode, true
+	}
+	return 0, false
+}
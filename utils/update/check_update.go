@@ -13,6 +13,11 @@ import (
 )
 
 func CheckUpdate() {
+	// never prompt in json output mode
+	if log.JSON() {
+		return
+	}
+
 	// check update every day
 	if time.Since(config.GetLastUpdateNotifyTime()) < time.Hour*24 {
 		return
@@ -157,6 +157,7 @@ func DownloadBinaryCompressedFile(filename, tempDir string) error {
 	}
 
 	p := mpb.New(
+		mpb.WithOutput(log.ProgressWriter()),
 		mpb.WithWidth(64),
 		mpb.WithRefreshRate(200*time.Millisecond),
 	)